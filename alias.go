@@ -0,0 +1,57 @@
+package tinycli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// aliasParam matches the positional parameters of a user-defined alias.
+var aliasParam = regexp.MustCompile(`\$[1-9]`)
+
+// lookupAlias returns the definition of the user-defined alias name of c, if
+// name is not the name or alias of one of its subcommands.
+func (c *invocation[P]) lookupAlias(e *Env[P], name string) (def string, ok bool, err error) {
+	if c.UserAliases == nil {
+		return "", false, nil
+	}
+	if _, isSub := c.subcommandIndex().names[name]; isSub {
+		return "", false, nil
+	}
+	aliases, err := c.UserAliases(e)
+	if err != nil {
+		return "", false, fmt.Errorf("loading aliases: %w", err)
+	}
+	def, ok = aliases[name]
+	return def, ok, nil
+}
+
+// expandAlias returns the args of the alias definition def, split as by
+// [SplitArgs], with $1 through $9 replaced by the corresponding args, and the
+// args following the highest referenced one appended.
+func expandAlias(def string, args []string) ([]string, error) {
+	words, err := SplitArgs(def)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("empty definition")
+	}
+	used := 0
+	expanded := make([]string, 0, len(words)+len(args))
+	for _, w := range words {
+		expanded = append(expanded, aliasParam.ReplaceAllStringFunc(w, func(param string) string {
+			n, _ := strconv.Atoi(param[1:])
+			used = max(used, n)
+			if n > len(args) {
+				return param
+			}
+			return args[n-1]
+		}))
+	}
+	if used > len(args) {
+		return nil, fmt.Errorf("requires %s, received %d", pluralArgs(used), len(args))
+	}
+	return append(expanded, args[used:]...), nil
+}
@@ -0,0 +1,131 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_userAliases(t *testing.T) {
+	type params struct {
+		interactive bool
+		branch      string
+	}
+	cmdFactory := func(aliases map[string]string, loadErr error, got *string) *cli.Command[*params] {
+		return &cli.Command[*params]{
+			Name:  "root",
+			Usage: "root usage",
+			UserAliases: func(e *cli.Env[*params]) (map[string]string, error) {
+				return aliases, loadErr
+			},
+			Subcommands: []*cli.Command[*params]{
+				{
+					Name: "checkout",
+					Flags: func(fs *flag.FlagSet, p *params) {
+						fs.BoolVar(&p.interactive, "interactive", false, "interactive mode")
+						fs.StringVar(&p.branch, "b", "", "new branch")
+					},
+					Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+						*got = fmt.Sprintf("checkout interactive=%t b=%q args=%q", e.Params.interactive, e.Params.branch, e.Args)
+						return cli.ExitSuccess
+					},
+				},
+				{
+					Name: "status",
+					Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+						*got = "status"
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	aliases := map[string]string{
+		"co":     "checkout --interactive",
+		"nb":     "checkout -b '$1 draft' main",
+		"swap":   "checkout $2 $1",
+		"status": "checkout",
+		"bad":    "checkout 'main",
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		loadErr    error
+		wantGot    string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:    "expand",
+			args:    []string{"root", "co", "main"},
+			wantGot: `checkout interactive=true b="" args=["main"]`,
+		},
+		{
+			name:    "params",
+			args:    []string{"root", "nb", "feature", "extra"},
+			wantGot: `checkout interactive=false b="feature draft" args=["main" "extra"]`,
+		},
+		{
+			name:    "params_reordered",
+			args:    []string{"root", "swap", "a", "b", "c"},
+			wantGot: `checkout interactive=false b="" args=["b" "a" "c"]`,
+		},
+		{
+			name:    "subcommand_precedence",
+			args:    []string{"root", "status"},
+			wantGot: "status",
+		},
+		{
+			name:       "missing_params",
+			args:       []string{"root", "swap", "a"},
+			wantErrbuf: "root usage\nalias \"swap\": requires 2 args, received 1\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "invalid_definition",
+			args:       []string{"root", "bad"},
+			wantErrbuf: "root usage\nalias \"bad\": unterminated quote\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "load_error",
+			args:       []string{"root", "co"},
+			loadErr:    errors.New("bad config"),
+			wantErrbuf: "root usage\nloading aliases: bad config\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "unknown",
+			args:       []string{"root", "nope"},
+			wantErrbuf: "root usage\nunknown command\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				errbuf bytes.Buffer
+				got    string
+			)
+			e := &cli.Env[*params]{Args: tt.args, Err: &errbuf, Params: &params{}}
+			if status := cmdFactory(aliases, tt.loadErr, &got).Execute(t.Context(), e); status != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", status, tt.wantStatus)
+			}
+			if got != tt.wantGot {
+				t.Errorf("executed %q, want %q", got, tt.wantGot)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tinycli
+
+import "fmt"
+
+// NoArgs is an [ArgsFunc] that accepts no positional args.
+func NoArgs(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("accepts no args, received %d", len(args))
+	}
+	return nil
+}
+
+// ExactArgs returns an [ArgsFunc] that accepts exactly n positional args.
+func ExactArgs(n int) ArgsFunc {
+	return func(args []string) error {
+		if len(args) != n {
+			return fmt.Errorf("accepts %s, received %d", pluralArgs(n), len(args))
+		}
+		return nil
+	}
+}
+
+// MinArgs returns an [ArgsFunc] that accepts at least n positional args.
+func MinArgs(n int) ArgsFunc {
+	return func(args []string) error {
+		if len(args) < n {
+			return fmt.Errorf("requires at least %s, received %d", pluralArgs(n), len(args))
+		}
+		return nil
+	}
+}
+
+// MaxArgs returns an [ArgsFunc] that accepts at most n positional args.
+func MaxArgs(n int) ArgsFunc {
+	return func(args []string) error {
+		if len(args) > n {
+			return fmt.Errorf("accepts at most %s, received %d", pluralArgs(n), len(args))
+		}
+		return nil
+	}
+}
+
+// RangeArgs returns an [ArgsFunc] that accepts between min and max positional
+// args, inclusive.
+func RangeArgs(min, max int) ArgsFunc {
+	return func(args []string) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("accepts between %d and %d args, received %d", min, max, len(args))
+		}
+		return nil
+	}
+}
+
+// AllArgs returns an [ArgsFunc] that applies each of fns in order, returning
+// the first error.
+func AllArgs(fns ...ArgsFunc) ArgsFunc {
+	return func(args []string) error {
+		for _, fn := range fns {
+			if err := fn(args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func pluralArgs(n int) string {
+	if n == 1 {
+		return "1 arg"
+	}
+	return fmt.Sprintf("%d args", n)
+}
@@ -0,0 +1,96 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestArgsFuncs(t *testing.T) {
+	errOdd := errors.New("odd")
+	even := func(args []string) error {
+		if len(args)%2 != 0 {
+			return errOdd
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name    string
+		fn      cli.ArgsFunc
+		args    []string
+		wantErr string
+	}{
+		{name: "no_args", fn: cli.NoArgs},
+		{name: "no_args_err", fn: cli.NoArgs, args: []string{"a"}, wantErr: "accepts no args, received 1"},
+		{name: "exact", fn: cli.ExactArgs(1), args: []string{"a"}},
+		{name: "exact_err", fn: cli.ExactArgs(2), args: []string{"a"}, wantErr: "accepts 2 args, received 1"},
+		{name: "min", fn: cli.MinArgs(1), args: []string{"a", "b"}},
+		{name: "min_err", fn: cli.MinArgs(1), wantErr: "requires at least 1 arg, received 0"},
+		{name: "max", fn: cli.MaxArgs(2), args: []string{"a", "b"}},
+		{name: "max_err", fn: cli.MaxArgs(1), args: []string{"a", "b"}, wantErr: "accepts at most 1 arg, received 2"},
+		{name: "range", fn: cli.RangeArgs(1, 2), args: []string{"a"}},
+		{name: "range_err", fn: cli.RangeArgs(1, 2), args: []string{"a", "b", "c"}, wantErr: "accepts between 1 and 2 args, received 3"},
+		{name: "all", fn: cli.AllArgs(cli.MinArgs(1), even), args: []string{"a", "b"}},
+		{name: "all_err", fn: cli.AllArgs(cli.MinArgs(1), even), args: []string{"a"}, wantErr: "odd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr string
+			if err := tt.fn(tt.args); err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("args func error = %q, want %q", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_args(t *testing.T) {
+	cmd := &cli.Command[any]{
+		Name:  "root",
+		Usage: "root usage",
+		Args:  cli.ExactArgs(1),
+		Subcommands: []*cli.Command[any]{
+			{
+				Name:   "sub",
+				Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus { return cli.ExitSuccess },
+			},
+		},
+		Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus { return cli.ExitSuccess },
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{name: "valid", args: []string{"root", "a"}, wantStatus: cli.ExitSuccess},
+		{name: "subcommand", args: []string{"root", "sub", "x", "y"}, wantStatus: cli.ExitSuccess},
+		{
+			name:       "invalid",
+			args:       []string{"root", "a", "b"},
+			wantErrbuf: "root usage\naccepts 1 arg, received 2\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			if got := cmd.Execute(t.Context(), &cli.Env[any]{Args: tt.args, Err: &errbuf}); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
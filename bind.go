@@ -0,0 +1,159 @@
+package tinycli
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// BindFlags defines a flag on fs for each tagged field of the struct pointed to
+// by v, binding the flag to the field.
+//
+// A field is bound when it has a `cli` tag naming its flag, and may also have
+// an `env` tag naming its env var, a `default` tag with its default value as
+// text, and a `usage` tag with its usage text:
+//
+//	type params struct {
+//		Port uint `cli:"port" env:"FOO_PORT" default:"5000" usage:"port number"`
+//	}
+//
+// Fields may be strings, bools, ints, uints, floats, durations, [flag.Value]
+// implementations, or [encoding.TextUnmarshaler] implementations. An env tag
+// takes precedence over a Command's VarsFunc and VarPrefix, but not its Vars.
+func BindFlags(fs *flag.FlagSet, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("binding flags: %T is not a pointer to a struct", v)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		sf := rt.Field(i)
+		name, ok := sf.Tag.Lookup("cli")
+		if !ok || name == "-" {
+			continue
+		}
+		if err := bindField(fs, rv.Field(i), sf, name); err != nil {
+			return fmt.Errorf("binding flag -%s to field %s: %w", name, sf.Name, err)
+		}
+	}
+	return nil
+}
+
+// AutoFlags returns a [FlagsFunc] that binds the tagged fields of the params
+// struct with [BindFlags]. It panics if a field cannot be bound, as the flag
+// package does when a flag is redefined.
+func AutoFlags[P any]() FlagsFunc[P] {
+	return func(fs *flag.FlagSet, params P) {
+		if err := BindFlags(fs, params); err != nil {
+			panic(err)
+		}
+	}
+}
+
+var (
+	errUnexportedField = errors.New("field is not exported")
+	errUnsupportedType = errors.New("unsupported field type")
+)
+
+func bindField(fs *flag.FlagSet, fv reflect.Value, sf reflect.StructField, name string) error {
+	if !sf.IsExported() {
+		return errUnexportedField
+	}
+	usage := sf.Tag.Get("usage")
+	p := fv.Addr().Interface()
+
+	switch p := p.(type) {
+	case flag.Value:
+		fs.Var(p, name, usage)
+	case encoding.TextUnmarshaler:
+		fs.Var(textValue{p}, name, usage)
+	case *time.Duration:
+		fs.DurationVar(p, name, 0, usage)
+	case *string:
+		fs.StringVar(p, name, "", usage)
+	case *bool:
+		fs.BoolVar(p, name, false, usage)
+	case *int:
+		fs.IntVar(p, name, 0, usage)
+	case *int64:
+		fs.Int64Var(p, name, 0, usage)
+	case *uint:
+		fs.UintVar(p, name, 0, usage)
+	case *uint64:
+		fs.Uint64Var(p, name, 0, usage)
+	case *float64:
+		fs.Float64Var(p, name, 0, usage)
+	default:
+		return fmt.Errorf("%w %s", errUnsupportedType, fv.Type())
+	}
+
+	f := fs.Lookup(name)
+	if def, ok := sf.Tag.Lookup("default"); ok {
+		if err := f.Value.Set(def); err != nil {
+			return fmt.Errorf("invalid default %q: %w", def, err)
+		}
+		f.DefValue = f.Value.String()
+	}
+	if varName, ok := sf.Tag.Lookup("env"); ok {
+		f.Value = &boundValue{Value: f.Value, varName: varName}
+	}
+	return nil
+}
+
+// A textValue is a flag.Value bound to a TextUnmarshaler field, which unlike
+// the flag package's TextVar keeps the field's current value as its default.
+type textValue struct {
+	p encoding.TextUnmarshaler
+}
+
+func (v textValue) String() string {
+	if m, ok := v.p.(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	return ""
+}
+
+func (v textValue) Set(s string) error {
+	return v.p.UnmarshalText([]byte(s))
+}
+
+func (v textValue) Get() any {
+	return reflect.ValueOf(v.p).Elem().Interface()
+}
+
+// A boundValue is a flag.Value bound to an env var by a struct tag.
+type boundValue struct {
+	flag.Value
+	varName string
+}
+
+func (v *boundValue) IsBoolFlag() bool {
+	return isBoolFlag(v.Value)
+}
+
+func (v *boundValue) Get() any {
+	if g, ok := v.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.Value.String()
+}
+
+// unwrapValue returns the value underlying a tag-bound or validated value.
+func unwrapValue(v flag.Value) flag.Value {
+	for {
+		switch w := v.(type) {
+		case *boundValue:
+			v = w.Value
+		case *validatedValue:
+			v = w.Value
+		default:
+			return v
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package tinycli_test
+
+import (
+	"context"
+	"flag"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestAutoFlags(t *testing.T) {
+	type p struct {
+		Port    uint          `cli:"port" env:"FOO_PORT" default:"5000" usage:"port number"`
+		Host    string        `cli:"host" default:"localhost"`
+		Verbose bool          `cli:"v" env:"FOO_VERBOSE"`
+		Timeout time.Duration `cli:"timeout" default:"5s"`
+		Ratio   float64       `cli:"ratio"`
+		Addr    netip.Addr    `cli:"addr" default:"127.0.0.1"`
+		Level   string        `cli:"log-level"`
+		Ignored string
+		Skipped string `cli:"-"`
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:      "root",
+			Usage:     "root usage",
+			Flags:     cli.AutoFlags[*p](),
+			VarPrefix: "APP_",
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				return cli.ExitSuccess
+			},
+		}
+	}
+
+	tests := []tc[*p]{
+		{
+			name: "defaults",
+			args: []string{"root"},
+
+			wantParams: &p{
+				Port:    5000,
+				Host:    "localhost",
+				Timeout: 5 * time.Second,
+				Addr:    netip.MustParseAddr("127.0.0.1"),
+			},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "flags_and_vars",
+			args: []string{"root", "-v", "-host=example.com", "-addr=::1", "-ratio=0.5"},
+			vars: map[string]string{
+				"FOO_PORT":      "8080",
+				"APP_LOG_LEVEL": "debug",
+				"APP_PORT":      "1",
+			},
+
+			wantParams: &p{
+				Port:    8080,
+				Host:    "example.com",
+				Verbose: true,
+				Timeout: 5 * time.Second,
+				Ratio:   0.5,
+				Addr:    netip.MustParseAddr("::1"),
+				Level:   "debug",
+			},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "invalid_bound_var",
+			args: []string{"root"},
+			vars: map[string]string{
+				"FOO_VERBOSE": "maybe",
+			},
+
+			wantErrbuf: "root usage\ninvalid boolean value \"maybe\" for $FOO_VERBOSE: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params p
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(), &params, tt)
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("cmd.Execute()=%v, want %v", got, want)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("cmd.Execute() err buffer mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantParams != nil {
+				if diff := cmp.Diff(tt.wantParams, gotParams, cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); diff != "" {
+					t.Errorf("cmd.Execute() params mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestBindFlags_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       any
+		wantErr string
+	}{
+		{
+			name:    "not_struct_pointer",
+			v:       struct{}{},
+			wantErr: "is not a pointer to a struct",
+		},
+		{
+			name: "unsupported",
+			v: &struct {
+				C chan int `cli:"c"`
+			}{},
+			wantErr: "binding flag -c to field C: unsupported field type chan int",
+		},
+		{
+			name: "unexported",
+			v: &struct {
+				s string `cli:"s"`
+			}{},
+			wantErr: "binding flag -s to field s: field is not exported",
+		},
+		{
+			name: "invalid_default",
+			v: &struct {
+				N int `cli:"n" default:"x"`
+			}{},
+			wantErr: `binding flag -n to field N: invalid default "x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cli.BindFlags(flag.NewFlagSet("test", flag.ContinueOnError), tt.v)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("BindFlags() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package tinycli
+
+import (
+	"flag"
+	"path"
+	"slices"
+	"strings"
+)
+
+// A ChildEnvOptions configures the environment built by [Env.ChildEnv].
+type ChildEnvOptions struct {
+	Allow  []string // names or path.Match patterns of vars to keep; empty keeps all
+	Deny   []string // names or path.Match patterns of vars to remove
+	Inject bool     // whether to add resolved flag values under their bound var names
+}
+
+// ChildEnv returns an environment for subprocesses, in the "key=value" form
+// used by [os/exec.Cmd], built from the Env Vars.
+//
+// Vars not matched by opts.Allow, vars matched by opts.Deny, and vars whose
+// values contain a secret known to the Env [Redactor] are removed. When
+// opts.Inject is set, the current values of the flags of executed commands
+// are added under their bound var names, subject to the same filters and
+// except for secret flags, so that children observe the resolved
+// configuration. An injected var replaces any var whose name matches it under
+// the Env's VarCase policy, such as Path for PATH when folding.
+func (e *Env[P]) ChildEnv(opts ChildEnvOptions) []string {
+	fold := e.foldVars()
+	keep := func(name string) bool {
+		if len(opts.Allow) > 0 && !matchVarName(opts.Allow, name, fold) {
+			return false
+		}
+		return !matchVarName(opts.Deny, name, fold)
+	}
+	vars := make(map[string]string, len(e.Vars))
+	for name, value := range e.Vars {
+		if keep(name) {
+			vars[name] = value
+		}
+	}
+
+	if opts.Inject {
+		for _, c := range e.cmds {
+			if c.fs == nil {
+				continue
+			}
+			c.fs.VisitAll(func(f *flag.Flag) {
+				if slices.Contains(c.Secrets, f.Name) {
+					return
+				}
+				varName, ok := c.lookupVarName(f.Name)
+				if !ok || !keep(varName) {
+					return
+				}
+				if fold {
+					for name := range vars {
+						if strings.EqualFold(name, varName) {
+							delete(vars, name)
+						}
+					}
+				}
+				vars[varName] = f.Value.String()
+			})
+		}
+	}
+
+	environ := make([]string, 0, len(vars))
+	for name, value := range vars {
+		if e.Redactor.Redact(value) != value {
+			continue
+		}
+		environ = append(environ, name+"="+value)
+	}
+	slices.Sort(environ)
+	return environ
+}
+
+func matchVarName(patterns []string, name string, fold bool) bool {
+	for _, pattern := range patterns {
+		if fold {
+			pattern, name = strings.ToUpper(pattern), strings.ToUpper(name)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package tinycli_test
+
+import (
+	"context"
+	"flag"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_ChildEnv(t *testing.T) {
+	type p struct {
+		Region string
+		Token  string
+		Port   int
+	}
+
+	vars := map[string]string{
+		"HOME":         "/home/gopher",
+		"PATH":         "/usr/bin",
+		"AWS_SECRET":   "hunter2",
+		"APP_TOKEN":    "s3cr3t",
+		"APP_REGION":   "us-east-1",
+		"GITHUB_TOKEN": "ghp_abc123",
+		"COPY":         "token=s3cr3t",
+	}
+
+	run := func(t *testing.T, opts cli.ChildEnvOptions, args ...string) []string {
+		t.Helper()
+
+		redactor := &cli.Redactor{}
+		redactor.AddPattern(regexp.MustCompile(`ghp_[a-z0-9]+`))
+
+		var environ []string
+		cmd := &cli.Command[*p]{
+			Name:      "root",
+			VarPrefix: "APP_",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.Region, "region", "", "")
+				fs.StringVar(&p.Token, "token", "", "")
+				fs.IntVar(&p.Port, "port", 8080, "")
+			},
+			Secrets: []string{"token"},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				environ = e.ChildEnv(opts)
+				return cli.ExitSuccess
+			},
+		}
+		cmd.Execute(t.Context(), &cli.Env[*p]{
+			Args:     append([]string{"root"}, args...),
+			Vars:     vars,
+			Params:   &p{},
+			Redactor: redactor,
+		})
+		return environ
+	}
+
+	t.Run("secrets_filtered", func(t *testing.T) {
+		want := []string{
+			"APP_REGION=us-east-1",
+			"AWS_SECRET=hunter2",
+			"HOME=/home/gopher",
+			"PATH=/usr/bin",
+		}
+		if diff := cmp.Diff(want, run(t, cli.ChildEnvOptions{})); diff != "" {
+			t.Errorf("env.ChildEnv() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("allow_deny", func(t *testing.T) {
+		opts := cli.ChildEnvOptions{
+			Allow: []string{"HOME", "PATH", "AWS_*", "APP_*"},
+			Deny:  []string{"AWS_*"},
+		}
+		want := []string{
+			"APP_REGION=us-east-1",
+			"HOME=/home/gopher",
+			"PATH=/usr/bin",
+		}
+		if diff := cmp.Diff(want, run(t, opts)); diff != "" {
+			t.Errorf("env.ChildEnv() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("inject", func(t *testing.T) {
+		opts := cli.ChildEnvOptions{
+			Allow:  []string{"PATH", "APP_PORT"},
+			Inject: true,
+		}
+		want := []string{
+			"APP_PORT=9090",
+			"PATH=/usr/bin",
+		}
+		if diff := cmp.Diff(want, run(t, opts, "-port=9090", "-region=eu-west-1")); diff != "" {
+			t.Errorf("env.ChildEnv() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("inject_fold", func(t *testing.T) {
+		var environ []string
+		cmd := &cli.Command[*p]{
+			Name:    "root",
+			VarCase: cli.MatchFold,
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.Region, "region", "", "")
+			},
+			Vars: map[string]string{"region": "REGION"},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				environ = e.ChildEnv(cli.ChildEnvOptions{Allow: []string{"region"}, Inject: true})
+				return cli.ExitSuccess
+			},
+		}
+		cmd.Execute(t.Context(), &cli.Env[*p]{
+			Args:   []string{"root", "-region=eu-west-1"},
+			Vars:   map[string]string{"Region": "us-east-1", "PATH": "/usr/bin"},
+			Params: &p{},
+		})
+		want := []string{"REGION=eu-west-1"}
+		if diff := cmp.Diff(want, environ); diff != "" {
+			t.Errorf("env.ChildEnv() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
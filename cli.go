@@ -23,6 +23,7 @@ The execution environment of a Command is encapsulated in an [Env]. The default
 env looks like:
 
 	e := Env[T]{
+	  In:     os.Stdin,
 	  Err:    os.Stderr,
 	  Out:    os.Stdout,
 	  Args:   ok.Args,
@@ -30,6 +31,64 @@ env looks like:
 	  Params: params // parameter object of type T
 	}
 
+The default env also describes the terminal connected to os.Stdout, if any,
+with its size and color support in Env.Terminal. Together with
+[Env.IsTerminal], this lets actions adapt their output to interactive and
+piped use.
+
+[Env.Successf], [Env.Infof], and [Env.Warnf] write messages in color when the
+stream is a terminal, following the NO_COLOR and CLICOLOR conventions. Setting
+the Env Color to ColorAlways or ColorNever overrides detection, for example in
+tests.
+
+[StandardFlags] defines the common -q, -quiet, -v, -no-color, and -log-format
+flags, which the Env helpers respect across every command that defines them or
+inherits them as Persistent flags: quiet suppresses Successf and Infof, -v
+enables Debugf, and [Env.Logger] applies the log format and verbosity:
+
+	c := Command[*p]{
+		Flags: func(fs *flag.FlagSet, p *p) {
+			StandardFlags(fs, &p.opts)
+		},
+		Persistent: []string{"q", "quiet", "v", "no-color", "log-format"},
+	}
+
+Commands that print results may define -o and -output flags with [OutputFlag],
+and write results with [Env.Emit], which marshals them as JSON, YAML, or an
+aligned table, or executes a Go template such as -o go-template='{{.Name}}',
+as selected by the user:
+
+	c := Command[*p]{
+		Flags: func(fs *flag.FlagSet, p *p) {
+			OutputFlag(fs, nil, "table")
+		},
+		Persistent: []string{"o", "output"},
+		ActionE: func(ctx context.Context, e *Env[*p]) error {
+			return e.Emit(listServers())
+		},
+	}
+
+An ActionR action instead returns a [Result], which the framework renders:
+its Data is written by Emit, and its Message is written unless quiet, to the
+error output stream when a machine-readable format is selected:
+
+	c := Command[*p]{
+		ActionR: func(ctx context.Context, e *Env[*p]) (Result, error) {
+			s, err := createServer(ctx)
+			return Result{Message: "created " + s.Name, Data: s}, err
+		},
+	}
+
+Listing commands may also write tables directly with [Env.Table], which
+aligns tab-separated columns, truncates lines to the terminal width, and omits
+its header row when the -no-headers flag defined by [NoHeadersFlag] is set:
+
+	t := e.Table("NAME", "PORT")
+	for _, s := range servers {
+		fmt.Fprintf(t, "%s\t%d\n", s.Name, s.Port)
+	}
+	return t.Flush()
+
 The generic parameter type is usually a pointer to a struct, with fields that
 can be bound to command-line flags via a [flag.FlagSet]. A Command may be
 configured with a Flags func that does the work of defining and binding
@@ -60,11 +119,203 @@ variables:
 		},
 	}
 
+For bindings that follow a convention or are only known at runtime, a Command
+may instead be configured with a VarsFunc, which is consulted for flags without
+an entry in Vars:
+
+	c := Command[*p]{
+		VarsFunc: func(flagName string) (string, bool) {
+			return "FOO_" + strings.ToUpper(flagName), true
+		},
+	}
+
+Flags without an entry in Vars may also be bound by naming convention. A
+Command with a VarPrefix binds each remaining flag to the prefix followed by
+the upper snake case flag name. Prefixes accumulate down the tree, so a
+subcommand may declare its own namespace layered over its parent's:
+
+	c := Command[*p]{
+		VarPrefix: "FOO_",
+		Subcommands: []*Command[*p]{
+			{
+				Name:      "serve",
+				VarPrefix: "SERVE_", // -port binds to $FOO_SERVE_PORT
+			},
+		},
+	}
+
+The same convention is available as a VarsFunc from [AutoVars]. In every case,
+explicit Vars entries take precedence.
+
+Flags and env var bindings may also be declared with struct tags on the params
+type, and bound with [AutoFlags]:
+
+	type p struct {
+		Port uint `cli:"port" env:"FOO_PORT" default:"5000" usage:"port number"`
+	}
+
+	c := Command[*p]{
+		Flags: AutoFlags[*p](),
+	}
+
+A Command may also be configured with a Config func that loads values from
+configuration, such as a file or the Windows registry, keyed by flag name:
+
+	c := Command[*p]{
+		Config: func(e *Env[*p]) (map[string]string, error) {
+			return map[string]string{"port": "8080"}, nil
+		},
+	}
+
+[JSONConfig] loads such values from a JSON file, and on Windows,
+RegistryConfig loads them from registry keys. [FindConfigFile] locates such a
+file in the platform's user configuration directory, given by [ConfigDir],
+following the XDG conventions on Unix systems, and [CacheDir] and [StateDir]
+locate directories for cached files and persistent state:
+
+	path, err := FindConfigFile("foo", "config.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		dir, _ := ConfigDir("foo")
+		path = filepath.Join(dir, "config.json")
+	}
+	c := Command[*p]{
+		Config: JSONConfig[*p](path),
+	}
+
 The precedence of flag sources is:
 
  1. User command-line flags
  2. Environment variables
- 3. Flag default values
+ 3. Config values
+ 4. Flag default values
+
+A Command's Precedence reorders the first three, for it and its subcommands,
+for example so env vars override flags baked into a container image's
+entrypoint:
+
+	c := Command[*p]{
+		Precedence: []Source{SourceVar, SourceFlag, SourceConfig},
+	}
+
+Hooks and actions can query which of these a flag's value was resolved from
+with [Env.Source], for example to warn when a sensitive value was given on the
+command line.
+
+Setting the CLI_DEBUG env var to a true value, or the -debug-cli flag defined
+by [DebugFlag], traces resolution to the error output stream: each matched
+subcommand and expanded alias, and the value and source of every flag, with
+secrets redacted:
+
+	debug: foo serve: -port=80 (var $FOO_PORT)
+
+Adding the Command returned by [ShowConfigCommand] to a tree prints the value
+and source of every flag for a command line, without running its action, as
+in "foo show-config serve -port 80".
+
+[Command.Resolve] resolves a command line without running it, returning the
+selected command, the remaining args, and the value and source of each flag,
+for tools such as GUI wrappers or parser fuzz tests. Errors that Execute would
+report are returned instead.
+
+[SaveConfig] persists the values of a Resolution that are not defaults to a
+file read by JSONConfig, leaving out secrets and env var values, so a "foo
+config save serve -port 80" command can keep values experimented with on the
+command line:
+
+	save.ActionE = func(ctx context.Context, e *Env[*p]) error {
+		re := *e
+		re.Args = append([]string{"foo"}, e.Args...)
+		r, err := root.Resolve(&re)
+		if err != nil {
+			return err
+		}
+		return SaveConfig(configPath, r)
+	}
+
+Hooks and actions shared between commands can find where they run with
+[Env.CommandPath], such as []string{"foo", "serve"}, and [Env.Command], for
+example to tag telemetry or tailor error messages.
+
+A Command may list Required flags. When any of them are set by none of a
+command-line flag, an environment variable, or a config value, Execute fails
+with ExitUsage, reporting all of the gaps together:
+
+	c := Command[*p]{
+		Required: []string{"env", "port"},
+	}
+
+	// Results in error output like:
+	// missing: -env (or $FOO_ENV), -port (or $FOO_PORT)
+
+With PromptRequired, a Command instead asks for each missing required value
+when its input is a terminal, or when the built-in -interactive flag is given.
+Secret flags are read without echo, invalid answers are asked again, and empty
+answers leave the flag missing. This suits first-run flows like "foo login":
+
+	c := Command[*p]{
+		Required:       []string{"user", "password"},
+		Secrets:        []string{"password"},
+		PromptRequired: true,
+	}
+
+Groups declare relationships between flags, such as flags that cannot be used
+together or must be used together. Errors name the env var or config a
+conflicting value came from:
+
+	c := Command[*p]{
+		Groups: []FlagGroup{
+			MutuallyExclusive("json", "yaml"),
+			RequiredTogether("user", "password"),
+		},
+	}
+
+	// Results in error output like:
+	// missing: -password (or $FOO_PASSWORD), required with -user (from $FOO_USER)
+
+Flags listed in Secrets have their values redacted from error output written
+by the framework, and their defaults from the -help=json spec. Additional
+values and patterns can be registered with the Env [Redactor].
+
+With SecretFiles set, a Secrets flag whose env var is unset is also read from
+the file named by the env var with "_FILE" appended, such as
+$FOO_PASSWORD_FILE, following the convention for Docker and Kubernetes secrets.
+
+Flags listed in Expand have ${NAME} references in their values replaced with
+the values of env vars, whether set from the command line, env vars, or config,
+so path-like options compose naturally, as in -cache='${HOME}/.cache/foo'. $$
+escapes a literal $, and referencing an undefined var is a usage error. Expand
+flags should hold strings or lists:
+
+	c := Command[*p]{
+		Expand: []string{"cache-dir", "config"},
+	}
+
+A Command with a Version accepts a -version flag, unless it defines its own,
+which prints the command name and version and exits with ExitSuccess. The
+version may default to the one embedded in the program's build info:
+
+	c := Command[*p]{
+		Name:    "foo",
+		Version: BuildVersion("").Version,
+	}
+
+Flags and commands can be scheduled for removal with a [Deprecation], which is
+compared against the Version of the command or its nearest ancestor. Before
+the Since version a deprecation is only noted in the -help=json spec, from
+Since its use prints a warning, and from Removal its use is an error:
+
+	c := Command[*p]{
+		Version: "v1.5.0",
+		DeprecatedFlags: map[string]Deprecation{
+			"addr": {Since: "v1.4.0", Removal: "v2.0.0", Message: "use -listen"},
+		},
+	}
+
+	// Results in error output like:
+	// warning: flag -addr is deprecated since v1.4.0 and will be removed in v2.0.0: use -listen
+
+Without a schedule, as in Deprecation{Message: "use list"}, a deprecated flag or
+command always warns with the suggested replacement and still executes.
 
 A tinycli command-line interface is tree, with each Command optionally defining
 a list of Subcommands:
@@ -76,6 +327,61 @@ a list of Subcommands:
 		},
 	}
 
+A subcommand named with [Param] matches any token not matched by a sibling,
+capturing it for [Env.PathValue], so grammars like "foo get <resource>" need
+no child per resource:
+
+	c := Command[*p]{
+		Name: "get",
+		Subcommands: []*Command[*p]{
+			{Name: Param("resource")}, // e.PathValue("resource")
+		},
+	}
+
+A subcommand may also be invoked by any of its Aliases. Duplicate names and
+aliases among siblings are reported when the tree is executed, or earlier by
+calling [Command.Validate], for example from a test.
+
+A Command with Abbrev set also accepts any unique prefix of a subcommand's name
+or aliases, so that "foo stat" runs "foo status". Exact names take precedence,
+and an ambiguous prefix is reported as a usage error listing the candidates:
+
+	ambiguous command "st": could be status or stash
+
+Subcommand and flag names match args exactly by default. A MatchCase policy of
+MatchFold, inherited by subcommands, also accepts names that differ only in
+case, so that "foo Serve -PORT=80" runs "foo serve -port=80". Exact matches
+take precedence, and a flag name matching several flags case-insensitively is
+left undefined.
+
+Env var names are matched case-insensitively on Windows, as the Windows
+environment matches them, and exactly elsewhere. A VarCase policy of
+MatchExact or MatchFold, inherited by subcommands, overrides the platform
+default, so that $FOO_PORT is found as Foo_Port when folding:
+
+	c := Command[*p]{
+		VarCase: MatchFold,
+	}
+
+Adding the Command returned by [CompletionCommand] to a tree's root provides
+bash, zsh, and fish completion of subcommand names, flag names, and positional
+args returned by each Command's Complete hook:
+
+	c := Command[*p]{
+		Subcommands: []*Command[*p]{
+			CompletionCommand[*p](), // source <(foo completion bash)
+		},
+	}
+
+[Command.RunScript] executes a tree once for each line read from a reader,
+stopping at the first failing line unless ScriptOptions.ContinueOnError is set,
+so that many invocations can be batched in one process. The Command returned by
+[ScriptCommand] runs a script file, or standard input with "foo script -".
+
+Commands with Hidden set and flags listed in HiddenFlags, such as internal or
+debugging commands, are left out of generated help and completion but remain
+usable.
+
 After parsing, the Action func of the last visited Command is invoked, receiving
 the execution Env with the resulting parameter object and remaining positional
 arguments:
@@ -91,8 +397,142 @@ arguments:
 	e := DefaultEnv[*p](&params)
 	status := c.Execute(context.Background(), e)
 
-A non-goal of tinycli is automatically formatting Command usage and help text.
-Instead, usage and help text for a Command are manually configured:
+Programs typically call [Main] instead, which also cancels the context on
+SIGINT or SIGTERM, exits immediately on a second signal, and exits the process
+with the resulting status:
+
+	func main() {
+		Main(c, &p{})
+	}
+
+An action that fails with an error may instead be configured as ActionE. The
+error is reported like other errors, and mapped to an ExitStatus: an
+[ExitError] selects its own status, a [ValueError] results in ExitUsage,
+context errors result in ExitCanceled, and other errors in ExitFailure. Statuses
+such as ExitUnavailable and ExitTempFail follow the BSD sysexits.h conventions:
+
+	c := Command[*p]{
+		ActionE: func(ctx context.Context, e *Env[*p]) error {
+			return &ExitError{Status: ExitUnavailable, Err: errors.New("backend unavailable")}
+		},
+	}
+
+Errors without an explicit status, whether returned from ActionE or from the
+Before, After, or Config hooks, are first offered to the ErrorMapper of the
+command and then of each ancestor, so domain errors can map to documented exit
+statuses in one place:
+
+	c := Command[*p]{
+		ErrorMapper: func(err error) (ExitStatus, bool) {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return ExitTempFail, true
+			}
+			return 0, false
+		},
+	}
+
+A Command's Timeout bounds the time of its action. When the timeout elapses,
+the context passed to the action is canceled, and an action that then fails
+results in ExitTimeout with an error like "timed out after 30s". A -timeout flag
+defined by [TimeoutFlag] lets users override it:
+
+	c := Command[*p]{
+		Timeout: 30 * time.Second,
+		Flags: func(fs *flag.FlagSet, p *p) {
+			TimeoutFlag(fs, nil, 0) // foo -timeout 2m
+		},
+	}
+
+The Env ShutdownGrace bounds how long Execute waits for an action to return
+once its context is canceled, by a signal received by Run, a Timeout, or the
+Deadline, before it returns ExitCanceled without the action. Meanwhile, the
+context returned by [Env.ShutdownContext] remains valid for wrapping up:
+
+	<-ctx.Done()
+	return srv.Shutdown(e.ShutdownContext()) // abort when the grace period ends
+
+[Retry] wraps an action that may fail transiently, such as one that makes
+network requests, so that it is called again with a doubling delay when it
+returns an error, as configured by a [RetryPolicy]. Input errors, and errors
+for which the policy's Retryable func reports false, are returned at once:
+
+	c := Command[*p]{
+		ActionE: Retry(upload, RetryPolicy{MaxAttempts: 5}),
+	}
+
+A Command's Defer hook is called with the resulting ExitStatus once its action
+or subcommand completes, including when it fails or panics, for closing files,
+flushing logs, or printing summaries. Hooks of nested commands are called
+innermost first.
+
+Helpers nested deep in an action may end execution with [Env.Exit] instead of
+threading a status back up, and register cleanup with [Env.Defer], which runs
+before Execute returns however the tree ends:
+
+	func mustConnect(ctx context.Context, e *Env[*p]) *Conn {
+		conn, err := connect(ctx, e.Params.addr)
+		if err != nil {
+			e.Errorf("connecting: %v\n", err)
+			e.Exit(ExitUnavailable)
+		}
+		e.Defer(func() { conn.Close() })
+		return conn
+	}
+
+PersistentBefore and PersistentAfter hooks run around the final action only,
+once every command on the path has parsed successfully, so a root can open a
+resource for whichever leaf runs. PersistentBefore hooks are called from the
+root down, then the action, then PersistentAfter hooks from the leaf up, then
+Defer hooks. A PersistentAfter hook is called whenever its command's
+PersistentBefore hook succeeded:
+
+	c := Command[*p]{
+		PersistentBefore: func(ctx context.Context, e *Env[*p]) error {
+			return e.Params.openDB(ctx)
+		},
+		PersistentAfter: func(e *Env[*p], status ExitStatus) {
+			e.Params.db.Close()
+		},
+	}
+
+A root's OnReport hook is called once the tree finishes with a [Report] of
+the command path, the names of the flags set on the command line, the exit
+status, and the duration, for collecting anonymized usage metrics. Flag values
+are never reported:
+
+	c := Command[*p]{
+		OnReport: func(e *Env[*p], r Report) {
+			metrics.Record(strings.Join(r.Path, " "), r.Flags, int(r.Status), r.Duration)
+		},
+	}
+
+A Trace hook starts a tracing span around the final action of a Command and
+its subcommands, named after the command path. The context it returns is
+passed to PersistentBefore hooks and the action, and the func it returns ends
+the span with the exit status and error of the action. An OpenTelemetry
+tracer is adapted in a few lines:
+
+	c := Command[*p]{
+		Trace: func(ctx context.Context, e *Env[*p]) (context.Context, func(ExitStatus, error)) {
+			ctx, span := tracer.Start(ctx, strings.Join(e.CommandPath(), " "))
+			return ctx, func(status ExitStatus, err error) {
+				span.SetAttributes(attribute.Int("exit_status", int(status)))
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				}
+				span.End()
+			}
+		},
+	}
+
+Panics in hooks and actions are recovered by Execute, which writes the panic
+value and a trimmed stack trace to the Env error output, calls the root's
+OnPanic hook, and returns ExitFailure. Setting NoRecover on the root lets them
+crash the program instead.
+
+Usage and help text for a Command are manually configured by default, so that
+they read the way the program's authors intend:
 
 	c := Command[*p]{
 		Name: "foo",
@@ -107,6 +547,140 @@ Instead, usage and help text for a Command are manually configured:
 	  -port   uint port number`,
 	}
 
+Help text that depends on runtime information, such as available plugins or
+current defaults, may instead be returned by a HelpFunc:
+
+	c := Command[*p]{
+		HelpFunc: func(e *Env[*p]) string {
+			return "plugins:\n  " + strings.Join(plugins(), "\n  ")
+		},
+	}
+
+Commands may also opt in to help text generated from their definition, listing
+their subcommands and their flags with types, defaults, and env var bindings:
+
+	c := Command[*p]{
+		HelpFunc: GenerateHelp[*p],
+	}
+
+A UsageFunc replaces the default layout of usage and help text for a Command
+and its subcommands, both for -h and for the usage that accompanies errors,
+where its standard output is redirected to the error stream. It may render
+usage with templates, color, or wrapping to the terminal width:
+
+	c := Command[*p]{
+		UsageFunc: func(e *Env[*p], c *Command[*p]) {
+			e.Printf("%s\n\n%s\n", c.Usage, wrap(c.Help, e.Terminal.Width))
+		},
+	}
+
+Flags listed in a Command's Persistent flags apply to its whole subtree: they
+are accepted after the names of its subcommands, as in "foo serve -env=dev",
+and are listed in a "global flags" section appended to the help text of its
+descendants, along with their env var bindings. Descendants may not define
+flags with the same names.
+
+Passing -help=json instead prints a [CommandSpec] describing the command's
+usage, flags, env var bindings, ArgsUsage, and Examples as JSON, for editors
+and other tools that render contextual help.
+
+[Spec] returns the same description for a whole tree, including subcommands
+and aliases, which can be compared against a checked-in copy in tests to catch
+accidental interface changes.
+
+A Command with subcommands, none of which is named "help", also accepts help as
+a subcommand: "foo help serve" prints the same help as "foo serve -h". Topics
+add help pages not attached to a command, such as "foo help environment":
+
+	c := Command[*p]{
+		Topics: []HelpTopic{
+			{Name: "environment", Usage: "environment variables", Help: envHelp},
+		},
+	}
+
+By default, the usage text of a Command accompanies every error it reports. A
+UsagePolicy of UsageOnUsageErrs limits it to errors caused by invalid input,
+such as unknown flags, so runtime failures report only the error message.
+Subcommands inherit the policy of their parent unless they set their own.
+
+Errors for undefined flags suggest similar flag names, or else name the
+ancestor or subcommand that defines the flag:
+
+	// flag provided but not defined: -prot (did you mean -port?)
+	// flag provided but not defined: -force (-force is a flag of "foo reset")
+
+An ErrorHandler replaces the default error report for a Command and its
+subcommands, for example to print errors as JSON, add hints, or forward them
+to an error tracker. It receives the error and the resulting exit status, and
+should redact what it writes with the Env Redactor:
+
+	c := Command[*p]{
+		ErrorHandler: func(e *Env[*p], err error, status ExitStatus) {
+			e.Errorf("error: %s\nrun '%s -h' for usage\n",
+				e.Redactor.Redact(err.Error()), strings.Join(e.CommandPath(), " "))
+		},
+	}
+
+Args that match none of a Command's subcommands are passed to its action as
+positional args, so a root command may accept files alongside subcommands. An
+ArgsPolicy of ArgsStrict instead rejects them as an unknown command, and a
+Fallback action handles them itself:
+
+	c := Command[*p]{
+		ArgsPolicy: ArgsStrict,
+		Fallback: func(ctx context.Context, e *Env[*p]) ExitStatus {
+			return runScript(ctx, e.Args)
+		},
+	}
+
+A Fallback runs in place of an action, with the same PersistentBefore and
+PersistentAfter hooks, Timeout, and tracing. [Plugins] returns a Fallback that
+runs external executables in the style of git, so that "foo bar" runs a
+"foo-bar" executable found on $PATH, forwarding the remaining args, env vars,
+and streams.
+
+A Command may validate its positional args with an Args func, such as
+[ExactArgs], called before its action. Errors result in ExitUsage:
+
+	c := Command[*p]{
+		Args: ExactArgs(2),
+	}
+
+	// Results in error output like:
+	// accepts 2 args, received 1
+
+A Command may have a Before hook, called before its flags are defined and
+parsed. It may rewrite e.Args, for example to expand aliases, or set up
+resources that the Flags hook depends on:
+
+	c := Command[*p]{
+		Before: func(e *Env[*p]) error {
+			if len(e.Args) > 1 && e.Args[1] == "ls" {
+				e.Args[1] = "list"
+			}
+			return nil
+		},
+	}
+
+[SplitArgs] splits a command line into args with shell quoting rules, for
+building e.Args from config-defined aliases, REPL input, or test cases:
+
+	args, err := SplitArgs(`serve -p '80 80'`) // ["serve", "-p", "80 80"]
+
+A Command's UserAliases hook loads user-defined aliases of its subcommands,
+typically from a config file, in the style of git aliases. An alias expands to
+a command line split as by SplitArgs, in which $1 through $9 are replaced by
+the args following the alias, and any args after the highest one referenced
+are appended. Subcommand names take precedence, and expansions are not expanded
+again:
+
+	c := Command[*p]{
+		UserAliases: func(e *Env[*p]) (map[string]string, error) {
+			// "foo co main" runs "foo checkout -interactive main"
+			return map[string]string{"co": "checkout -interactive"}, nil
+		},
+	}
+
 A Command may be have an After hook for validating and transforming
 parameter values after parsing. When a pointer to a [ValueError] is returned
 from the After hook, the error message will be formatted as if it originated
@@ -126,6 +700,39 @@ from a command-line flag:
 
 	// Results in error output like:
 	// invalid value "99999" for var $FOO_PORT: cannot exceed 65535
+
+ValueErrors joined with [errors.Join] are each formatted and reported
+together, so users can fix every invalid value at once:
+
+	return errors.Join(
+		&cli.ValueError{Name: "port", Err: errors.New("cannot exceed 65535")},
+		&cli.ValueError{Name: "host", Err: errors.New("must not be empty")},
+	)
+
+Checks of a single flag's input may instead wrap its value with [Validated],
+so that invalid values are reported as they are set, attributed to the flag,
+env var, or config key they are from. [InRange], [Matches], [OneOf], and
+[FileExists] provide common checks:
+
+	c := Command[*p]{
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.IntVar(&p.port, "port", 8080, "listen port")
+			f := fs.Lookup("port")
+			f.Value = Validated(f.Value, InRange(1, 65535))
+		},
+	}
+
+A Command's Normalize funcs rewrite the values of its flags into a canonical
+form once they are resolved, before the After hook, which is left for logic
+spanning several flags. [TrimSpace], [ToLower], [ExpandHome], and [AbsPath]
+cover common cases, and are applied in order:
+
+	c := Command[*p]{
+		Normalize: map[string][]NormalizeFunc{
+			"region": {TrimSpace, ToLower},
+			"dir":    {ExpandHome, AbsPath},
+		},
+	}
 */
 package tinycli
 
@@ -136,47 +743,119 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-type valueSource int
+// A Source is where the value of a flag was resolved from.
+type Source int
 
 const (
-	sourceDefault valueSource = iota
-	sourceFlag
-	sourceVar
+	SourceDefault Source = iota // flag default value
+	SourceFlag                  // command-line flag
+	SourceVar                   // environment variable
+	SourceConfig                // config value
+	SourcePrompt                // interactive prompt
 )
 
+func (s Source) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceFlag:
+		return "flag"
+	case SourceVar:
+		return "var"
+	case SourceConfig:
+		return "config"
+	case SourcePrompt:
+		return "prompt"
+	}
+	return fmt.Sprintf("Source(%d)", int(s))
+}
+
 // An Env represents the execution environment for a [Command].
 //
 // P is the type of custom parameter data available to Commands executed with
 // the Env.
 type Env[P any] struct {
-	Err    io.Writer         // standard output stream
-	Out    io.Writer         // error output stream
+	In     io.Reader         // standard input stream
+	Err    io.Writer         // error output stream
+	Out    io.Writer         // standard output stream
 	Args   []string          // command-line arguments
 	Vars   map[string]string // env var names -> values
 	Params P                 // custom data available to Command actions
+
+	// Prompts records prompt interactions, or replays recorded answers. When
+	// nil, prompts read from In without recording.
+	Prompts *PromptLog
+
+	// Terminal describes the terminal connected to Out, and is the zero
+	// Terminal when Out is not a terminal. DefaultEnv populates it.
+	Terminal Terminal
+
+	// Color controls whether color helpers such as Warnf emit ANSI color
+	// codes. The zero value, ColorAuto, follows the terminal and the NO_COLOR
+	// and CLICOLOR conventions; tests may force ColorAlways.
+	Color ColorMode
+
+	// Redactor replaces secret values in output written by the framework.
+	// When nil, Execute creates one as secrets are registered.
+	Redactor *Redactor
+
+	// Deadline bounds the total time of an invocation. When non-zero, Execute
+	// applies it to the context passed to hooks and actions, so every helper
+	// that respects the context is bound by it.
+	Deadline time.Time
+
+	// ShutdownGrace is how long Execute waits for an action to return after
+	// its context is canceled, by a signal, a timeout, or the Deadline. When
+	// it elapses, Execute returns without the action, with ExitCanceled, or
+	// ExitTimeout for a Command Timeout, and output the abandoned action
+	// writes afterwards is discarded. When zero, Execute waits for the action
+	// however long it takes. See [Env.ShutdownContext].
+	ShutdownGrace time.Duration
+
+	cmds        []*invocation[P]  // commands visited during execution
+	pathValues  map[string]string // tokens matched by parameter subcommands
+	shutdownCtx context.Context   // context for the action's shutdown work
+	resolveOnly bool              // stop before calling actions
+	resolved    bool              // resolution stopped before calling an action
+	errs        *error            // destination of errors, instead of the error stream
+	deferred    []func()          // cleanup funcs registered by Defer
 }
 
 // DefaultEnv returns an [Env] using the process environment.
 //
-// The resulting Env will use the [os.Stderr] and [os.Stdout] streams,
-// [os.Args], and environment variables from [os.Environ].
+// The resulting Env will use the [os.Stdin], [os.Stderr], and [os.Stdout]
+// streams, [os.Args], and environment variables from [os.Environ]. Its
+// Terminal describes os.Stdout.
 func DefaultEnv[P any](params P) *Env[P] {
 	environ := os.Environ()
 	vars := make(map[string]string, len(environ))
 	for _, v := range environ {
-		key, value, _ := strings.Cut(v, "=")
-		vars[key] = value
+		if v == "" {
+			continue
+		}
+		// Windows has per-drive variables with names beginning with "=",
+		// such as "=C:=C:\", so the separator is searched for after the
+		// first byte.
+		key, value, _ := strings.Cut(v[1:], "=")
+		vars[v[:1]+key] = value
 	}
 	return &Env[P]{
-		Err:    os.Stderr,
-		Out:    os.Stdout,
-		Args:   os.Args,
-		Vars:   vars,
-		Params: params,
+		In:       os.Stdin,
+		Err:      os.Stderr,
+		Out:      os.Stdout,
+		Args:     os.Args,
+		Vars:     vars,
+		Params:   params,
+		Terminal: newTerminal(os.Stdout, vars),
 	}
 }
 
@@ -196,52 +875,308 @@ func (e Env[P]) Errorf(format string, args ...any) (int, error) {
 	return 0, nil
 }
 
-func (e Env[P]) getVar(name string) (value string, isSet bool) {
-	if e.Vars == nil {
-		return "", false
+// foldVarCase reports whether env var names are matched case-insensitively by
+// default, as they are by the Windows environment.
+var foldVarCase = runtime.GOOS == "windows"
+
+// foldVars reports whether env var names are matched case-insensitively,
+// according to the VarCase of the nearest visited command that sets one.
+func (e Env[P]) foldVars() bool {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.VarCase != MatchInherit {
+			return cmd.VarCase == MatchFold
+		}
 	}
-	value, isSet = e.Vars[name]
-	return value, isSet
+	return foldVarCase
 }
 
-// An ExitStatus is the result of command execution.
-type ExitStatus int
-
-const (
-	ExitSuccess ExitStatus = 0 // execution succeeded
-	ExitFailure ExitStatus = 1 // execution failed due to an error
-	ExitUsage   ExitStatus = 2 // execution failed due to invalid user input
-)
+// CommandPath returns the names of the commands visited during execution,
+// from the root down, such as []string{"foo", "serve"}.
+func (e Env[P]) CommandPath() []string {
+	path := make([]string, len(e.cmds))
+	for i, c := range e.cmds {
+		path[i] = c.Name
+	}
+	return path
+}
 
-var (
+// Command returns the command being executed: the last command visited during
+// execution, or nil before execution starts.
+func (e Env[P]) Command() *Command[P] {
+	if len(e.cmds) == 0 {
+		return nil
+	}
+	return e.cmds[len(e.cmds)-1].Command
+}
+
+// PathValue returns the token matched by the parameter subcommand with the
+// given name during execution, or "" if there is none. See [Param].
+func (e Env[P]) PathValue(name string) string {
+	return e.pathValues[name]
+}
+
+// pathTokens returns the args that selected the visited commands: their
+// names, or for parameter subcommands, the matched tokens.
+func (e Env[P]) pathTokens() []string {
+	tokens := make([]string, len(e.cmds))
+	for i, c := range e.cmds {
+		tokens[i] = c.Name
+		if param, ok := paramName(c.Name); ok {
+			tokens[i] = e.pathValues[param]
+		}
+	}
+	return tokens
+}
+
+// Source reports where the value of the named flag of the executing command,
+// or of a Persistent flag of one of its ancestors, was resolved from. It
+// returns false if there is no such flag, or if flag values have not been
+// resolved yet, as in a Before hook.
+func (e Env[P]) Source(flagName string) (Source, bool) {
+	for i, c := range slices.Backward(e.cmds) {
+		if c.meta == nil || (i < len(e.cmds)-1 && !slices.Contains(c.Persistent, flagName)) {
+			continue
+		}
+		if m, ok := c.getMeta(flagName); ok {
+			return m.valueSource, true
+		}
+	}
+	return SourceDefault, false
+}
+
+// flagValue returns the value of the named flag of the executing command, so
+// that built-in commands need not bind flags to shared variables.
+func (e Env[P]) flagValue(name string) string {
+	if len(e.cmds) == 0 {
+		return ""
+	}
+	if f := e.cmds[len(e.cmds)-1].flagSet().Lookup(name); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+func (e Env[P]) getVar(name string) (value string, isSet bool) {
+	if e.Vars == nil {
+		return "", false
+	}
+	value, isSet = e.Vars[name]
+	if isSet || !e.foldVars() {
+		return value, isSet
+	}
+
+	// fall back to the first case-insensitive match, in sorted order so that
+	// the result is deterministic
+	var match string
+	for k, v := range e.Vars {
+		if strings.EqualFold(k, name) && (!isSet || k < match) {
+			match, value, isSet = k, v, true
+		}
+	}
+	return value, isSet
+}
+
+// An ExitStatus is the result of command execution.
+type ExitStatus int
+
+const (
+	ExitSuccess  ExitStatus = 0   // execution succeeded
+	ExitFailure  ExitStatus = 1   // execution failed due to an error
+	ExitUsage    ExitStatus = 2   // execution failed due to invalid user input
+	ExitTimeout  ExitStatus = 124 // execution stopped because the command's timeout elapsed
+	ExitCanceled ExitStatus = 130 // execution stopped due to context cancellation
+)
+
+// Exit statuses for failure classes, as defined by the BSD sysexits.h header,
+// for actions to return, directly or in an [ExitError], so that scripts can
+// distinguish them. Input errors detected by the framework use ExitUsage
+// rather than the EX_USAGE value 64.
+const (
+	ExitDataErr     ExitStatus = 65 // input data was incorrect
+	ExitNoInput     ExitStatus = 66 // an input file did not exist or was unreadable
+	ExitNoUser      ExitStatus = 67 // a specified user did not exist
+	ExitNoHost      ExitStatus = 68 // a specified host did not exist
+	ExitUnavailable ExitStatus = 69 // a service was unavailable
+	ExitSoftware    ExitStatus = 70 // an internal software error was detected
+	ExitOSErr       ExitStatus = 71 // an operating system error was detected
+	ExitOSFile      ExitStatus = 72 // a system file did not exist or was unreadable
+	ExitCantCreate  ExitStatus = 73 // an output file could not be created
+	ExitIOErr       ExitStatus = 74 // an error occurred doing I/O
+	ExitTempFail    ExitStatus = 75 // a temporary failure; retrying may succeed
+	ExitProtocol    ExitStatus = 76 // a remote system violated a protocol
+	ExitNoPerm      ExitStatus = 77 // permission was denied
+	ExitConfig      ExitStatus = 78 // configuration was invalid
+)
+
+var (
 	errMissingCommand = errors.New("missing command")
 	errUnknownCommand = errors.New("unknown command")
 )
 
+// A UsagePolicy controls when a Command's usage text accompanies an error.
+type UsagePolicy int
+
+const (
+	UsageInherit     UsagePolicy = iota // use the parent's policy, or UsageAlways at the root
+	UsageAlways                         // print usage with every error
+	UsageOnUsageErrs                    // print usage only with errors caused by invalid input
+	UsageNever                          // never print usage with errors
+)
+
+// An ArgsPolicy controls how a Command with subcommands handles args that match
+// none of them.
+type ArgsPolicy int
+
+const (
+	ArgsInherit     ArgsPolicy = iota // use the parent's policy, or ArgsPassthrough at the root
+	ArgsPassthrough                   // pass the args to the action, failing without one
+	ArgsStrict                        // fail with an unknown command error, even with an action
+)
+
+// A MatchPolicy controls whether subcommand and flag names match args
+// case-insensitively.
+type MatchPolicy int
+
+const (
+	MatchInherit MatchPolicy = iota // use the parent's policy, or MatchExact at the root
+	MatchExact                      // match names exactly
+	MatchFold                       // also match names case-insensitively, after exact matches
+)
+
 // A FlagsFunc is a hook for defining flags and binding them to parameter values.
 type FlagsFunc[P any] = func(*flag.FlagSet, P)
 
+// A VarsFunc is a hook for binding flag names to env var names dynamically.
+type VarsFunc = func(flagName string) (varName string, ok bool)
+
+// A ConfigFunc is a hook for loading configuration values, keyed by flag name.
+type ConfigFunc[P any] = func(*Env[P]) (map[string]string, error)
+
+// An AliasesFunc is a hook loading user-defined aliases, mapping alias names to
+// the command lines they expand to.
+type AliasesFunc[P any] = func(*Env[P]) (map[string]string, error)
+
+// A HelpFunc is a hook returning help text built at runtime.
+type HelpFunc[P any] = func(*Env[P]) string
+
+// A UsageFunc is a hook writing the usage and help of a Command to the Env
+// standard output stream.
+type UsageFunc[P any] func(*Env[P], *Command[P])
+
+// An ArgsFunc is a hook validating the positional args of a Command.
+type ArgsFunc = func(args []string) error
+
+// A BeforeFunc is a hook called before flags are defined and parsed.
+type BeforeFunc[P any] = func(*Env[P]) error
+
 // An AfterFunc is a hook providing access to the parse result.
 type AfterFunc[P any] = func(*Env[P]) error
 
 // An ActionFunc is a function called when a Command is invoked.
 type ActionFunc[P any] = func(context.Context, *Env[P]) ExitStatus
 
+// An ActionErrFunc is a function called when a Command is invoked that reports
+// failure with an error.
+type ActionErrFunc[P any] = func(context.Context, *Env[P]) error
+
+// An ExitFunc is a hook called with the final status of an execution.
+type ExitFunc[P any] = func(*Env[P], ExitStatus)
+
+// A PanicFunc is a hook called with the value of a recovered panic.
+type PanicFunc[P any] = func(*Env[P], any)
+
+// A CompleteFunc is a hook returning completion candidates for a partially
+// typed positional argument.
+type CompleteFunc[P any] = func(ctx context.Context, e *Env[P], toComplete string) ([]string, error)
+
+// An ErrorFunc is a hook reporting an error that ends an execution with the
+// given status.
+type ErrorFunc[P any] = func(e *Env[P], err error, status ExitStatus)
+
+// An ErrorMapFunc is a hook mapping an error to an ExitStatus, reporting false
+// for errors it does not map.
+type ErrorMapFunc = func(err error) (status ExitStatus, ok bool)
+
 // A Command represents a CLI command.
 //
 // P is the type of custom parameter data available to Command actions.
 type Command[P any] struct {
-	Name        string            // name used to invoke the command
-	Usage       string            // short usage text
-	Help        string            // log help text
-	Flags       FlagsFunc[P]      // flag setup hook
-	Vars        map[string]string // flag names -> env var names
-	After       AfterFunc[P]      // post-parse hook
-	Action      ActionFunc[P]     // command action function
-	Subcommands []*Command[P]     // child commands
+	Name             string            // name used to invoke the command
+	Version          string            // program version for -version, inherited by subcommands
+	Usage            string            // short usage text
+	Help             string            // log help text
+	HelpFunc         HelpFunc[P]       // dynamic help text, used instead of Help
+	UsageFunc        UsageFunc[P]      // usage and help rendering, replacing the default, inherited by subcommands
+	Flags            FlagsFunc[P]      // flag setup hook
+	Vars             map[string]string // flag names -> env var names
+	VarsFunc         VarsFunc          // dynamic env var binding for flags not in Vars
+	VarPrefix        string            // env var namespace for unbound flags
+	Config           ConfigFunc[P]     // config source for flags not otherwise set
+	Required         []string          // names of flags that must be set
+	PromptRequired   bool              // prompt for unset Required flags in interactive use
+	Groups           []FlagGroup       // relationships between flags
+	Secrets          []string          // names of flags with secret values
+	SecretFiles      bool              // read Secrets from files named by $VAR_FILE
+	Expand           []string          // names of flags whose values expand ${VAR} references
+	Persistent       []string          // names of flags also accepted after subcommand names
+	HiddenFlags      []string          // names of flags omitted from generated help and completion
+	Args             ArgsFunc          // positional args validator, called before the action
+	ArgsUsage        string            // positional args synopsis for the -help=json spec, such as "FILE..."
+	Examples         []string          // example command lines for the -help=json spec
+	Before           BeforeFunc[P]     // pre-parse hook
+	After            AfterFunc[P]      // post-parse hook
+	PersistentBefore ActionErrFunc[P]  // hook called before the final action, from the root down
+	PersistentAfter  ExitFunc[P]       // hook called after the final action, from the leaf up
+	Action           ActionFunc[P]     // command action function
+	ActionE          ActionErrFunc[P]  // command action function, used when Action is nil
+	ActionR          ResultFunc[P]     // command action returning a Result, used when Action and ActionE are nil
+	Timeout          time.Duration     // time limit of the action, 0 for none
+	Complete         CompleteFunc[P]   // dynamic completion of positional args
+	Defer            ExitFunc[P]       // cleanup hook, called after the action or subcommand
+	OnExit           ExitFunc[P]       // final hook, called on the executed root only
+	OnReport         ReportFunc[P]     // execution report hook, called on the executed root only
+	OnPanic          PanicFunc[P]      // recovered panic hook, called on the executed root only
+	Trace            TraceFunc[P]      // tracing span hook around the final action, inherited by subcommands
+	NoRecover        bool              // let panics crash the program, set on the executed root
+	UsagePolicy      UsagePolicy       // when usage text accompanies errors
+	ArgsPolicy       ArgsPolicy        // handling of args that match no subcommand
+	Fallback         ActionFunc[P]     // handler for args that match no subcommand, used instead of ArgsPolicy
+	MatchCase        MatchPolicy       // case sensitivity of subcommand and flag names
+	VarCase          MatchPolicy       // case sensitivity of env var names, folded by default on Windows
+	Locale           *Locale           // format of Locale flag values without their own, inherited by subcommands
+	Precedence       []Source          // order of flag sources, highest first, inherited by subcommands
+	ErrorMapper      ErrorMapFunc      // error to exit status mapping, inherited by subcommands
+	ErrorHandler     ErrorFunc[P]      // error reporting, replacing the default, inherited by subcommands
+	Deprecated       *Deprecation      // deprecation schedule of the command
+	Aliases          []string          // alternative names used to invoke the command
+	Abbrev           bool              // accept unique prefixes of subcommand names
+	UserAliases      AliasesFunc[P]    // user-defined aliases of subcommand command lines
+	Hidden           bool              // omitted from generated help and completion
+	Subcommands      []*Command[P]     // child commands
+	Topics           []HelpTopic       // help pages printed by the help subcommand
+
+	DeprecatedFlags map[string]Deprecation     // flag names -> deprecation schedules
+	Normalize       map[string][]NormalizeFunc // flag names -> normalizers applied after resolution
+
+	rawArgs bool // args are words to complete, not flags to hoist
+}
 
-	fs   *flag.FlagSet
-	meta map[string]*flagMeta
+// A HelpTopic is a page of documentation that is not attached to a command,
+// such as a description of the environment or configuration, printed by the
+// built-in help subcommand.
+type HelpTopic struct {
+	Name  string // name used to look up the topic, as in "foo help <name>"
+	Usage string // one-line summary
+	Help  string // long-form text
+}
+
+// An invocation holds the state of a Command during one execution, so that a
+// Command may be executed repeatedly and concurrently.
+type invocation[P any] struct {
+	*Command[P]
+	fs        *flag.FlagSet
+	meta      map[string]*flagMeta
+	varPrefix string // accumulated env var namespace
 }
 
 // A Value error is an error associated with a Command flag.
@@ -254,12 +1189,28 @@ func (e *ValueError) Error() string {
 	return e.Err.Error()
 }
 
+// An ExitError is an error returned from an [ActionErrFunc] that selects the
+// resulting ExitStatus.
+type ExitError struct {
+	Status ExitStatus // exit status
+	Err    error      // wrapped error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
 type decoratedValueError struct {
 	rawValue string
 	flagName string
 	varName  string
-	source   valueSource
+	source   Source
 	isBool   bool
+	isSecret bool
 	err      error
 }
 
@@ -275,22 +1226,32 @@ func (e *decoratedValueError) Error() string {
 	}
 
 	switch e.source {
-	case sourceFlag:
+	case SourceFlag:
 		if !e.isBool {
 			sourcePrefix = "flag "
 		}
 		sourceName = e.flagName
-	case sourceVar:
+	case SourceVar:
 		if !e.isBool {
 			sourcePrefix = "var "
 		}
 		sourceName = "$" + e.varName
+	case SourceConfig:
+		sourcePrefix = "config key "
+		sourceName = e.flagName
+	case SourcePrompt:
+		sourceName = "-" + e.flagName
+	}
+
+	rawValue := e.rawValue
+	if e.isSecret {
+		rawValue = redacted
 	}
 
-	return fmt.Sprintf("invalid %svalue %q for %s%s: %v", valuePrefix, e.rawValue, sourcePrefix, sourceName, e.err)
+	return fmt.Sprintf("invalid %svalue %q for %s%s: %v", valuePrefix, rawValue, sourcePrefix, sourceName, e.err)
 }
 
-func (c *Command[P]) decorateValueError(ve *ValueError) error {
+func (c *invocation[P]) decorateValueError(ve *ValueError) error {
 	meta, ok := c.getMeta(ve.Name)
 	if !ok {
 		return ve
@@ -301,19 +1262,189 @@ func (c *Command[P]) decorateValueError(ve *ValueError) error {
 		source:   meta.valueSource,
 		varName:  meta.varName,
 		isBool:   meta.isBool,
+		isSecret: meta.isSecret,
 		err:      ve.Err,
 	}
 }
 
-func (c *Command[P]) onHelp(e *Env[P]) {
-	e.Printf("%s\n\n%s\n", c.Usage, c.Help)
+// decorateValueErrors decorates err if it is a [ValueError], or each
+// ValueError joined in err, as by [errors.Join], so that every invalid value
+// is reported. It reports whether err holds any ValueError.
+func (c *invocation[P]) decorateValueErrors(err error) (error, bool) {
+	if valErr, ok := err.(*ValueError); ok {
+		return c.decorateValueError(valErr), true
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return err, false
+	}
+	var (
+		errs      []error
+		hasValErr bool
+	)
+	for _, err := range joined.Unwrap() {
+		if valErr, ok := err.(*ValueError); ok {
+			err = c.decorateValueError(valErr)
+			hasValErr = true
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...), hasValErr
 }
 
-func (c *Command[P]) onErr(e *Env[P], err error) {
-	e.Errorf("%s\n%v\n", c.Usage, err)
+func (c *invocation[P]) onHelp(e *Env[P]) {
+	if fn := c.usageFunc(e); fn != nil {
+		fn(e, c.Command)
+		return
+	}
+	e.Printf("%s\n\n%s\n", c.Usage, c.help(e))
+	c.writeGlobalFlags(e)
+}
+
+// help returns the Command help text, from HelpFunc when it is set.
+func (c *Command[P]) help(e *Env[P]) string {
+	if c.HelpFunc != nil {
+		return c.HelpFunc(e)
+	}
+	return c.Help
 }
 
-func (c *Command[P]) flagSet() *flag.FlagSet {
+// onActionErr reports an error returned from an ActionErrFunc, returning the
+// resulting ExitStatus, or status if err is nil.
+func (c *invocation[P]) onActionErr(e *Env[P], err error, status ExitStatus) ExitStatus {
+	if err == nil {
+		return status
+	}
+	var valErr *ValueError
+	if errors.As(err, &valErr) {
+		return c.onUsageErr(e, c.decorateValueError(valErr), ExitUsage)
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return c.onErr(e, err, exitErr.Status)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return c.onErr(e, err, c.exitStatus(e, err, ExitCanceled))
+	}
+	return c.onErr(e, err, c.exitStatus(e, err, ExitFailure))
+}
+
+// exitStatus maps an error returned from a hook or action to an ExitStatus
+// with the ErrorMapper of the nearest command that maps it, or returns def.
+func (c *Command[P]) exitStatus(e *Env[P], err error, def ExitStatus) ExitStatus {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.ErrorMapper == nil {
+			continue
+		}
+		if status, ok := cmd.ErrorMapper(err); ok {
+			return status
+		}
+	}
+	return def
+}
+
+// onErr reports a runtime error, such as a failure to load configuration,
+// that results in status, and returns status.
+func (c *Command[P]) onErr(e *Env[P], err error, status ExitStatus) ExitStatus {
+	c.writeErr(e, err, status, c.usagePolicy(e) == UsageAlways)
+	return status
+}
+
+// onUsageErr reports an error caused by invalid user input, such as an
+// unknown flag or command, that results in status, and returns status.
+func (c *Command[P]) onUsageErr(e *Env[P], err error, status ExitStatus) ExitStatus {
+	c.writeErr(e, err, status, c.usagePolicy(e) != UsageNever)
+	return status
+}
+
+func (c *Command[P]) writeErr(e *Env[P], err error, status ExitStatus, withUsage bool) {
+	if e.errs != nil {
+		*e.errs = &ExitError{Status: status, Err: err}
+		return
+	}
+	if h := c.errorHandler(e); h != nil {
+		h(e, err, status)
+		return
+	}
+	if withUsage {
+		if fn := c.usageFunc(e); fn != nil {
+			// render usage to the error stream
+			ue := *e
+			ue.Out = e.Err
+			fn(&ue, c)
+		} else {
+			e.Errorf("%s\n", c.Usage)
+		}
+	}
+	e.Errorf("%s\n", e.Redactor.Redact(err.Error()))
+}
+
+// usageFunc returns the UsageFunc of the nearest visited command that sets
+// one.
+func (c *Command[P]) usageFunc(e *Env[P]) UsageFunc[P] {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.UsageFunc != nil {
+			return cmd.UsageFunc
+		}
+	}
+	return nil
+}
+
+// errorHandler returns the ErrorHandler of the nearest visited command that
+// sets one.
+func (c *Command[P]) errorHandler(e *Env[P]) ErrorFunc[P] {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.ErrorHandler != nil {
+			return cmd.ErrorHandler
+		}
+	}
+	return nil
+}
+
+// argsPolicy returns the ArgsPolicy of the nearest visited command that sets
+// one.
+func (c *Command[P]) argsPolicy(e *Env[P]) ArgsPolicy {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.ArgsPolicy != ArgsInherit {
+			return cmd.ArgsPolicy
+		}
+	}
+	return ArgsPassthrough
+}
+
+// foldCase reports whether the MatchPolicy of the nearest of cmds that sets one
+// is MatchFold.
+func foldCase[P any](cmds []*invocation[P]) bool {
+	for _, cmd := range slices.Backward(cmds) {
+		if cmd.MatchCase != MatchInherit {
+			return cmd.MatchCase == MatchFold
+		}
+	}
+	return false
+}
+
+// locale returns the Locale of the nearest visited command that sets one.
+func locale[P any](cmds []*invocation[P]) *Locale {
+	for _, cmd := range slices.Backward(cmds) {
+		if cmd.Locale != nil {
+			return cmd.Locale
+		}
+	}
+	return nil
+}
+
+// usagePolicy returns the UsagePolicy of the nearest visited command that
+// sets one.
+func (c *Command[P]) usagePolicy(e *Env[P]) UsagePolicy {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.UsagePolicy != UsageInherit {
+			return cmd.UsagePolicy
+		}
+	}
+	return UsageAlways
+}
+
+func (c *invocation[P]) flagSet() *flag.FlagSet {
 	if c.fs == nil {
 		c.fs = flag.NewFlagSet(c.Name, flag.ContinueOnError)
 		c.fs.Usage = func() { /* no-op */ }
@@ -322,15 +1453,69 @@ func (c *Command[P]) flagSet() *flag.FlagSet {
 	return c.fs
 }
 
-func (c *Command[P]) lookupVarName(flagName string) (varName string, exists bool) {
-	if c.Vars == nil {
+// defineFlags calls the Flags hook of c, if any, to define its flags in fs
+// outside of an execution of c, with params p. It reports false if the hook
+// panics, as it may with params it does not expect, such as the nil value of
+// an interface type, leaving fs partially defined.
+func defineFlags[P any](c *Command[P], fs *flag.FlagSet, p P) (ok bool) {
+	if c.Flags == nil {
+		return true
+	}
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	c.Flags(fs, p)
+	return true
+}
+
+func (c *invocation[P]) lookupVarName(flagName string) (varName string, exists bool) {
+	if c.Vars != nil {
+		if varName, exists = c.Vars[flagName]; exists {
+			return varName, exists
+		}
+	}
+	if f := c.flagSet().Lookup(flagName); f != nil {
+		if bv, ok := f.Value.(*boundValue); ok {
+			return bv.varName, true
+		}
+	}
+	if c.VarsFunc != nil {
+		if varName, exists = c.VarsFunc(flagName); exists {
+			return varName, exists
+		}
+	}
+	if c.varPrefix == "" {
 		return "", false
 	}
-	varName, exists = c.Vars[flagName]
-	return varName, exists
+	return c.varPrefix + varNameSuffix(flagName), true
 }
 
-func (c *Command[P]) getVar(flagName string, env *Env[P]) (varName string, value string, isSet bool) {
+// varNameSuffix converts a flag name to an upper snake case env var name, so
+// that "log-level" and "logLevel" both become "LOG_LEVEL".
+func varNameSuffix(flagName string) string {
+	var sb strings.Builder
+	for i, r := range flagName {
+		switch {
+		case r == '-' || r == '.':
+			sb.WriteByte('_')
+		case unicode.IsUpper(r):
+			if i > 0 {
+				prev, _ := utf8.DecodeLastRuneInString(flagName[:i])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+					sb.WriteByte('_')
+				}
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return sb.String()
+}
+
+func (c *invocation[P]) getVar(flagName string, env *Env[P]) (varName string, value string, isSet bool) {
 	varName, exists := c.lookupVarName(flagName)
 	if !exists {
 		return "", "", false
@@ -339,7 +1524,26 @@ func (c *Command[P]) getVar(flagName string, env *Env[P]) (varName string, value
 	return varName, value, isSet
 }
 
-func (c *Command[P]) getMeta(flagName string) (*flagMeta, bool) {
+// getVarFile looks up the env var of a flag with "_FILE" appended, returning
+// the contents of the file it names without a trailing newline.
+func (c *invocation[P]) getVarFile(flagName string, env *Env[P]) (varName string, value string, isSet bool, err error) {
+	varName, exists := c.lookupVarName(flagName)
+	if !exists {
+		return "", "", false, nil
+	}
+	varName += "_FILE"
+	path, isSet := env.getVar(varName)
+	if !isSet {
+		return "", "", false, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("reading $%s: %w", varName, err)
+	}
+	return varName, strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+func (c *invocation[P]) getMeta(flagName string) (*flagMeta, bool) {
 	// c.meta must not be nil
 	meta, exists := c.meta[flagName]
 	if !exists {
@@ -348,24 +1552,185 @@ func (c *Command[P]) getMeta(flagName string) (*flagMeta, bool) {
 	return meta, true
 }
 
-func (c *Command[P]) lookupSubcommand(name string) *Command[P] {
-	if c.Subcommands == nil {
+// setFlag sets a flag from an env var or config value. A list flag is set from
+// the whole delimited value, replacing its default.
+func (c *invocation[P]) setFlag(name, value string) error {
+	if f := c.flagSet().Lookup(name); f != nil {
+		if lv, ok := unwrapValue(f.Value).(listValue); ok {
+			if vv, ok := f.Value.(*validatedValue); ok {
+				if err := vv.check(value); err != nil {
+					return err
+				}
+			}
+			return lv.setList(value)
+		}
+	}
+	return c.flagSet().Set(name, value)
+}
+
+// isHidden reports whether the named flag is omitted from generated help and
+// completion.
+func (c *invocation[P]) isHidden(flagName string) bool {
+	return slices.Contains(c.HiddenFlags, flagName)
+}
+
+// AutoVars returns a [VarsFunc] binding every flag to prefix followed by the
+// upper snake case flag name, so that with prefix "FOO_", "log-level" binds to
+// $FOO_LOG_LEVEL. It applies the same convention as VarPrefix, without
+// accumulating prefixes down the tree.
+func AutoVars(prefix string) VarsFunc {
+	return func(flagName string) (string, bool) {
+		return prefix + varNameSuffix(flagName), true
+	}
+}
+
+// checkRequired returns an error listing every required flag that was not set
+// by a command-line flag, environment variable, or config value.
+func (c *invocation[P]) checkRequired() error {
+	var missing []string
+	for _, name := range c.Required {
+		if meta, ok := c.getMeta(name); ok && meta.valueSource != SourceDefault {
+			continue
+		}
+		if varName, ok := c.lookupVarName(name); ok {
+			missing = append(missing, fmt.Sprintf("-%s (or $%s)", name, varName))
+		} else {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing: %s", strings.Join(missing, ", "))
+}
+
+// Param returns a Command Name that matches any subcommand token, capturing
+// it under name. The matched token is available from [Env.PathValue]:
+//
+//	get := Command[*p]{
+//		Name: "get",
+//		Subcommands: []*Command[*p]{
+//			{Name: Param("resource"), Action: getResource},
+//		},
+//	}
+//
+// Subcommands with literal names take precedence over parameter subcommands.
+func Param(name string) string {
+	return "{" + name + "}"
+}
+
+// paramName returns the parameter name of a Command Name created by [Param].
+func paramName(name string) (string, bool) {
+	if len(name) > 2 && name[0] == '{' && name[len(name)-1] == '}' {
+		return name[1 : len(name)-1], true
+	}
+	return "", false
+}
+
+func (c *Command[P]) lookupSubcommand(name string, fold bool) *Command[P] {
+	if len(c.Subcommands) == 0 {
 		return nil
 	}
-	for i := range c.Subcommands {
-		if c.Subcommands[i].Name == name {
-			return c.Subcommands[i]
+	idx := c.subcommandIndex()
+	if sub, ok := idx.names[name]; ok {
+		return sub
+	}
+	if fold {
+		for _, sub := range c.Subcommands {
+			if _, ok := paramName(sub.Name); ok {
+				continue
+			}
+			for _, n := range append([]string{sub.Name}, sub.Aliases...) {
+				if strings.EqualFold(n, name) {
+					return sub
+				}
+			}
+		}
+	}
+	if c.Abbrev {
+		if matches := c.prefixMatches(name, fold); len(matches) == 1 {
+			return matches[0]
+		}
+	}
+	return idx.param
+}
+
+// visitFlagArgs calls fn with the name and value of each flag in args, in the
+// order fs parses them, until the first non-flag argument or until fn returns
+// false.
+func visitFlagArgs(fs *flag.FlagSet, args []string, fn func(name, value string) bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+			return
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !hasValue {
+			if f := fs.Lookup(name); f != nil {
+				if !isBoolFlag(f.Value) {
+					if i+1 < len(args) {
+						i++
+						value = args[i]
+					}
+				}
+			}
+		}
+		if !fn(name, value) {
+			return
 		}
 	}
-	return nil
+}
+
+// hoists reports whether persistent flags are hoisted from the args following
+// the name of sub, which are otherwise left to sub itself.
+func hoists[P any](sub *Command[P]) bool {
+	return sub != nil && !sub.rawArgs
+}
+
+// hoistPersistent splits the Persistent flags of c, with their values, from
+// the args following the subcommand name args[0], so that they may be given
+// anywhere in the subcommand's arguments.
+func (c *invocation[P]) hoistPersistent(args []string, fold bool) (hoisted, rest []string) {
+	rest = append(rest, args[0])
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			rest = append(rest, arg)
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if fold {
+			name = foldFlagName(c.flagSet(), name)
+		}
+		if !hasValue && isRepeatedCount(c.flagSet(), name) && slices.Contains(c.Persistent, name[:1]) {
+			hoisted = append(hoisted, arg)
+			continue
+		}
+		f := c.flagSet().Lookup(name)
+		if f == nil || !slices.Contains(c.Persistent, name) {
+			rest = append(rest, arg)
+			continue
+		}
+		hoisted = append(hoisted, arg)
+		if !hasValue && !isBoolFlag(f.Value) && i+1 < len(args) {
+			i++
+			hoisted = append(hoisted, args[i])
+		}
+	}
+	return hoisted, rest
 }
 
 type flagMeta struct {
 	flagName    string
 	varName     string
 	value       string
-	valueSource valueSource
+	valueSource Source
 	isBool      bool
+	isSecret    bool
 }
 
 type boolFlag interface {
@@ -373,42 +1738,304 @@ type boolFlag interface {
 	IsBoolFlag() bool
 }
 
+// A builtinFlag is a boolean flag defined by the framework, such as the
+// -version flag of a Command with a Version. It is not bound to env vars or
+// config.
+type builtinFlag bool
+
+func (v *builtinFlag) String() string { return strconv.FormatBool(bool(*v)) }
+
+func (v *builtinFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errParse
+	}
+	*v = builtinFlag(b)
+	return nil
+}
+
+func (v *builtinFlag) IsBoolFlag() bool { return true }
+
+// isBoolFlag reports whether a flag value is a boolean flag, which does not
+// take a separate value argument.
+func isBoolFlag(v flag.Value) bool {
+	bf, ok := v.(boolFlag)
+	return ok && bf.IsBoolFlag()
+}
+
 // Execute parses command-line arguments and vars from the environment, calls
 // hook functions, then calls the command's action or defers to the specified
 // subcommand's own Execute method.
-func (c *Command[P]) Execute(ctx context.Context, e *Env[P]) ExitStatus {
+//
+// A panic in a hook or action of the tree is recovered, unless the command
+// Execute is called on sets NoRecover. The panic value and a stack trace are
+// written to the Env error output, the OnPanic hook of the command is called,
+// and Execute returns [ExitFailure]. A call to [Env.Exit] is not a panic, and
+// Execute returns the status it requests, with or without NoRecover.
+//
+// The OnExit hook of the command Execute is called on runs exactly once after
+// the tree finishes, including when it panics, in which case it receives
+// [ExitFailure], before the panic continues if it is not recovered. Its
+// OnReport hook is called just before, with a [Report] of the execution.
+//
+// Execution state is held by the Env, not the Command, so a tree may be
+// executed repeatedly, and concurrently with distinct Envs, provided its hooks
+// do not share state themselves.
+func (c *Command[P]) Execute(ctx context.Context, e *Env[P]) (status ExitStatus) {
+	if c.OnExit != nil && !e.resolveOnly {
+		defer func() {
+			if r := recover(); r != nil {
+				c.OnExit(e, panicStatus(r))
+				panic(r)
+			}
+			c.OnExit(e, status)
+		}()
+	}
+	if c.OnReport != nil && !e.resolveOnly {
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				c.OnReport(e, newReport(e, start, panicStatus(r)))
+				panic(r)
+			}
+			c.OnReport(e, newReport(e, start, status))
+		}()
+	}
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if sig, ok := r.(exitSignal); ok {
+			status = sig.status
+			return
+		}
+		if c.NoRecover {
+			panic(panicValue(r))
+		}
+		c.recoverPanic(e, r)
+		status = ExitFailure
+	}()
+	defer e.runDeferred(len(e.deferred))
+
+	if !e.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, e.Deadline)
+		defer cancel()
+	}
+	e.cmds = nil
+	e.pathValues = nil
+	return (&invocation[P]{Command: c}).execute(ctx, e, scope{})
+}
+
+// hasBuiltinHelp reports whether c handles a "help" subcommand token itself,
+// which it does when it has subcommands or help topics, and no subcommand
+// named "help".
+func (c *Command[P]) hasBuiltinHelp() bool {
+	if len(c.Subcommands) == 0 && len(c.Topics) == 0 {
+		return false
+	}
+	_, ok := c.subcommandIndex().names["help"]
+	return !ok
+}
+
+// lookupTopic returns the help topic of c with the given name, unless a
+// subcommand has the same name.
+func (c *Command[P]) lookupTopic(name string) (HelpTopic, bool) {
+	if _, ok := c.subcommandIndex().names[name]; ok {
+		return HelpTopic{}, false
+	}
+	for _, t := range c.Topics {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return HelpTopic{}, false
+}
+
+// runAction calls action, which is the action or Fallback of c, surrounded by
+// the PersistentBefore and PersistentAfter hooks of the commands on the
+// executed path. The PersistentAfter hooks of commands whose PersistentBefore
+// hook succeeded are called even if the action fails or panics.
+func (c *invocation[P]) runAction(ctx context.Context, e *Env[P], action func(context.Context, *Env[P]) (ExitStatus, error)) (status ExitStatus) {
+	path := e.cmds
+	var entered int
+	var actionErr error
+	if trace := c.traceFunc(e); trace != nil {
+		var end func(ExitStatus, error)
+		ctx, end = trace(ctx, e)
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(exitSignal); ok {
+					end(panicStatus(r), nil)
+				} else {
+					end(ExitFailure, fmt.Errorf("panic: %v", panicValue(r)))
+				}
+				panic(r)
+			}
+			end(status, actionErr)
+		}()
+	}
+	defer func() {
+		r := recover()
+		if r != nil {
+			status = panicStatus(r)
+		}
+		for _, a := range slices.Backward(path[:entered]) {
+			if a.PersistentAfter != nil {
+				a.PersistentAfter(e, status)
+			}
+		}
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	for _, a := range path {
+		if a.PersistentBefore != nil {
+			if err := a.PersistentBefore(ctx, e); err != nil {
+				actionErr = err
+				return c.onErr(e, err, c.exitStatus(e, err, ExitFailure))
+			}
+		}
+		entered++
+	}
+
+	ctx, cancel := c.withTimeout(ctx, e)
+	defer cancel()
+	var err error // set by the action, read only when it was not abandoned
+	status, abandoned := e.awaitAction(ctx, func(ctx context.Context, e *Env[P]) ExitStatus {
+		var status ExitStatus
+		status, err = action(ctx, e)
+		if (err != nil || status != ExitSuccess) && timedOut(ctx) {
+			err = context.Cause(ctx)
+			return c.onErr(e, err, ExitTimeout)
+		}
+		return c.onActionErr(e, err, status)
+	})
+	if abandoned {
+		actionErr = errAbandoned(ctx, e.ShutdownGrace)
+		if timedOut(ctx) {
+			return c.onErr(e, actionErr, ExitTimeout)
+		}
+		return c.onErr(e, actionErr, ExitCanceled)
+	}
+	actionErr = err
+	return status
+}
+
+// A scope holds state inherited from ancestor commands during execution.
+type scope struct {
+	varPrefix string // accumulated env var namespace
+	version   string // nearest Version of the command or an ancestor
+}
+
+func (c *invocation[P]) execute(ctx context.Context, e *Env[P], s scope) (status ExitStatus) {
+	e.cmds = append(e.cmds, c)
+	c.varPrefix = s.varPrefix + c.VarPrefix
+	if c.Version != "" {
+		s.version = c.Version
+	}
+
+	if c.Before != nil {
+		if err := c.Before(e); err != nil {
+			return c.onErr(e, err, c.exitStatus(e, err, ExitFailure))
+		}
+	}
+
 	if c.Flags != nil {
 		c.Flags(c.flagSet(), e.Params)
+		if loc := locale(e.cmds); loc != nil {
+			c.flagSet().VisitAll(func(f *flag.Flag) {
+				if v, ok := unwrapValue(f.Value).(localeValue); ok {
+					v.inheritLocale(*loc)
+					f.DefValue = f.Value.String()
+				}
+			})
+		}
+	}
+
+	for _, a := range e.cmds[:len(e.cmds)-1] {
+		for _, name := range a.Persistent {
+			if c.flagSet().Lookup(name) != nil {
+				return c.onErr(e, fmt.Errorf("flag -%s conflicts with a persistent flag of %s", name, a.Name), ExitFailure)
+			}
+		}
+	}
+
+	var showVersion builtinFlag
+	if c.Version != "" && c.flagSet().Lookup("version") == nil {
+		c.flagSet().Var(&showVersion, "version", "print version and exit")
+	}
+	var interactive builtinFlag
+	if c.PromptRequired && c.flagSet().Lookup("interactive") == nil {
+		c.flagSet().Var(&interactive, "interactive", "prompt for missing required values")
 	}
 
 	if len(e.Args) < 1 {
-		c.onErr(e, errors.New("no arguments provided"))
-		return ExitFailure
+		return c.onErr(e, errors.New("no arguments provided"), ExitFailure)
 	}
 
-	if err := c.flagSet().Parse(e.Args[1:]); err != nil {
+	fold := foldCase(e.cmds)
+	flagArgs := e.Args[1:]
+	if fold {
+		flagArgs = foldFlagArgs(c.flagSet(), flagArgs)
+	}
+	if err := c.flagSet().Parse(expandCounts(c.flagSet(), flagArgs)); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
-			c.onHelp(e)
+			switch format := helpFormat(c.flagSet(), flagArgs); format {
+			case "", "text":
+				c.onHelp(e)
+			case "json":
+				if err := c.onHelpJSON(e); err != nil {
+					return c.onErr(e, err, ExitFailure)
+				}
+			default:
+				return c.onUsageErr(e, fmt.Errorf("unknown help format %q", format), ExitUsage)
+			}
 			return ExitSuccess
 		}
-		c.onErr(e, err)
-		return ExitUsage
+		c.addSecretArgs(e, flagArgs)
+		return c.onUsageErr(e, c.suggestFlag(e, err), ExitUsage)
+	}
+
+	args := c.flagSet().Args()
+	if len(args) > 0 && len(c.Persistent) > 0 && hoists(c.lookupSubcommand(args[0], fold)) {
+		var hoisted []string
+		hoisted, args = c.hoistPersistent(args, fold)
+		if fold {
+			hoisted = foldFlagArgs(c.flagSet(), hoisted)
+		}
+		if err := c.flagSet().Parse(expandCounts(c.flagSet(), hoisted)); err != nil {
+			c.addSecretArgs(e, hoisted)
+			return c.onUsageErr(e, c.suggestFlag(e, err), ExitUsage)
+		}
+	}
+
+	if showVersion {
+		e.Printf("%s %s\n", c.Name, c.Version)
+		return ExitSuccess
 	}
 
 	c.meta = make(map[string]*flagMeta, c.flagSet().NFlag())
 	c.flagSet().VisitAll(func(f *flag.Flag) {
-		_, isBool := f.Value.(boolFlag)
+		if _, ok := f.Value.(*builtinFlag); ok {
+			return
+		}
+		isBool := isBoolFlag(f.Value)
 		c.meta[f.Name] = &flagMeta{
 			flagName:    f.Name,
 			value:       f.Value.String(),
-			valueSource: sourceDefault,
+			valueSource: SourceDefault,
 			isBool:      isBool,
+			isSecret:    slices.Contains(c.Secrets, f.Name),
 		}
 	})
 
 	c.flagSet().Visit(func(f *flag.Flag) {
-		m := c.meta[f.Name]
-		m.valueSource = sourceFlag
+		if m, ok := c.meta[f.Name]; ok {
+			m.valueSource = SourceFlag
+		}
 	})
 
 	keys := make([]string, 0, len(c.meta))
@@ -417,59 +2044,229 @@ func (c *Command[P]) Execute(ctx context.Context, e *Env[P]) ExitStatus {
 	}
 	slices.Sort(keys)
 
+	order := c.precedence(e)
+	if err := checkPrecedence(order); err != nil {
+		return c.onErr(e, err, ExitFailure)
+	}
+
 	for _, k := range keys {
 		m := c.meta[k]
-		if m.valueSource != sourceDefault {
+		if !outranks(order, SourceVar, m.valueSource) {
 			continue
 		}
 		varName, envValue, isSet := c.getVar(m.flagName, e)
+		if !isSet && c.SecretFiles && m.isSecret {
+			var err error
+			if varName, envValue, isSet, err = c.getVarFile(m.flagName, e); err != nil {
+				return c.onErr(e, err, ExitFailure)
+			}
+		}
 		if isSet {
-			if setErr := c.flagSet().Set(m.flagName, envValue); setErr != nil {
+			if setErr := c.setFlag(m.flagName, envValue); setErr != nil {
 				valErr := decoratedValueError{
 					rawValue: envValue,
-					source:   sourceVar,
+					source:   SourceVar,
 					varName:  varName,
 					isBool:   m.isBool,
+					isSecret: m.isSecret,
 					err:      setErr,
 				}
 
-				c.onErr(e, &valErr)
-				return ExitUsage
+				return c.onUsageErr(e, &valErr, ExitUsage)
 			}
 			m.varName = varName
 			m.value = envValue
-			m.valueSource = sourceVar
+			m.valueSource = SourceVar
+		}
+	}
+
+	if c.Config != nil {
+		config, err := c.Config(e)
+		if err != nil {
+			return c.onErr(e, err, c.exitStatus(e, err, ExitFailure))
+		}
+		for _, k := range keys {
+			m := c.meta[k]
+			if !outranks(order, SourceConfig, m.valueSource) {
+				continue
+			}
+			configValue, isSet := config[m.flagName]
+			if !isSet {
+				continue
+			}
+			if setErr := c.setFlag(m.flagName, configValue); setErr != nil {
+				valErr := decoratedValueError{
+					rawValue: configValue,
+					flagName: m.flagName,
+					source:   SourceConfig,
+					isBool:   m.isBool,
+					isSecret: m.isSecret,
+					err:      setErr,
+				}
+
+				return c.onUsageErr(e, &valErr, ExitUsage)
+			}
+			m.value = configValue
+			m.valueSource = SourceConfig
+		}
+	}
+
+	if err := c.expandFlags(e); err != nil {
+		return c.onUsageErr(e, err, ExitUsage)
+	}
+
+	if c.PromptRequired && (bool(interactive) || e.InputMode() == InputTerminal) {
+		if err := c.promptRequired(e); err != nil {
+			return c.onErr(e, err, ExitFailure)
+		}
+	}
+
+	for _, k := range keys {
+		if m := c.meta[k]; m.isSecret && m.valueSource != SourceDefault {
+			e.redactor().AddValue(m.value)
 		}
 	}
+	if err := c.normalizeFlags(e, keys); err != nil {
+		return c.onUsageErr(e, err, ExitUsage)
+	}
+	c.traceFlags(e, keys)
+
+	if err := c.checkDeprecations(e, s.version); err != nil {
+		return c.onUsageErr(e, err, ExitUsage)
+	}
+
+	if err := c.checkRequired(); err != nil {
+		return c.onUsageErr(e, err, ExitUsage)
+	}
+	if err := c.checkGroups(); err != nil {
+		return c.onUsageErr(e, err, ExitUsage)
+	}
+
+	e.Args = args
 
-	e.Args = c.flagSet().Args()
+	if err := ctx.Err(); err != nil {
+		return c.onErr(e, err, ExitCanceled)
+	}
 
 	if c.After != nil {
 		if err := c.After(e); err != nil {
-			if valErr, isValErr := err.(*ValueError); isValErr {
-				err = c.decorateValueError(valErr)
+			if valErr, isValErr := c.decorateValueErrors(err); isValErr {
+				return c.onUsageErr(e, valErr, ExitUsage)
 			}
-			c.onErr(e, err)
-			return ExitUsage
+			return c.onErr(e, err, c.exitStatus(e, err, ExitUsage))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return c.onErr(e, err, ExitCanceled)
+	}
+
+	if c.Defer != nil && !e.resolveOnly {
+		defer func() {
+			if r := recover(); r != nil {
+				c.Defer(e, panicStatus(r))
+				panic(r)
+			}
+			c.Defer(e, status)
+		}()
+	}
+
+	return c.dispatch(ctx, e, s)
+}
+
+// dispatch completes the execution of c once its flags are resolved, by
+// executing the subcommand named by e.Args, or else its own action.
+func (c *invocation[P]) dispatch(ctx context.Context, e *Env[P], s scope) ExitStatus {
+	if len(c.Subcommands) > 0 {
+		if err := c.subcommandIndex().err; err != nil {
+			return c.onErr(e, err, ExitFailure)
+		}
+	}
+
+	if len(e.Args) > 0 && e.Args[0] == "help" && c.hasBuiltinHelp() {
+		if len(e.Args) == 1 {
+			c.onHelp(e)
+			return ExitSuccess
+		}
+		if c.lookupSubcommand(e.Args[1], foldCase(e.cmds)) == nil {
+			topic, ok := c.lookupTopic(e.Args[1])
+			switch {
+			case !ok:
+				err := fmt.Errorf("unknown command or help topic %q", e.Args[1])
+				return c.onUsageErr(e, err, ExitUsage)
+			case len(e.Args) > 2:
+				err := fmt.Errorf("help topic %q accepts no args", e.Args[1])
+				return c.onUsageErr(e, err, ExitUsage)
+			}
+			e.Printf("%s\n", strings.TrimRight(topic.Help, "\n"))
+			return ExitSuccess
+		}
+		// "help sub..." is handled as "sub... -h"
+		e.Args = append(slices.Clone(e.Args[1:]), "-h")
+	}
+
+	if len(e.Args) > 0 {
+		def, ok, err := c.lookupAlias(e, e.Args[0])
+		if err != nil {
+			return c.onErr(e, err, c.exitStatus(e, err, ExitFailure))
+		}
+		if ok {
+			args, err := expandAlias(def, e.Args[1:])
+			if err != nil {
+				return c.onUsageErr(e, fmt.Errorf("alias %q: %w", e.Args[0], err), ExitUsage)
+			}
+			c.tracef(e, "expanded alias %q to %q", e.Args[0], args)
+			e.Args = args
 		}
 	}
 
 	if len(e.Args) > 0 {
-		subCmd := c.lookupSubcommand(e.Args[0])
+		subCmd := c.lookupSubcommand(e.Args[0], foldCase(e.cmds))
 		if subCmd != nil {
-			return subCmd.Execute(ctx, e)
+			if name, ok := paramName(subCmd.Name); ok {
+				if e.pathValues == nil {
+					e.pathValues = make(map[string]string)
+				}
+				e.pathValues[name] = e.Args[0]
+			}
+			c.tracef(e, "arg %q matched subcommand %s", e.Args[0], subCmd.Name)
+			return (&invocation[P]{Command: subCmd}).execute(ctx, e, scope{varPrefix: c.varPrefix, version: s.version})
+		}
+		if len(c.Subcommands) > 0 {
+			if err := c.checkAmbiguous(e.Args[0], foldCase(e.cmds)); err != nil {
+				return c.onUsageErr(e, err, ExitFailure)
+			}
+			if c.Fallback != nil && !e.resolveOnly {
+				c.tracef(e, "no subcommand matched %q, calling Fallback", e.Args[0])
+				return c.runAction(ctx, e, func(ctx context.Context, e *Env[P]) (ExitStatus, error) {
+					return c.Fallback(ctx, e), nil
+				})
+			}
+			if c.argsPolicy(e) == ArgsStrict {
+				return c.onUsageErr(e, errUnknownCommand, ExitFailure)
+			}
 		}
 	}
 
-	if c.Action != nil {
-		return c.Action(ctx, e)
+	if c.Args != nil && c.hasAction() {
+		if err := c.Args(e.Args); err != nil {
+			return c.onUsageErr(e, err, ExitUsage)
+		}
+	}
+
+	if e.resolveOnly {
+		e.resolved = true
+		return ExitSuccess
+	}
+
+	if c.hasAction() {
+		c.tracef(e, "running action with args %q", e.Args)
+		return c.runAction(ctx, e, c.callAction)
 	}
 
 	if len(e.Args) == 0 {
-		c.onErr(e, errMissingCommand)
-		return ExitFailure
+		return c.onUsageErr(e, errMissingCommand, ExitFailure)
 	}
 
-	c.onErr(e, errUnknownCommand)
-	return ExitFailure
+	return c.onUsageErr(e, errUnknownCommand, ExitFailure)
 }
@@ -6,8 +6,12 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	cli "github.com/jonathonwebb/tinycli"
@@ -62,6 +66,9 @@ func TestDefaultEnv(t *testing.T) {
 
 	env := cli.DefaultEnv(data)
 
+	if want, got := io.Reader(os.Stdin), env.In; want != got {
+		t.Errorf("DefaultEnv(%+v).In = %v, want %v", data, got, want)
+	}
 	if want, got := os.Stderr, env.Err; want != got {
 		t.Errorf("DefaultEnv(%+v).Err = %v, want %v", data, got, want)
 	}
@@ -85,6 +92,72 @@ func TestDefaultEnv(t *testing.T) {
 	}
 }
 
+func TestEnv_varCaseFolding(t *testing.T) {
+	type p struct {
+		Path string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.Path, "path", "", "")
+			},
+			Vars: map[string]string{
+				"path": "PATH",
+			},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				return cli.ExitSuccess
+			},
+		}
+	}
+	vars := map[string]string{"pATH": `C:\other`, "Path": `C:\bin`}
+
+	t.Run("fold", func(t *testing.T) {
+		defer cli.SetFoldVarCase(true)()
+
+		var params p
+		gotParams, _, _, _, _ := execTestCommand(t, cmdFactory(), &params, tc[*p]{args: []string{"root"}, vars: vars})
+		if want, got := `C:\bin`, gotParams.Path; want != got {
+			t.Errorf("folded env var lookup=%q, want %q", got, want)
+		}
+	})
+
+	t.Run("no_fold", func(t *testing.T) {
+		defer cli.SetFoldVarCase(false)()
+
+		var params p
+		gotParams, _, _, _, _ := execTestCommand(t, cmdFactory(), &params, tc[*p]{args: []string{"root"}, vars: vars})
+		if want, got := "", gotParams.Path; want != got {
+			t.Errorf("case-sensitive env var lookup=%q, want %q", got, want)
+		}
+	})
+
+	t.Run("var_case_fold", func(t *testing.T) {
+		defer cli.SetFoldVarCase(false)()
+
+		cmd := cmdFactory()
+		cmd.VarCase = cli.MatchFold
+		var params p
+		gotParams, _, _, _, _ := execTestCommand(t, cmd, &params, tc[*p]{args: []string{"root"}, vars: vars})
+		if want, got := `C:\bin`, gotParams.Path; want != got {
+			t.Errorf("VarCase=MatchFold env var lookup=%q, want %q", got, want)
+		}
+	})
+
+	t.Run("var_case_exact", func(t *testing.T) {
+		defer cli.SetFoldVarCase(true)()
+
+		cmd := cmdFactory()
+		cmd.VarCase = cli.MatchExact
+		var params p
+		gotParams, _, _, _, _ := execTestCommand(t, cmd, &params, tc[*p]{args: []string{"root"}, vars: vars})
+		if want, got := "", gotParams.Path; want != got {
+			t.Errorf("VarCase=MatchExact env var lookup=%q, want %q", got, want)
+		}
+	})
+}
+
 type tc[T any] struct {
 	name string
 	args []string
@@ -133,6 +206,10 @@ func TestCommand_Execute(t *testing.T) {
 		NilVarMapStr string
 		NilFlagsStr  string
 		NilAfterStr  string
+
+		ReqStr      string
+		ReqInt      int
+		ReqFlagOnly string
 	}
 
 	cmdFactory := func() *cli.Command[*p] {
@@ -167,6 +244,13 @@ func TestCommand_Execute(t *testing.T) {
 						Err:  errCustomTest,
 					}
 				}
+				if e.Params.RootStr == "joined_err" {
+					return errors.Join(
+						&cli.ValueError{Name: "rootStr", Err: errCustomTest},
+						&cli.ValueError{Name: "rootInt", Err: errCustomTest},
+						errCustomTest,
+					)
+				}
 				return nil
 			},
 			Subcommands: []*cli.Command[*p]{
@@ -253,6 +337,25 @@ func TestCommand_Execute(t *testing.T) {
 					},
 					After: nil,
 				},
+				{
+					Name:  "required",
+					Usage: "required usage",
+					Help:  "required help",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.StringVar(&p.ReqStr, "reqStr", "", "")
+						fs.IntVar(&p.ReqInt, "reqInt", 0, "")
+						fs.StringVar(&p.ReqFlagOnly, "reqFlagOnly", "", "")
+					},
+					Vars: map[string]string{
+						"reqStr": "REQ_STR",
+						"reqInt": "REQ_INT",
+					},
+					Required: []string{"reqStr", "reqInt", "reqFlagOnly"},
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+						e.Printf("required out\n")
+						return cli.ExitSuccess
+					},
+				},
 			},
 		}
 
@@ -316,6 +419,73 @@ func TestCommand_Execute(t *testing.T) {
 			wantOutbuf: "sub usage\n\nsub help\n",
 			wantStatus: cli.ExitSuccess,
 		},
+		{
+			name: "help_json_sub",
+			args: []string{"root", "-rootBool", "nil_varmap", "--help=json"},
+			vars: map[string]string{},
+
+			wantOutbuf: `{
+  "name": "nil_varmap",
+  "usage": "nil_varmap usage",
+  "help": "nil_varmap help",
+  "flags": [
+    {
+      "name": "nilVarMapStr",
+      "type": "string",
+      "default": ""
+    }
+  ]
+}
+`,
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "help_json_bound_var",
+			args: []string{"root", "-rootStr", "x", "sub", "-subStr=y", "-help=json"},
+			vars: map[string]string{},
+
+			wantOutbuf: `{
+  "name": "sub",
+  "usage": "sub usage",
+  "help": "sub help",
+  "flags": [
+    {
+      "name": "subBool",
+      "type": "bool",
+      "default": "false",
+      "var": "SUB_BOOL",
+      "bool": true
+    },
+    {
+      "name": "subFlagOnly",
+      "type": "string",
+      "default": ""
+    },
+    {
+      "name": "subInt",
+      "type": "int",
+      "default": "0",
+      "var": "SUB_INT"
+    },
+    {
+      "name": "subStr",
+      "type": "string",
+      "default": "",
+      "var": "SUB_STR"
+    }
+  ]
+}
+`,
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "help_unknown_format",
+			args: []string{"root", "-help=xml"},
+			vars: map[string]string{},
+
+			wantErrbuf: "root usage\nunknown help format \"xml\"\n",
+			wantStatus: cli.ExitUsage,
+		},
 		{
 			name: "valid_flag",
 			args: []string{"root", "-rootStr=testVal", "sub"},
@@ -456,6 +626,17 @@ func TestCommand_Execute(t *testing.T) {
 			wantErrbuf: "root usage\ncustom test error\n",
 			wantStatus: cli.ExitUsage,
 		},
+		{
+			name: "after_joined_value_errs",
+			args: []string{"root", "-rootStr=joined_err", "sub"},
+			vars: map[string]string{"ROOT_INT": "7"},
+
+			wantErrbuf: "root usage\n" +
+				"invalid value \"joined_err\" for flag rootStr: custom test error\n" +
+				"invalid value \"7\" for var $ROOT_INT: custom test error\n" +
+				"custom test error\n",
+			wantStatus: cli.ExitUsage,
+		},
 		{
 			name: "after_generic_err",
 			args: []string{"root", "-rootStr=generic_err", "sub"},
@@ -463,6 +644,35 @@ func TestCommand_Execute(t *testing.T) {
 			wantErrbuf: "root usage\ncustom test error\n",
 			wantStatus: cli.ExitUsage,
 		},
+		{
+			name: "required_missing",
+			args: []string{"root", "required"},
+			vars: map[string]string{},
+
+			wantErrbuf: "required usage\nmissing: -reqStr (or $REQ_STR), -reqInt (or $REQ_INT), -reqFlagOnly\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name: "required_partial",
+			args: []string{"root", "required", "-reqFlagOnly=x"},
+			vars: map[string]string{
+				"REQ_INT": "1",
+			},
+
+			wantErrbuf: "required usage\nmissing: -reqStr (or $REQ_STR)\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name: "required_satisfied",
+			args: []string{"root", "required", "-reqStr=x", "-reqFlagOnly=y"},
+			vars: map[string]string{
+				"REQ_INT": "1",
+			},
+
+			wantOutbuf: "required out\n",
+			wantParams: &p{ReqStr: "x", ReqInt: 1, ReqFlagOnly: "y"},
+			wantStatus: cli.ExitSuccess,
+		},
 		{
 			name: "nil_cmd_flags_func",
 			args: []string{"root", "nil_flags"},
@@ -513,6 +723,1940 @@ func TestCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestCommand_Execute_helpJSONArgs(t *testing.T) {
+	cmd := &cli.Command[any]{
+		Name: "root",
+		Subcommands: []*cli.Command[any]{
+			{
+				Name:      "cat",
+				Usage:     "cat usage",
+				ArgsUsage: "[FILE...]",
+				Examples:  []string{"root cat a.txt b.txt"},
+				Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+					return cli.ExitSuccess
+				},
+			},
+		},
+	}
+
+	var outbuf bytes.Buffer
+	e := &cli.Env[any]{Args: []string{"root", "cat", "-help=json"}, Out: &outbuf}
+	if got := cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+		t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+	}
+	want := `{
+  "name": "cat",
+  "usage": "cat usage",
+  "args": "[FILE...]",
+  "examples": [
+    "root cat a.txt b.txt"
+  ]
+}
+`
+	if diff := cmp.Diff(want, outbuf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCommand_Execute_varsFunc(t *testing.T) {
+	type p struct {
+		Region string
+		Token  string
+		Local  string
+	}
+
+	cmd := &cli.Command[*p]{
+		Name: "root",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.StringVar(&p.Region, "region", "", "")
+			fs.StringVar(&p.Token, "token", "", "")
+			fs.StringVar(&p.Local, "local", "", "")
+		},
+		Vars: map[string]string{
+			"token": "SECRET_TOKEN",
+		},
+		VarsFunc: func(flagName string) (string, bool) {
+			if flagName == "local" {
+				return "", false
+			}
+			return "APP_" + strings.ToUpper(flagName), true
+		},
+		Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+			return cli.ExitSuccess
+		},
+	}
+
+	var params p
+	gotParams, _, _, _, gotStatus := execTestCommand(t, cmd, &params, tc[*p]{
+		args: []string{"root"},
+		vars: map[string]string{
+			"APP_REGION":   "us-east-1",
+			"APP_TOKEN":    "ignored",
+			"SECRET_TOKEN": "s3cr3t",
+			"APP_LOCAL":    "ignored",
+		},
+	})
+
+	if want, got := cli.ExitSuccess, gotStatus; want != got {
+		t.Errorf("cmd.Execute()=%v, want %v", got, want)
+	}
+	if diff := cmp.Diff(&p{Region: "us-east-1", Token: "s3cr3t"}, gotParams); diff != "" {
+		t.Errorf("cmd.Execute() params mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAutoVars(t *testing.T) {
+	type p struct {
+		Port     int
+		LogLevel string
+	}
+
+	cmd := &cli.Command[*p]{
+		Name: "root",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.IntVar(&p.Port, "port", 0, "")
+			fs.StringVar(&p.LogLevel, "log-level", "", "")
+		},
+		Vars:     map[string]string{"port": "PORT"},
+		VarsFunc: cli.AutoVars("FOO_"),
+		Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+			return cli.ExitSuccess
+		},
+	}
+
+	var params p
+	gotParams, _, _, _, gotStatus := execTestCommand(t, cmd, &params, tc[*p]{
+		args: []string{"root"},
+		vars: map[string]string{
+			"PORT":          "8080",
+			"FOO_PORT":      "ignored",
+			"FOO_LOG_LEVEL": "debug",
+		},
+	})
+
+	if want, got := cli.ExitSuccess, gotStatus; want != got {
+		t.Errorf("cmd.Execute()=%v, want %v", got, want)
+	}
+	if diff := cmp.Diff(&p{Port: 8080, LogLevel: "debug"}, gotParams); diff != "" {
+		t.Errorf("cmd.Execute() params mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCommand_Execute_config(t *testing.T) {
+	type p struct {
+		Port    int
+		Host    string
+		Verbose bool
+		Region  string
+	}
+
+	cmdFactory := func(config map[string]string, configErr error) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.IntVar(&p.Port, "port", 5000, "")
+				fs.StringVar(&p.Host, "host", "localhost", "")
+				fs.BoolVar(&p.Verbose, "v", false, "")
+				fs.StringVar(&p.Region, "region", "", "")
+			},
+			Vars: map[string]string{
+				"host": "HOST",
+			},
+			Required: []string{"region"},
+			Config: func(e *cli.Env[*p]) (map[string]string, error) {
+				return config, configErr
+			},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				return cli.ExitSuccess
+			},
+		}
+	}
+
+	tests := []struct {
+		tc[*p]
+		config    map[string]string
+		configErr error
+	}{
+		{
+			tc: tc[*p]{
+				name: "precedence",
+				args: []string{"root", "-v=false"},
+				vars: map[string]string{
+					"HOST": "example.com",
+				},
+
+				wantParams: &p{Port: 8080, Host: "example.com", Region: "eu"},
+				wantStatus: cli.ExitSuccess,
+			},
+			config: map[string]string{
+				"port":   "8080",
+				"host":   "ignored",
+				"v":      "true",
+				"region": "eu",
+			},
+		},
+		{
+			tc: tc[*p]{
+				name: "required_missing",
+				args: []string{"root"},
+
+				wantErrbuf: "root usage\nmissing: -region\n",
+				wantStatus: cli.ExitUsage,
+			},
+			config: map[string]string{
+				"port": "8080",
+			},
+		},
+		{
+			tc: tc[*p]{
+				name: "invalid_value",
+				args: []string{"root"},
+
+				wantErrbuf: "root usage\ninvalid value \"invalid\" for config key port: parse error\n",
+				wantStatus: cli.ExitUsage,
+			},
+			config: map[string]string{
+				"port": "invalid",
+			},
+		},
+		{
+			tc: tc[*p]{
+				name: "invalid_bool_value",
+				args: []string{"root"},
+
+				wantErrbuf: "root usage\ninvalid boolean value \"invalid\" for config key v: parse error\n",
+				wantStatus: cli.ExitUsage,
+			},
+			config: map[string]string{
+				"v": "invalid",
+			},
+		},
+		{
+			tc: tc[*p]{
+				name: "config_err",
+				args: []string{"root"},
+
+				wantErrbuf: "root usage\ncustom test error\n",
+				wantStatus: cli.ExitFailure,
+			},
+			configErr: errCustomTest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var params p
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(tt.config, tt.configErr), &params, tt.tc)
+
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("%s: cmd.Execute()=%v, want %v", tt.name, got, want)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("%s: cmd.Execute err buffer mismatch (-want +got):\n%s", tt.name, diff)
+			}
+			if tt.wantParams != nil {
+				if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+					t.Errorf("%s: cmd.Execute() params mismatch (-want +got):\n%s", tt.name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_varPrefix(t *testing.T) {
+	type p struct {
+		LogLevel  string
+		Debug     bool
+		ServePort int
+		AdminPort int
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:      "root",
+			Usage:     "root usage",
+			VarPrefix: "MYAPP_",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.LogLevel, "log-level", "", "")
+				fs.BoolVar(&p.Debug, "debug", false, "")
+			},
+			Vars: map[string]string{
+				"debug": "DEBUG",
+			},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name:      "serve",
+					Usage:     "serve usage",
+					VarPrefix: "SERVE_",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.IntVar(&p.ServePort, "port", 0, "")
+					},
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+						return cli.ExitSuccess
+					},
+				},
+				{
+					Name:      "admin",
+					Usage:     "admin usage",
+					VarPrefix: "ADMIN_",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.IntVar(&p.AdminPort, "port", 0, "")
+					},
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []tc[*p]{
+		{
+			name: "serve",
+			args: []string{"root", "serve"},
+			vars: map[string]string{
+				"MYAPP_LOG_LEVEL":  "debug",
+				"DEBUG":            "true",
+				"MYAPP_DEBUG":      "false",
+				"MYAPP_SERVE_PORT": "8080",
+				"MYAPP_ADMIN_PORT": "9090",
+			},
+
+			wantParams: &p{LogLevel: "debug", Debug: true, ServePort: 8080},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "admin",
+			args: []string{"root", "admin"},
+			vars: map[string]string{
+				"MYAPP_SERVE_PORT": "8080",
+				"MYAPP_ADMIN_PORT": "9090",
+			},
+
+			wantParams: &p{AdminPort: 9090},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "invalid",
+			args: []string{"root", "admin"},
+			vars: map[string]string{
+				"MYAPP_ADMIN_PORT": "invalid",
+			},
+
+			wantErrbuf: "admin usage\ninvalid value \"invalid\" for var $MYAPP_ADMIN_PORT: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var params p
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(), &params, tt)
+
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("%s: cmd.Execute()=%v, want %v", tt.name, got, want)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("%s: cmd.Execute err buffer mismatch (-want +got):\n%s", tt.name, diff)
+			}
+			if tt.wantParams != nil {
+				if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+					t.Errorf("%s: cmd.Execute() params mismatch (-want +got):\n%s", tt.name, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_canceled(t *testing.T) {
+	t.Run("before_parse", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		var called bool
+		var errbuf bytes.Buffer
+		cmd := &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				called = true
+				return cli.ExitSuccess
+			},
+		}
+		status := cmd.Execute(ctx, &cli.Env[any]{Err: &errbuf, Args: []string{"root"}})
+
+		if want, got := cli.ExitCanceled, status; want != got {
+			t.Errorf("cmd.Execute()=%v, want %v", got, want)
+		}
+		if called {
+			t.Errorf("cmd.Execute() called Action after cancellation")
+		}
+		if diff := cmp.Diff("root usage\ncontext canceled\n", errbuf.String()); diff != "" {
+			t.Errorf("cmd.Execute err buffer mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("in_after", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		var called bool
+		var errbuf bytes.Buffer
+		cmd := &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			After: func(e *cli.Env[any]) error {
+				cancel()
+				return nil
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name: "sub",
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+						called = true
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+		status := cmd.Execute(ctx, &cli.Env[any]{Err: &errbuf, Args: []string{"root", "sub"}})
+
+		if want, got := cli.ExitCanceled, status; want != got {
+			t.Errorf("cmd.Execute()=%v, want %v", got, want)
+		}
+		if called {
+			t.Errorf("cmd.Execute() dispatched to subcommand after cancellation")
+		}
+		if diff := cmp.Diff("root usage\ncontext canceled\n", errbuf.String()); diff != "" {
+			t.Errorf("cmd.Execute err buffer mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestCommand_Execute_deadline(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+
+	var got time.Time
+	var ok bool
+	cmd := &cli.Command[any]{
+		Name: "root",
+		Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+			got, ok = ctx.Deadline()
+			return cli.ExitSuccess
+		},
+	}
+	cmd.Execute(t.Context(), &cli.Env[any]{Args: []string{"root"}, Deadline: deadline})
+
+	if !ok || !got.Equal(deadline) {
+		t.Errorf("ctx.Deadline()=(%v, %t), want (%v, true)", got, ok, deadline)
+	}
+
+	var errbuf bytes.Buffer
+	status := cmd.Execute(t.Context(), &cli.Env[any]{
+		Err:      &errbuf,
+		Args:     []string{"root"},
+		Deadline: time.Now().Add(-time.Second),
+	})
+	if want, got := cli.ExitCanceled, status; want != got {
+		t.Errorf("cmd.Execute() past deadline=%v, want %v", got, want)
+	}
+	if diff := cmp.Diff("\ncontext deadline exceeded\n", errbuf.String()); diff != "" {
+		t.Errorf("cmd.Execute err buffer mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCommand_Execute_onExit(t *testing.T) {
+	type call struct {
+		Args   []string
+		Status cli.ExitStatus
+	}
+
+	cmdFactory := func(calls *[]call) *cli.Command[any] {
+		onExit := func(e *cli.Env[any], status cli.ExitStatus) {
+			*calls = append(*calls, call{Args: e.Args, Status: status})
+		}
+		return &cli.Command[any]{
+			Name:      "root",
+			OnExit:    onExit,
+			NoRecover: true,
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:   "sub",
+					OnExit: onExit, // ignored, sub is not the executed root
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+						switch {
+						case len(e.Args) > 0 && e.Args[0] == "panic":
+							panic("boom")
+						case len(e.Args) > 0 && e.Args[0] == "fail":
+							return cli.ExitFailure
+						}
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		want      []call
+		wantPanic bool
+	}{
+		{
+			name: "success",
+			args: []string{"root", "sub"},
+			want: []call{{Args: []string{}, Status: cli.ExitSuccess}},
+		},
+		{
+			name: "failure",
+			args: []string{"root", "sub", "fail"},
+			want: []call{{Args: []string{"fail"}, Status: cli.ExitFailure}},
+		},
+		{
+			name: "error",
+			args: []string{"root", "unknown"},
+			want: []call{{Args: []string{"unknown"}, Status: cli.ExitFailure}},
+		},
+		{
+			name:      "panic",
+			args:      []string{"root", "sub", "panic"},
+			want:      []call{{Args: []string{"panic"}, Status: cli.ExitFailure}},
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []call
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.wantPanic {
+						t.Errorf("cmd.Execute() panic=%v, want panic: %t", r, tt.wantPanic)
+					}
+				}()
+				cmdFactory(&calls).Execute(t.Context(), &cli.Env[any]{Args: tt.args})
+			}()
+
+			if diff := cmp.Diff(tt.want, calls); diff != "" {
+				t.Errorf("OnExit calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_helpFunc(t *testing.T) {
+	cmd := &cli.Command[[]string]{
+		Name:  "root",
+		Usage: "root usage",
+		Help:  "static help",
+		HelpFunc: func(e *cli.Env[[]string]) string {
+			return "plugins: " + strings.Join(e.Params, ", ")
+		},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "text",
+			args: []string{"root", "-h"},
+			want: "root usage\n\nplugins: a, b\n",
+		},
+		{
+			name: "json",
+			args: []string{"root", "-help=json"},
+			want: `{
+  "name": "root",
+  "usage": "root usage",
+  "help": "plugins: a, b"
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf bytes.Buffer
+			e := &cli.Env[[]string]{Args: tt.args, Out: &outbuf, Params: []string{"a", "b"}}
+			if want, got := cli.ExitSuccess, cmd.Execute(t.Context(), e); want != got {
+				t.Errorf("cmd.Execute() = %d, want %d", got, want)
+			}
+			if diff := cmp.Diff(tt.want, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_usageFunc(t *testing.T) {
+	cmdFactory := func() *cli.Command[any] {
+		return &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			UsageFunc: func(e *cli.Env[any], c *cli.Command[any]) {
+				e.Printf("USAGE: %s\n", c.Usage)
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:   "sub",
+					Usage:  "sub usage",
+					Args:   cli.NoArgs,
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus { return cli.ExitSuccess },
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "help_flag",
+			args:       []string{"root", "sub", "-h"},
+			wantOutbuf: "USAGE: sub usage\n",
+		},
+		{
+			name:       "help_command",
+			args:       []string{"root", "help"},
+			wantOutbuf: "USAGE: root usage\n",
+		},
+		{
+			name:       "error",
+			args:       []string{"root", "sub", "x"},
+			wantErrbuf: "USAGE: sub usage\naccepts no args, received 1\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Err: &errbuf}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_helpCommand(t *testing.T) {
+	cmdFactory := func() *cli.Command[any] {
+		return &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			Help:  "root help",
+			Topics: []cli.HelpTopic{
+				{Name: "environment", Help: "FOO_HOME sets the home directory.\n"},
+				{Name: "sub", Help: "shadowed by the sub command"},
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:   "sub",
+					Usage:  "sub usage",
+					Help:   "sub help",
+					Topics: []cli.HelpTopic{{Name: "config", Help: "sub config"}},
+					Subcommands: []*cli.Command[any]{
+						{Name: "subsub", Usage: "subsub usage", Help: "subsub help"},
+						{
+							Name: "help",
+							Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+								e.Printf("custom help\n")
+								return cli.ExitSuccess
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "root",
+			args:       []string{"root", "help"},
+			wantOutbuf: "root usage\n\nroot help\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "sub",
+			args:       []string{"root", "help", "sub"},
+			wantOutbuf: "sub usage\n\nsub help\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "subsub",
+			args:       []string{"root", "help", "sub", "subsub"},
+			wantOutbuf: "subsub usage\n\nsubsub help\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "topic",
+			args:       []string{"root", "help", "environment"},
+			wantOutbuf: "FOO_HOME sets the home directory.\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "topic_extra_args",
+			args:       []string{"root", "help", "environment", "x"},
+			wantErrbuf: "root usage\nhelp topic \"environment\" accepts no args\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "defined",
+			args:       []string{"root", "sub", "help"},
+			wantOutbuf: "custom help\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "unknown",
+			args:       []string{"root", "help", "bogus"},
+			wantErrbuf: "root usage\nunknown command or help topic \"bogus\"\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Err: &errbuf}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("unknown_with_action", func(t *testing.T) {
+		var (
+			errbuf bytes.Buffer
+			ran    bool
+		)
+		cmd := cmdFactory()
+		cmd.Action = func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+			ran = true
+			return cli.ExitSuccess
+		}
+		e := &cli.Env[any]{Args: []string{"root", "help", "nosuch"}, Err: &errbuf}
+		if got := cmd.Execute(t.Context(), e); got != cli.ExitUsage {
+			t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitUsage)
+		}
+		if ran {
+			t.Error("help of an unknown command ran the root action")
+		}
+		if diff := cmp.Diff("root usage\nunknown command or help topic \"nosuch\"\n", errbuf.String()); diff != "" {
+			t.Errorf("error output mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestCommand_Execute_globalFlags(t *testing.T) {
+	type p struct {
+		Verbose bool
+		Region  string
+		Port    int
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:      "root",
+			VarPrefix: "APP_",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.BoolVar(&p.Verbose, "v", false, "enable verbose output")
+				fs.StringVar(&p.Region, "region", "", "region name")
+			},
+			Vars:       map[string]string{"v": ""},
+			Persistent: []string{"v", "region"},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name:  "serve",
+					Usage: "serve usage",
+					Help:  "serve help",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.IntVar(&p.Port, "port", 8080, "port number")
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "text",
+			args: []string{"root", "serve", "-h"},
+			want: `serve usage
+
+serve help
+
+global flags:
+  -v       enable verbose output
+  -region  region name ($APP_REGION)
+`,
+		},
+		{
+			name: "json",
+			args: []string{"root", "serve", "-help=json"},
+			want: `{
+  "name": "serve",
+  "usage": "serve usage",
+  "help": "serve help",
+  "flags": [
+    {
+      "name": "port",
+      "type": "int",
+      "usage": "port number",
+      "default": "8080",
+      "var": "APP_PORT"
+    }
+  ],
+  "globalFlags": [
+    {
+      "name": "v",
+      "type": "bool",
+      "usage": "enable verbose output",
+      "default": "false",
+      "bool": true
+    },
+    {
+      "name": "region",
+      "type": "string",
+      "usage": "region name",
+      "default": "",
+      "var": "APP_REGION"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Out: &outbuf, Params: &p{}}
+			if want, got := cli.ExitSuccess, cmdFactory().Execute(t.Context(), e); want != got {
+				t.Errorf("cmd.Execute() = %d, want %d", got, want)
+			}
+			if diff := cmp.Diff(tt.want, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_usagePolicy(t *testing.T) {
+	cmdFactory := func(policy, subPolicy cli.UsagePolicy) *cli.Command[any] {
+		return &cli.Command[any]{
+			Name:        "root",
+			Usage:       "root usage",
+			UsagePolicy: policy,
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:        "sub",
+					Usage:       "sub usage",
+					UsagePolicy: subPolicy,
+					After: func(e *cli.Env[any]) error {
+						if len(e.Args) > 0 {
+							return errCustomTest
+						}
+						return nil
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     cli.UsagePolicy
+		subPolicy  cli.UsagePolicy
+		args       []string
+		wantErrbuf string
+	}{
+		{
+			name:       "always_runtime",
+			args:       []string{"root", "sub", "x"},
+			wantErrbuf: "sub usage\n" + errCustomTest.Error() + "\n",
+		},
+		{
+			name:       "usage_errs_runtime",
+			policy:     cli.UsageOnUsageErrs,
+			args:       []string{"root", "sub", "x"},
+			wantErrbuf: errCustomTest.Error() + "\n",
+		},
+		{
+			name:       "usage_errs_usage",
+			policy:     cli.UsageOnUsageErrs,
+			args:       []string{"root", "sub", "-unknown"},
+			wantErrbuf: "sub usage\nflag provided but not defined: -unknown\n",
+		},
+		{
+			name:       "never_usage",
+			policy:     cli.UsageNever,
+			args:       []string{"root", "unknown"},
+			wantErrbuf: "unknown command\n",
+		},
+		{
+			name:       "sub_overrides",
+			policy:     cli.UsageNever,
+			subPolicy:  cli.UsageAlways,
+			args:       []string{"root", "sub", "x"},
+			wantErrbuf: "sub usage\n" + errCustomTest.Error() + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			cmdFactory(tt.policy, tt.subPolicy).Execute(t.Context(), &cli.Env[any]{Args: tt.args, Err: &errbuf})
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_argsPolicy(t *testing.T) {
+	cmdFactory := func(policy cli.ArgsPolicy, fallback bool, got *[]string) *cli.Command[any] {
+		record := func(name string) cli.ActionFunc[any] {
+			return func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				*got = append([]string{name}, e.Args...)
+				return cli.ExitSuccess
+			}
+		}
+		cmd := &cli.Command[any]{
+			Name:       "root",
+			Usage:      "root usage",
+			ArgsPolicy: policy,
+			Action:     record("root"),
+			Subcommands: []*cli.Command[any]{
+				{Name: "sub", Action: record("sub")},
+			},
+		}
+		if fallback {
+			cmd.Fallback = record("fallback")
+		}
+		return cmd
+	}
+
+	tests := []struct {
+		name       string
+		policy     cli.ArgsPolicy
+		fallback   bool
+		args       []string
+		wantGot    []string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:    "passthrough_default",
+			args:    []string{"root", "a.txt", "b.txt"},
+			wantGot: []string{"root", "a.txt", "b.txt"},
+		},
+		{
+			name:    "passthrough_sub",
+			policy:  cli.ArgsPassthrough,
+			args:    []string{"root", "sub", "x"},
+			wantGot: []string{"sub", "x"},
+		},
+		{
+			name:       "strict",
+			policy:     cli.ArgsStrict,
+			args:       []string{"root", "a.txt"},
+			wantErrbuf: "root usage\nunknown command\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:    "strict_no_args",
+			policy:  cli.ArgsStrict,
+			args:    []string{"root"},
+			wantGot: []string{"root"},
+		},
+		{
+			name:     "fallback",
+			policy:   cli.ArgsStrict,
+			fallback: true,
+			args:     []string{"root", "a.txt"},
+			wantGot:  []string{"fallback", "a.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				errbuf bytes.Buffer
+				got    []string
+			)
+			e := &cli.Env[any]{Args: tt.args, Err: &errbuf}
+			if status := cmdFactory(tt.policy, tt.fallback, &got).Execute(t.Context(), e); status != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", status, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantGot, got); diff != "" {
+				t.Errorf("action args mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_persistent(t *testing.T) {
+	type p struct {
+		Env     string
+		Verbose bool
+		Port    int
+	}
+
+	cmdFactory := func(conflict bool) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.Env, "env", "prod", "")
+				fs.BoolVar(&p.Verbose, "v", false, "")
+			},
+			Vars:       map[string]string{"env": "ENV"},
+			Required:   []string{"env"},
+			Persistent: []string{"env", "v"},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name: "serve",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.IntVar(&p.Port, "port", 8080, "")
+						if conflict {
+							fs.StringVar(&p.Env, "env", "", "")
+						}
+					},
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+						e.Printf("%+v %q\n", *e.Params, e.Args)
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		conflict   bool
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "before_subcommand",
+			args:       []string{"root", "-env=dev", "serve", "-port=80"},
+			wantOutbuf: "{Env:dev Verbose:false Port:80} []\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "after_subcommand",
+			args:       []string{"root", "serve", "-env", "dev", "-port=80", "-v", "arg"},
+			wantOutbuf: "{Env:dev Verbose:true Port:80} [\"arg\"]\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "after_subcommand_over_var",
+			args:       []string{"root", "serve", "--env=dev"},
+			vars:       map[string]string{"ENV": "staging"},
+			wantOutbuf: "{Env:dev Verbose:false Port:8080} []\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "after_terminator",
+			args:       []string{"root", "-env=dev", "serve", "--", "-env=x"},
+			wantOutbuf: "{Env:dev Verbose:false Port:8080} [\"-env=x\"]\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "required_after_subcommand",
+			args:       []string{"root", "serve"},
+			wantErrbuf: "\nmissing: -env (or $ENV)\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "invalid_after_subcommand",
+			args:       []string{"root", "serve", "-v=maybe"},
+			wantErrbuf: "\ninvalid boolean value \"maybe\" for -v: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "conflict",
+			args:       []string{"root", "-env=dev", "serve"},
+			conflict:   true,
+			wantErrbuf: "\nflag -env conflicts with a persistent flag of root\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Vars: tt.vars, Out: &outbuf, Err: &errbuf, Params: &p{}}
+			if got := cmdFactory(tt.conflict).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_actionE(t *testing.T) {
+	type p struct {
+		Port int
+	}
+
+	cmdFactory := func(err error) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.IntVar(&p.Port, "port", 0, "")
+			},
+			ActionE: func(ctx context.Context, e *cli.Env[*p]) error {
+				return err
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "nil",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "generic",
+			err:        errCustomTest,
+			wantErrbuf: "root usage\n" + errCustomTest.Error() + "\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "exit_error",
+			err:        fmt.Errorf("wrapped: %w", &cli.ExitError{Status: 3, Err: errCustomTest}),
+			wantErrbuf: "root usage\nwrapped: " + errCustomTest.Error() + "\n",
+			wantStatus: 3,
+		},
+		{
+			name:       "value_error",
+			err:        &cli.ValueError{Name: "port", Err: errCustomTest},
+			wantErrbuf: "root usage\ninvalid value \"80\" for flag port: " + errCustomTest.Error() + "\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "canceled",
+			err:        context.Canceled,
+			wantErrbuf: "root usage\ncontext canceled\n",
+			wantStatus: cli.ExitCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: []string{"root", "-port=80"}, Err: &errbuf, Params: &p{}}
+			if got := cmdFactory(tt.err).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_errorMapper(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	cmdFactory := func(hook string, err error) *cli.Command[any] {
+		return &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			ErrorMapper: func(err error) (cli.ExitStatus, bool) {
+				switch {
+				case errors.Is(err, context.DeadlineExceeded):
+					return cli.ExitTempFail, true
+				case errors.Is(err, errNotFound):
+					return cli.ExitNoInput, true
+				}
+				return 0, false
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name: "sub",
+					ErrorMapper: func(err error) (cli.ExitStatus, bool) {
+						if errors.Is(err, errCustomTest) {
+							return cli.ExitConfig, true
+						}
+						return 0, false
+					},
+					Before: func(e *cli.Env[any]) error {
+						if hook == "before" {
+							return err
+						}
+						return nil
+					},
+					After: func(e *cli.Env[any]) error {
+						if hook == "after" {
+							return err
+						}
+						return nil
+					},
+					ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+						return err
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		hook       string
+		err        error
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "unmapped",
+			err:        errors.New("other"),
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "nearest",
+			err:        errCustomTest,
+			wantStatus: cli.ExitConfig,
+		},
+		{
+			name:       "inherited",
+			err:        fmt.Errorf("loading: %w", errNotFound),
+			wantStatus: cli.ExitNoInput,
+		},
+		{
+			name:       "deadline",
+			err:        context.DeadlineExceeded,
+			wantStatus: cli.ExitTempFail,
+		},
+		{
+			name:       "exit_error",
+			err:        &cli.ExitError{Status: 3, Err: errNotFound},
+			wantStatus: 3,
+		},
+		{
+			name:       "before",
+			hook:       "before",
+			err:        errNotFound,
+			wantStatus: cli.ExitNoInput,
+		},
+		{
+			name:       "after",
+			hook:       "after",
+			err:        errNotFound,
+			wantStatus: cli.ExitNoInput,
+		},
+		{
+			name:       "after_unmapped",
+			hook:       "after",
+			err:        errors.New("other"),
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &cli.Env[any]{Args: []string{"root", "sub"}, Err: io.Discard}
+			if got := cmdFactory(tt.hook, tt.err).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_errorHandler(t *testing.T) {
+	type call struct {
+		Err    string
+		Status cli.ExitStatus
+	}
+
+	cmdFactory := func(calls *[]call) *cli.Command[any] {
+		return &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			ErrorHandler: func(e *cli.Env[any], err error, status cli.ExitStatus) {
+				*calls = append(*calls, call{Err: err.Error(), Status: status})
+				e.Errorf("error: %v (run '%s -h' for usage)\n", err, strings.Join(e.CommandPath(), " "))
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:  "sub",
+					Usage: "sub usage",
+					ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+						if len(e.Args) > 0 {
+							return &cli.ExitError{Status: cli.ExitUnavailable, Err: errCustomTest}
+						}
+						return errCustomTest
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCalls  []call
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "runtime",
+			args:       []string{"root", "sub"},
+			wantCalls:  []call{{Err: errCustomTest.Error(), Status: cli.ExitFailure}},
+			wantErrbuf: "error: " + errCustomTest.Error() + " (run 'root sub -h' for usage)\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "exit_error",
+			args:       []string{"root", "sub", "x"},
+			wantCalls:  []call{{Err: errCustomTest.Error(), Status: cli.ExitUnavailable}},
+			wantErrbuf: "error: " + errCustomTest.Error() + " (run 'root sub -h' for usage)\n",
+			wantStatus: cli.ExitUnavailable,
+		},
+		{
+			name:       "usage",
+			args:       []string{"root", "sub", "-unknown"},
+			wantCalls:  []call{{Err: "flag provided but not defined: -unknown", Status: cli.ExitUsage}},
+			wantErrbuf: "error: flag provided but not defined: -unknown (run 'root sub -h' for usage)\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				errbuf bytes.Buffer
+				calls  []call
+			)
+			e := &cli.Env[any]{Args: tt.args, Err: &errbuf}
+			if got := cmdFactory(&calls).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantCalls, calls); diff != "" {
+				t.Errorf("ErrorHandler calls mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_before(t *testing.T) {
+	type p struct {
+		Greeting string
+		Name     string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		var defaultName string
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Before: func(e *cli.Env[*p]) error {
+				if len(e.Args) > 1 && e.Args[1] == "fail" {
+					return errCustomTest
+				}
+				if len(e.Args) > 1 && e.Args[1] == "hi" {
+					e.Args[1] = "hello"
+				}
+				defaultName = "world"
+				return nil
+			},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name: "hello",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.StringVar(&p.Name, "name", defaultName, "")
+					},
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+						e.Printf("hello, %s\n", e.Params.Name)
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "alias",
+			args:       []string{"root", "hi"},
+			wantOutbuf: "hello, world\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "flag",
+			args:       []string{"root", "hello", "-name=gopher"},
+			wantOutbuf: "hello, gopher\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "error",
+			args:       []string{"root", "fail"},
+			wantErrbuf: "root usage\n" + errCustomTest.Error() + "\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Out: &outbuf, Err: &errbuf, Params: &p{}}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_versionFlag(t *testing.T) {
+	type p struct {
+		Version string
+	}
+
+	cmdFactory := func(ownFlag bool) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:      "root",
+			Version:   "v1.2.3",
+			VarPrefix: "APP_",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				if ownFlag {
+					fs.StringVar(&p.Version, "version", "", "")
+				}
+			},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				e.Printf("action %q\n", e.Params.Version)
+				return cli.ExitSuccess
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		ownFlag    bool
+		wantOutbuf string
+	}{
+		{
+			name:       "flag",
+			args:       []string{"root", "-version"},
+			wantOutbuf: "root v1.2.3\n",
+		},
+		{
+			name:       "double_dash",
+			args:       []string{"root", "--version"},
+			wantOutbuf: "root v1.2.3\n",
+		},
+		{
+			name:       "not_bound_to_var",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_VERSION": "true"},
+			wantOutbuf: "action \"\"\n",
+		},
+		{
+			name:       "own_flag",
+			args:       []string{"root", "-version=x"},
+			ownFlag:    true,
+			wantOutbuf: "action \"x\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Vars: tt.vars, Out: &outbuf, Params: &p{}}
+			if want, got := cli.ExitSuccess, cmdFactory(tt.ownFlag).Execute(t.Context(), e); want != got {
+				t.Errorf("cmd.Execute() = %d, want %d", got, want)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnv_CommandPath(t *testing.T) {
+	var (
+		gotPath []string
+		gotCmd  *cli.Command[any]
+	)
+	leaf := &cli.Command[any]{
+		Name: "sub",
+		Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+			gotPath, gotCmd = e.CommandPath(), e.Command()
+			return cli.ExitSuccess
+		},
+	}
+	cmd := &cli.Command[any]{
+		Name:        "root",
+		Subcommands: []*cli.Command[any]{{Name: "mid", Subcommands: []*cli.Command[any]{leaf}}},
+	}
+
+	e := &cli.Env[any]{Args: []string{"root", "mid", "sub"}}
+	if got := e.Command(); got != nil {
+		t.Errorf("env.Command() before Execute = %v, want nil", got)
+	}
+	if got := cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+		t.Fatalf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+	}
+	if diff := cmp.Diff([]string{"root", "mid", "sub"}, gotPath); diff != "" {
+		t.Errorf("env.CommandPath() mismatch (-want +got):\n%s", diff)
+	}
+	if gotCmd != leaf {
+		t.Errorf("env.Command() = %p, want %p", gotCmd, leaf)
+	}
+}
+
+func TestEnv_Source(t *testing.T) {
+	type p struct {
+		Env   string
+		Token string
+		Port  int
+		Debug bool
+		Local string
+	}
+
+	type source struct {
+		Source cli.Source
+		OK     bool
+	}
+
+	var got map[string]source
+	cmd := &cli.Command[*p]{
+		Name: "root",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.StringVar(&p.Env, "env", "", "")
+			fs.StringVar(&p.Local, "local", "", "")
+		},
+		Persistent: []string{"env"},
+		Subcommands: []*cli.Command[*p]{
+			{
+				Name: "serve",
+				Flags: func(fs *flag.FlagSet, p *p) {
+					fs.StringVar(&p.Token, "token", "", "")
+					fs.IntVar(&p.Port, "port", 0, "")
+					fs.BoolVar(&p.Debug, "debug", false, "")
+				},
+				Vars: map[string]string{"token": "TOKEN"},
+				Config: func(e *cli.Env[*p]) (map[string]string, error) {
+					return map[string]string{"port": "8080"}, nil
+				},
+				Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+					got = make(map[string]source)
+					for _, name := range []string{"env", "token", "port", "debug", "local", "unknown"} {
+						s, ok := e.Source(name)
+						got[name] = source{s, ok}
+					}
+					return cli.ExitSuccess
+				},
+			},
+		},
+	}
+
+	cmd.Execute(t.Context(), &cli.Env[*p]{
+		Args:   []string{"root", "serve", "-env=dev"},
+		Vars:   map[string]string{"TOKEN": "x"},
+		Params: &p{},
+	})
+
+	want := map[string]source{
+		"env":     {cli.SourceFlag, true},
+		"token":   {cli.SourceVar, true},
+		"port":    {cli.SourceConfig, true},
+		"debug":   {cli.SourceDefault, true},
+		"local":   {cli.SourceDefault, false},
+		"unknown": {cli.SourceDefault, false},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("e.Source() mismatch (-want +got):\n%s", diff)
+	}
+	if want, got := "config", cli.SourceConfig.String(); want != got {
+		t.Errorf("SourceConfig.String() = %q, want %q", got, want)
+	}
+}
+
+func TestCommand_Execute_param(t *testing.T) {
+	type call struct {
+		Cmd      string
+		Resource string
+		Name     string
+		Args     []string
+	}
+
+	cmdFactory := func(calls *[]call) *cli.Command[any] {
+		action := func(cmd string) cli.ActionFunc[any] {
+			return func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				*calls = append(*calls, call{
+					Cmd:      cmd,
+					Resource: e.PathValue("resource"),
+					Name:     e.PathValue("name"),
+					Args:     e.Args,
+				})
+				return cli.ExitSuccess
+			}
+		}
+		return &cli.Command[any]{
+			Name: "root",
+			Subcommands: []*cli.Command[any]{
+				{
+					Name: "get",
+					Subcommands: []*cli.Command[any]{
+						{
+							Name:   "all",
+							Action: action("all"),
+						},
+						{
+							Name:   cli.Param("resource"),
+							Action: action("resource"),
+							Subcommands: []*cli.Command[any]{
+								{
+									Name:   cli.Param("name"),
+									Action: action("name"),
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want []call
+	}{
+		{
+			name: "literal_first",
+			args: []string{"root", "get", "all"},
+			want: []call{{Cmd: "all", Args: []string{}}},
+		},
+		{
+			name: "param",
+			args: []string{"root", "get", "pods"},
+			want: []call{{Cmd: "resource", Resource: "pods", Args: []string{}}},
+		},
+		{
+			name: "nested_params",
+			args: []string{"root", "get", "pods", "web-1", "extra"},
+			want: []call{{Cmd: "name", Resource: "pods", Name: "web-1", Args: []string{"extra"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []call
+			cmdFactory(&calls).Execute(t.Context(), &cli.Env[any]{Args: tt.args})
+			if diff := cmp.Diff(tt.want, calls); diff != "" {
+				t.Errorf("action calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_defer(t *testing.T) {
+	type call struct {
+		Cmd    string
+		Status cli.ExitStatus
+	}
+
+	cmdFactory := func(calls *[]call) *cli.Command[any] {
+		deferFunc := func(name string) cli.ExitFunc[any] {
+			return func(e *cli.Env[any], status cli.ExitStatus) {
+				*calls = append(*calls, call{Cmd: name, Status: status})
+			}
+		}
+		return &cli.Command[any]{
+			Name:      "root",
+			Defer:     deferFunc("root"),
+			NoRecover: true,
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:  "sub",
+					Defer: deferFunc("sub"),
+					After: func(e *cli.Env[any]) error {
+						if len(e.Args) > 0 && e.Args[0] == "invalid" {
+							return errCustomTest
+						}
+						return nil
+					},
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+						switch {
+						case len(e.Args) > 0 && e.Args[0] == "panic":
+							panic("boom")
+						case len(e.Args) > 0 && e.Args[0] == "fail":
+							return cli.ExitFailure
+						}
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		want      []call
+		wantPanic bool
+	}{
+		{
+			name: "success",
+			args: []string{"root", "sub"},
+			want: []call{{"sub", cli.ExitSuccess}, {"root", cli.ExitSuccess}},
+		},
+		{
+			name: "failure",
+			args: []string{"root", "sub", "fail"},
+			want: []call{{"sub", cli.ExitFailure}, {"root", cli.ExitFailure}},
+		},
+		{
+			name: "sub_hook_error",
+			args: []string{"root", "sub", "invalid"},
+			want: []call{{"root", cli.ExitUsage}},
+		},
+		{
+			name:      "panic",
+			args:      []string{"root", "sub", "panic"},
+			want:      []call{{"sub", cli.ExitFailure}, {"root", cli.ExitFailure}},
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []call
+			func() {
+				defer func() {
+					if r := recover(); (r != nil) != tt.wantPanic {
+						t.Errorf("cmd.Execute() panic=%v, want panic: %t", r, tt.wantPanic)
+					}
+				}()
+				cmdFactory(&calls).Execute(t.Context(), &cli.Env[any]{Args: tt.args})
+			}()
+
+			if diff := cmp.Diff(tt.want, calls); diff != "" {
+				t.Errorf("Defer calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_persistentHooks(t *testing.T) {
+	cmdFactory := func(calls *[]string) *cli.Command[any] {
+		hooks := func(name string) (cli.ActionErrFunc[any], cli.ExitFunc[any]) {
+			before := func(ctx context.Context, e *cli.Env[any]) error {
+				*calls = append(*calls, name+" before")
+				if len(e.Args) > 0 && e.Args[0] == "fail_"+name {
+					return errCustomTest
+				}
+				return nil
+			}
+			after := func(e *cli.Env[any], status cli.ExitStatus) {
+				*calls = append(*calls, fmt.Sprintf("%s after %d", name, status))
+			}
+			return before, after
+		}
+		rootBefore, rootAfter := hooks("root")
+		subBefore, subAfter := hooks("sub")
+		return &cli.Command[any]{
+			Name:             "root",
+			PersistentBefore: rootBefore,
+			PersistentAfter:  rootAfter,
+			Defer: func(e *cli.Env[any], status cli.ExitStatus) {
+				*calls = append(*calls, fmt.Sprintf("root defer %d", status))
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:             "sub",
+					PersistentBefore: subBefore,
+					PersistentAfter:  subAfter,
+					Flags: func(fs *flag.FlagSet, _ any) {
+						fs.Bool("v", false, "")
+					},
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+						*calls = append(*calls, "action")
+						switch {
+						case len(e.Args) > 0 && e.Args[0] == "panic":
+							panic("boom")
+						case len(e.Args) > 0 && e.Args[0] == "fail":
+							return cli.ExitFailure
+						}
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       []string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "success",
+			args:       []string{"root", "sub"},
+			want:       []string{"root before", "sub before", "action", "sub after 0", "root after 0", "root defer 0"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "action_failure",
+			args:       []string{"root", "sub", "fail"},
+			want:       []string{"root before", "sub before", "action", "sub after 1", "root after 1", "root defer 1"},
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "before_error",
+			args:       []string{"root", "sub", "fail_sub"},
+			want:       []string{"root before", "sub before", "root after 1", "root defer 1"},
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "panic",
+			args:       []string{"root", "sub", "panic"},
+			want:       []string{"root before", "sub before", "action", "sub after 1", "root after 1", "root defer 1"},
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "parse_error",
+			args:       []string{"root", "sub", "-x"},
+			want:       []string{"root defer 2"},
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			e := &cli.Env[any]{Args: tt.args, Err: io.Discard}
+			if got := cmdFactory(&calls).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.want, calls); diff != "" {
+				t.Errorf("hook calls mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_reuse(t *testing.T) {
+	type p struct {
+		Name string
+	}
+
+	cmd := &cli.Command[*p]{
+		Name:      "root",
+		VarPrefix: "APP_",
+		Subcommands: []*cli.Command[*p]{
+			{
+				Name: "hello",
+				Flags: func(fs *flag.FlagSet, p *p) {
+					fs.StringVar(&p.Name, "name", "world", "")
+				},
+				Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+					e.Printf("hello, %s\n", e.Params.Name)
+					return cli.ExitSuccess
+				},
+			},
+			cli.VersionCommand[*p](cli.VersionInfo{Version: "v1.0.0"}),
+		},
+	}
+
+	run := func(args []string, vars map[string]string) string {
+		var outbuf bytes.Buffer
+		cmd.Execute(t.Context(), &cli.Env[*p]{Args: args, Vars: vars, Out: &outbuf, Params: &p{}})
+		return outbuf.String()
+	}
+
+	if want, got := "hello, world\n", run([]string{"root", "hello"}, nil); want != got {
+		t.Errorf("first execution output = %q, want %q", got, want)
+	}
+	if want, got := "hello, gopher\n", run([]string{"root", "hello", "-name=gopher"}, nil); want != got {
+		t.Errorf("second execution output = %q, want %q", got, want)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			name := fmt.Sprint("gopher", i)
+			if want, got := "hello, "+name+"\n", run([]string{"root", "hello"}, map[string]string{"APP_NAME": name}); want != got {
+				t.Errorf("concurrent execution output = %q, want %q", got, want)
+			}
+			if want, got := "v1.0.0\n", run([]string{"root", "version", "-output=short"}, nil); want != got {
+				t.Errorf("concurrent version output = %q, want %q", got, want)
+			}
+		})
+	}
+	wg.Wait()
+}
+
 func ExampleCommand() {
 	type p struct {
 		env     string
@@ -0,0 +1,169 @@
+/*
+Package clitest provides utilities for testing tinycli commands.
+
+[RunPTY] executes a [cli.Command] with its input and output streams connected
+to a pseudo-terminal, so that terminal-sensitive behavior is tested the way
+users experience it. Interactions are scripted with [Expect] and [Send] steps:
+
+	res, err := clitest.RunPTY(ctx, cmd, env,
+		clitest.Expect("name? "),
+		clitest.Send("gopher\r"),
+	)
+
+Pseudo-terminals are currently supported on Linux. On other platforms, RunPTY
+and [OpenPTY] return an error wrapping [errors.ErrUnsupported].
+*/
+package clitest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+// A PTY is a pseudo-terminal pair.
+type PTY struct {
+	Control *os.File // controlling side, driven by tests
+	TTY     *os.File // terminal side, used as command streams
+}
+
+// OpenPTY opens a new pseudo-terminal pair.
+func OpenPTY() (*PTY, error) {
+	return openPTY()
+}
+
+// Close closes both sides of the pseudo-terminal.
+func (p *PTY) Close() error {
+	err := p.TTY.Close()
+	if cerr := p.Control.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// A Step is a scripted interaction with a command run by [RunPTY].
+type Step struct {
+	expect string
+	send   string
+}
+
+// Expect returns a Step that waits until the command has written s to the
+// terminal since the previous Expect step matched.
+func Expect(s string) Step {
+	return Step{expect: s}
+}
+
+// Send returns a Step that types keys into the terminal. Use "\r" to press
+// enter.
+func Send(keys string) Step {
+	return Step{send: keys}
+}
+
+// A Result is the outcome of a command run by [RunPTY].
+type Result struct {
+	Output string         // terminal output, including echoed input
+	Status cli.ExitStatus // status returned by Execute
+}
+
+// RunPTY executes cmd with the In, Out, and Err streams of env connected to a
+// pseudo-terminal, performing the script steps in order while the command
+// runs.
+//
+// The terminal uses its default line discipline: input is echoed and output
+// newlines are translated to "\r\n". RunPTY returns an error if a step cannot
+// be completed before ctx is done.
+func RunPTY[P any](ctx context.Context, cmd *cli.Command[P], env *cli.Env[P], script ...Step) (*Result, error) {
+	pty, err := OpenPTY()
+	if err != nil {
+		return nil, err
+	}
+	defer pty.Close()
+
+	env.In, env.Out, env.Err = pty.TTY, pty.TTY, pty.TTY
+
+	out := &transcript{changed: make(chan struct{}, 1)}
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		io.Copy(out, pty.Control) // returns once the terminal side is closed
+	}()
+
+	statusc := make(chan cli.ExitStatus, 1)
+	go func() {
+		statusc <- cmd.Execute(ctx, env)
+	}()
+
+	for _, step := range script {
+		if step.expect != "" {
+			if err := out.wait(ctx, step.expect); err != nil {
+				return &Result{Output: out.String()}, err
+			}
+		}
+		if step.send != "" {
+			if _, err := pty.Control.WriteString(step.send); err != nil {
+				return &Result{Output: out.String()}, fmt.Errorf("clitest: sending %q: %w", step.send, err)
+			}
+		}
+	}
+
+	var status cli.ExitStatus
+	select {
+	case status = <-statusc:
+	case <-ctx.Done():
+		return &Result{Output: out.String()}, fmt.Errorf("clitest: waiting for command: %w", ctx.Err())
+	}
+
+	pty.TTY.Close()
+	<-readDone
+	return &Result{Output: out.String(), Status: status}, nil
+}
+
+type transcript struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	off     int // offset past the last expected match
+	changed chan struct{}
+}
+
+func (t *transcript) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	n, err := t.buf.Write(p)
+	t.mu.Unlock()
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+	return n, err
+}
+
+func (t *transcript) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.String()
+}
+
+func (t *transcript) wait(ctx context.Context, s string) error {
+	for {
+		t.mu.Lock()
+		i := strings.Index(t.buf.String()[t.off:], s)
+		if i >= 0 {
+			t.off += i + len(s)
+		}
+		t.mu.Unlock()
+		if i >= 0 {
+			return nil
+		}
+
+		select {
+		case <-t.changed:
+		case <-ctx.Done():
+			return fmt.Errorf("clitest: waiting for %q: %w", s, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package clitest_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	cli "github.com/jonathonwebb/tinycli"
+	"github.com/jonathonwebb/tinycli/clitest"
+)
+
+func TestRunPTY(t *testing.T) {
+	cmd := &cli.Command[any]{
+		Name: "greet",
+		Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+			e.Printf("name? ")
+			name, err := bufio.NewReader(e.In).ReadString('\n')
+			if err != nil {
+				return cli.ExitFailure
+			}
+			e.Printf("hello, %s\n", strings.TrimSpace(name))
+			return cli.ExitSuccess
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := clitest.RunPTY(ctx, cmd, &cli.Env[any]{Args: []string{"greet"}},
+		clitest.Expect("name? "),
+		clitest.Send("gopher\r"),
+		clitest.Expect("hello, gopher"),
+	)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("RunPTY() error: %v", err)
+	}
+
+	if want, got := cli.ExitSuccess, res.Status; want != got {
+		t.Errorf("RunPTY().Status=%v, want %v", got, want)
+	}
+	if want, got := "name? gopher\r\nhello, gopher\r\n", res.Output; want != got {
+		t.Errorf("RunPTY().Output=%q, want %q", got, want)
+	}
+}
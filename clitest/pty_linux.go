@@ -0,0 +1,52 @@
+package clitest
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func openPTY() (*PTY, error) {
+	control, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("clitest: %w", err)
+	}
+
+	var unlock int32
+	if err := ioctl(control, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		control.Close()
+		return nil, fmt.Errorf("clitest: unlocking pty: %w", err)
+	}
+
+	var n uint32
+	if err := ioctl(control, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		control.Close()
+		return nil, fmt.Errorf("clitest: getting pty number: %w", err)
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		control.Close()
+		return nil, fmt.Errorf("clitest: %w", err)
+	}
+
+	return &PTY{Control: control, TTY: tty}, nil
+}
+
+func ioctl(f *os.File, req, arg uintptr) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg)
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package clitest
+
+import (
+	"errors"
+	"fmt"
+)
+
+func openPTY() (*PTY, error) {
+	return nil, fmt.Errorf("clitest: pseudo-terminals: %w", errors.ErrUnsupported)
+}
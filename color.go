@@ -0,0 +1,107 @@
+package tinycli
+
+import (
+	"fmt"
+	"io"
+)
+
+// A ColorMode controls whether the Env color helpers emit ANSI color codes.
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // color terminals, unless disabled by env vars
+	ColorAlways                  // always emit color codes
+	ColorNever                   // never emit color codes
+)
+
+func (m ColorMode) String() string {
+	switch m {
+	case ColorAuto:
+		return "auto"
+	case ColorAlways:
+		return "always"
+	case ColorNever:
+		return "never"
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(m))
+}
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// Successf formats and writes a success message to the Env standard output
+// stream, in green when color is enabled for it. It writes nothing when the
+// -quiet flag defined by [StandardFlags] is set.
+func (e Env[P]) Successf(format string, args ...any) (int, error) {
+	if e.StandardOptions().Quiet {
+		return 0, nil
+	}
+	return e.colorf(e.Out, ansiGreen, format, args...)
+}
+
+// Infof formats and writes an informational message to the Env standard output
+// stream, in cyan when color is enabled for it. It writes nothing when the
+// -quiet flag defined by [StandardFlags] is set.
+func (e Env[P]) Infof(format string, args ...any) (int, error) {
+	if e.StandardOptions().Quiet {
+		return 0, nil
+	}
+	return e.colorf(e.Out, ansiCyan, format, args...)
+}
+
+// Warnf formats and writes a warning message to the Env error output stream,
+// in yellow when color is enabled for it.
+func (e Env[P]) Warnf(format string, args ...any) (int, error) {
+	return e.colorf(e.Err, ansiYellow, format, args...)
+}
+
+// colorf formats and writes a message to w, wrapped in the given color code
+// when color is enabled for w. A trailing newline is written after the reset
+// code, so that the color does not bleed into following lines.
+func (e Env[P]) colorf(w io.Writer, color, format string, args ...any) (int, error) {
+	if w == nil {
+		return 0, nil
+	}
+	msg := fmt.Sprintf(format, args...)
+	if !e.UseColor(w) || msg == "" {
+		return io.WriteString(w, msg)
+	}
+	var nl string
+	if msg[len(msg)-1] == '\n' {
+		msg, nl = msg[:len(msg)-1], "\n"
+	}
+	return io.WriteString(w, color+msg+ansiReset+nl)
+}
+
+// UseColor reports whether ANSI color codes should be written to stream.
+//
+// With ColorAuto, color is disabled by the -no-color flag defined by
+// [StandardFlags], a non-empty $NO_COLOR, or $CLICOLOR=0, forced by a
+// non-empty $CLICOLOR_FORCE other than "0", and otherwise enabled when stream
+// is a terminal other than TERM=dumb.
+func (e Env[P]) UseColor(stream any) bool {
+	switch e.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if e.StandardOptions().NoColor {
+		return false
+	}
+	if v, _ := e.getVar("NO_COLOR"); v != "" {
+		return false
+	}
+	if v, _ := e.getVar("CLICOLOR_FORCE"); v != "" && v != "0" {
+		return true
+	}
+	if v, _ := e.getVar("CLICOLOR"); v == "0" {
+		return false
+	}
+	term, _ := e.getVar("TERM")
+	return e.IsTerminal(stream) && term != "dumb"
+}
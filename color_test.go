@@ -0,0 +1,57 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_colorHelpers(t *testing.T) {
+	var outbuf, errbuf bytes.Buffer
+	e := cli.Env[any]{Out: &outbuf, Err: &errbuf, Color: cli.ColorAlways}
+
+	e.Successf("deployed %s\n", "web")
+	e.Infof("%d replicas", 3)
+	e.Warnf("disk %d%% full\n", 90)
+
+	if diff := cmp.Diff("\x1b[32mdeployed web\x1b[0m\n\x1b[36m3 replicas\x1b[0m", outbuf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff("\x1b[33mdisk 90% full\x1b[0m\n", errbuf.String()); diff != "" {
+		t.Errorf("error output mismatch (-want +got):\n%s", diff)
+	}
+
+	outbuf.Reset()
+	e.Color = cli.ColorNever
+	e.Successf("deployed %s\n", "web")
+	if diff := cmp.Diff("deployed web\n", outbuf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEnv_UseColor(t *testing.T) {
+	tests := []struct {
+		name string
+		mode cli.ColorMode
+		vars map[string]string
+		want bool
+	}{
+		{name: "auto_not_terminal", want: false},
+		{name: "auto_force", vars: map[string]string{"CLICOLOR_FORCE": "1"}, want: true},
+		{name: "auto_force_zero", vars: map[string]string{"CLICOLOR_FORCE": "0"}, want: false},
+		{name: "auto_no_color", vars: map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, want: false},
+		{name: "always", mode: cli.ColorAlways, vars: map[string]string{"NO_COLOR": "1"}, want: true},
+		{name: "never", mode: cli.ColorNever, vars: map[string]string{"CLICOLOR_FORCE": "1"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := cli.Env[any]{Out: &bytes.Buffer{}, Vars: tt.vars, Color: tt.mode}
+			if got := e.UseColor(e.Out); got != tt.want {
+				t.Errorf("env.UseColor()=%t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package tinycli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Default settings for a [CompletionCache].
+const (
+	DefaultCompletionTTL     = 30 * time.Second
+	DefaultCompletionTimeout = 2 * time.Second
+)
+
+// A CompletionCache configures the caching of dynamic completion results by
+// [CacheCompletions].
+type CompletionCache struct {
+	TTL     time.Duration // how long results are reused; zero means DefaultCompletionTTL
+	Timeout time.Duration // bound on each call; zero means DefaultCompletionTimeout
+	Dir     string        // cache directory; empty means a directory under os.UserCacheDir
+}
+
+type cachedCompletion struct {
+	Expires time.Time `json:"expires"`
+	Values  []string  `json:"values"`
+}
+
+// CacheCompletions wraps a [CompleteFunc] that may be slow, such as one that
+// calls a remote API, so that tab completion stays responsive.
+//
+// Results are stored in the cache directory, keyed by the command path,
+// including the values matched by parameter subcommands, the positional args
+// before the partially typed word, and the word itself, and reused until the
+// cache TTL has passed. Each call to fn is bounded by the cache timeout; if it
+// fails or times out, stale cached results are returned when available.
+//
+// When cache.Dir is empty, results are stored under [os.UserCacheDir] in a
+// directory named after the root command, which respects XDG_CACHE_HOME.
+func CacheCompletions[P any](fn CompleteFunc[P], cache CompletionCache) CompleteFunc[P] {
+	if cache.TTL == 0 {
+		cache.TTL = DefaultCompletionTTL
+	}
+	if cache.Timeout == 0 {
+		cache.Timeout = DefaultCompletionTimeout
+	}
+
+	return func(ctx context.Context, e *Env[P], toComplete string) ([]string, error) {
+		path := cache.path(e.pathTokens(), e.Args, toComplete)
+		cached, hit := readCachedCompletion(path)
+		if hit && time.Now().Before(cached.Expires) {
+			return cached.Values, nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, cache.Timeout)
+		defer cancel()
+		values, err := fn(ctx, e, toComplete)
+		if err != nil {
+			if hit {
+				return cached.Values, nil
+			}
+			return nil, err
+		}
+
+		if path != "" {
+			writeCachedCompletion(path, cachedCompletion{
+				Expires: time.Now().Add(cache.TTL),
+				Values:  values,
+			})
+		}
+		return values, nil
+	}
+}
+
+// path returns the cache file path for a completion, or the empty string if
+// no cache directory is available.
+func (c CompletionCache) path(cmdPath, args []string, toComplete string) string {
+	dir := c.Dir
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil || len(cmdPath) == 0 {
+			return ""
+		}
+		dir = filepath.Join(cacheDir, cmdPath[0], "completions")
+	}
+	key, err := json.Marshal([]any{cmdPath, args, toComplete})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(key)
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func readCachedCompletion(path string) (cachedCompletion, bool) {
+	var cached cachedCompletion
+	if path == "" {
+		return cached, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cached, false
+	}
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return cached, false
+	}
+	return cached, true
+}
+
+// writeCachedCompletion stores a completion result, ignoring errors since the
+// cache is only an optimization.
+func writeCachedCompletion(path string, cached cachedCompletion) {
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".completion-*")
+	if err != nil {
+		return
+	}
+	_, werr := f.Write(b)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+var errCompletionShell = errors.New(`must be one of "bash", "zsh", or "fish"`)
+
+// CompletionCommand returns a "completion" Command that prints a bash, zsh,
+// or fish completion script for the command tree it is added to.
+//
+// The scripts complete subcommand names, flag names, and positional args
+// returned by a Command's Complete hook, by calling the program's hidden
+// "completion __complete" protocol with the words typed so far. Loading a
+// script is shell specific:
+//
+//	source <(foo completion bash)
+//	source <(foo completion zsh)
+//	foo completion fish | source
+func CompletionCommand[P any]() *Command[P] {
+	return &Command[P]{
+		Name:    "completion",
+		Usage:   "usage: completion bash|zsh|fish",
+		rawArgs: true,
+		After: func(e *Env[P]) error {
+			if len(e.Args) == 0 {
+				return errors.New("missing shell")
+			}
+			switch e.Args[0] {
+			case "bash", "zsh", "fish", "__complete":
+				return nil
+			}
+			return fmt.Errorf("invalid shell %q: %w", e.Args[0], errCompletionShell)
+		},
+		Action: func(ctx context.Context, e *Env[P]) ExitStatus {
+			root := e.cmds[0]
+			if e.Args[0] == "__complete" {
+				for _, v := range complete(ctx, e, root, e.Args[1:]) {
+					e.Printf("%s\n", v)
+				}
+				return ExitSuccess
+			}
+			name := root.Name
+			fn := "_" + strings.Map(func(r rune) rune {
+				if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return r
+				}
+				return '_'
+			}, name)
+			e.Printf(completionScripts[e.Args[0]], name, fn)
+			return ExitSuccess
+		},
+	}
+}
+
+// completionScripts are completion script templates, formatted with the
+// program name and a shell function name.
+var completionScripts = map[string]string{
+	"bash": `%[2]s() {
+	local IFS=$'\n'
+	COMPREPLY=($(%[1]s completion __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F %[2]s %[1]s
+`,
+	"zsh": `#compdef %[1]s
+%[2]s() {
+	local -a candidates
+	candidates=(${(f)"$(%[1]s completion __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	compadd -a candidates
+}
+compdef %[2]s %[1]s
+`,
+	"fish": `complete -c %[1]s -f -a '(%[1]s completion __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+// complete returns the completion candidates for the last of words, the
+// arguments typed after the name of the executed root.
+func complete[P any](ctx context.Context, e *Env[P], root *invocation[P], words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	toComplete := words[len(words)-1]
+
+	path := []*invocation[P]{root}
+	cur := root
+	var (
+		args       []string
+		terminated bool
+		pending    *flag.Flag // flag awaiting a value
+	)
+	for _, w := range words[:len(words)-1] {
+		switch {
+		case pending != nil:
+			pending = nil
+		case terminated:
+			args = append(args, w)
+		case w == "--":
+			terminated = true
+		case len(w) > 1 && w[0] == '-':
+			name, _, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+			if f := lookupCompletionFlag(path, name); f != nil && !hasValue && !isBoolFlag(f.Value) {
+				pending = f
+			}
+		default:
+			if sub := cur.lookupSubcommand(w, foldCase(path)); sub != nil && len(args) == 0 {
+				if name, ok := paramName(sub.Name); ok {
+					if e.pathValues == nil {
+						e.pathValues = make(map[string]string)
+					}
+					e.pathValues[name] = w
+				}
+				cur = completionInvocation(e, sub)
+				path = append(path, cur)
+				continue
+			}
+			args = append(args, w)
+		}
+	}
+	if pending != nil {
+		return completeFlagValue(pending, "", toComplete)
+	}
+
+	var candidates []string
+	if !terminated && strings.HasPrefix(toComplete, "-") {
+		prefix := strings.TrimLeft(toComplete, "-")
+		if name, value, ok := strings.Cut(prefix, "="); ok {
+			f := lookupCompletionFlag(path, name)
+			if f == nil {
+				return nil
+			}
+			return completeFlagValue(f, strings.TrimSuffix(toComplete, value), value)
+		}
+		add := func(name string) {
+			if strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, "-"+name)
+			}
+		}
+		cur.flagSet().VisitAll(func(f *flag.Flag) {
+			if !cur.isHidden(f.Name) {
+				add(f.Name)
+			}
+		})
+		for _, a := range path[:len(path)-1] {
+			for _, name := range a.Persistent {
+				if !a.isHidden(name) {
+					add(name)
+				}
+			}
+		}
+		slices.Sort(candidates)
+		return candidates
+	}
+
+	if len(args) == 0 {
+		for _, sub := range cur.Subcommands {
+			if _, ok := paramName(sub.Name); !ok && !sub.Hidden && strings.HasPrefix(sub.Name, toComplete) {
+				candidates = append(candidates, sub.Name)
+			}
+		}
+	}
+	if cur.Complete != nil {
+		e.cmds = path
+		e.Args = args
+		values, err := cur.Complete(ctx, e, toComplete)
+		if err == nil {
+			candidates = append(candidates, values...)
+		}
+	}
+	return candidates
+}
+
+// completeFlagValue returns the choices of an enum flag starting with
+// toComplete, each preceded by prefix.
+func completeFlagValue(f *flag.Flag, prefix, toComplete string) []string {
+	cv, ok := unwrapValue(f.Value).(choicesValue)
+	if !ok {
+		return nil
+	}
+	var candidates []string
+	for _, choice := range cv.choices() {
+		if strings.HasPrefix(choice, toComplete) {
+			candidates = append(candidates, prefix+choice)
+		}
+	}
+	return candidates
+}
+
+// completionInvocation returns an invocation of c with its flags defined, but
+// not parsed. Flags are left undefined when the Flags hook panics.
+func completionInvocation[P any](e *Env[P], c *Command[P]) *invocation[P] {
+	inv := &invocation[P]{Command: c}
+	if !defineFlags(c, inv.flagSet(), e.Params) {
+		inv.fs = nil
+	}
+	return inv
+}
+
+// lookupCompletionFlag looks up a flag of the last command in path, or a
+// Persistent flag of one of its ancestors.
+func lookupCompletionFlag[P any](path []*invocation[P], name string) *flag.Flag {
+	last := len(path) - 1
+	if f := path[last].flagSet().Lookup(name); f != nil {
+		return f
+	}
+	for _, a := range path[:last] {
+		if slices.Contains(a.Persistent, name) {
+			return a.flagSet().Lookup(name)
+		}
+	}
+	return nil
+}
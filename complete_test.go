@@ -0,0 +1,318 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCacheCompletions(t *testing.T) {
+	var calls int
+	var fail bool
+	fn := func(ctx context.Context, e *cli.Env[any], toComplete string) ([]string, error) {
+		calls++
+		if fail {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []string{toComplete + "1", toComplete + "2"}, nil
+	}
+
+	// complete runs a cached completion from within a command's action, the
+	// way the completion protocol invokes it
+	complete := func(t *testing.T, cached cli.CompleteFunc[any], toComplete string) ([]string, error) {
+		t.Helper()
+		var values []string
+		var err error
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				values, err = cached(ctx, e, toComplete)
+				return cli.ExitSuccess
+			},
+		}
+		cmd.Execute(t.Context(), &cli.Env[any]{Args: []string{"root"}})
+		return values, err
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		calls, fail = 0, false
+		cached := cli.CacheCompletions(fn, cli.CompletionCache{Dir: t.TempDir()})
+
+		for range 3 {
+			got, err := complete(t, cached, "fo")
+			if err != nil {
+				t.Fatalf("cached completion error: %v", err)
+			}
+			if diff := cmp.Diff([]string{"fo1", "fo2"}, got); diff != "" {
+				t.Errorf("cached completion mismatch (-want +got):\n%s", diff)
+			}
+		}
+		complete(t, cached, "ba")
+
+		if want, got := 2, calls; want != got {
+			t.Errorf("completion called %d times, want %d", got, want)
+		}
+	})
+
+	t.Run("expired_stale_on_timeout", func(t *testing.T) {
+		calls, fail = 0, false
+		cached := cli.CacheCompletions(fn, cli.CompletionCache{
+			Dir:     t.TempDir(),
+			TTL:     time.Nanosecond,
+			Timeout: 10 * time.Millisecond,
+		})
+
+		complete(t, cached, "fo")
+		fail = true
+		got, err := complete(t, cached, "fo")
+		if err != nil {
+			t.Fatalf("stale completion error: %v", err)
+		}
+		if diff := cmp.Diff([]string{"fo1", "fo2"}, got); diff != "" {
+			t.Errorf("stale completion mismatch (-want +got):\n%s", diff)
+		}
+		if want, got := 2, calls; want != got {
+			t.Errorf("completion called %d times, want %d", got, want)
+		}
+
+		if _, err := complete(t, cached, "new"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("uncached completion error=%v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("keyed_by_path_values_and_args", func(t *testing.T) {
+		dir := t.TempDir()
+		var calls int
+		cmd := &cli.Command[any]{
+			Name: "foo",
+			Subcommands: []*cli.Command[any]{
+				{
+					Name: "get",
+					Subcommands: []*cli.Command[any]{
+						{
+							Name: cli.Param("resource"),
+							Complete: cli.CacheCompletions(func(ctx context.Context, e *cli.Env[any], toComplete string) ([]string, error) {
+								calls++
+								return []string{e.PathValue("resource") + "-" + strings.Join(e.Args, "-") + "1"}, nil
+							}, cli.CompletionCache{Dir: dir}),
+						},
+					},
+				},
+				cli.CompletionCommand[any](),
+			},
+		}
+		for _, tt := range []struct {
+			words []string
+			want  string
+		}{
+			{[]string{"get", "pods", ""}, "pods-1\n"},
+			{[]string{"get", "svc", ""}, "svc-1\n"},
+			{[]string{"get", "svc", "a", ""}, "svc-a1\n"},
+			{[]string{"get", "pods", ""}, "pods-1\n"},
+		} {
+			var outbuf bytes.Buffer
+			args := append([]string{"foo", "completion", "__complete"}, tt.words...)
+			cmd.Execute(t.Context(), &cli.Env[any]{Args: args, Out: &outbuf})
+			if got := outbuf.String(); got != tt.want {
+				t.Errorf("completion of %q = %q, want %q", tt.words, got, tt.want)
+			}
+		}
+		if want := 3; calls != want {
+			t.Errorf("completion called %d times, want %d", calls, want)
+		}
+	})
+
+	t.Run("default_dir", func(t *testing.T) {
+		calls, fail = 0, false
+		cacheHome := t.TempDir()
+		t.Setenv("XDG_CACHE_HOME", cacheHome)
+		t.Setenv("HOME", cacheHome)
+		t.Setenv("LocalAppData", cacheHome)
+
+		complete(t, cli.CacheCompletions(fn, cli.CompletionCache{}), "fo")
+
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			t.Skip(err)
+		}
+		entries, err := os.ReadDir(filepath.Join(cacheDir, "root", "completions"))
+		if err != nil {
+			t.Fatalf("reading cache dir: %v", err)
+		}
+		if want, got := 1, len(entries); want != got {
+			t.Errorf("cache dir has %d entries, want %d", got, want)
+		}
+	})
+}
+
+func TestCompletionCommand(t *testing.T) {
+	type p struct {
+		Env     string
+		Verbose bool
+		Port    int
+		Format  string
+		Log     string
+		Retries int
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.Env, "env", "", "")
+				fs.BoolVar(&p.Verbose, "v", false, "")
+				cli.EnumVar(fs, &p.Log, "log", "text", "", []string{"text", "json"})
+				fs.IntVar(&p.Retries, "retries", 0, "")
+			},
+			Persistent:  []string{"env", "log", "retries"},
+			HiddenFlags: []string{"v"},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name: "serve",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.IntVar(&p.Port, "port", 0, "")
+						cli.EnumVar(fs, &p.Format, "format", "table", "", []string{"json", "yaml", "table"})
+					},
+					Complete: func(ctx context.Context, e *cli.Env[*p], toComplete string) ([]string, error) {
+						var values []string
+						for _, v := range []string{"alpha", "beta"} {
+							if strings.HasPrefix(v, toComplete) {
+								values = append(values, v+strings.Repeat("+", len(e.Args)))
+							}
+						}
+						return values, nil
+					},
+				},
+				{
+					Name: "get",
+					Subcommands: []*cli.Command[*p]{
+						{Name: cli.Param("resource")},
+					},
+				},
+				{Name: "__dump-state", Hidden: true},
+				cli.CompletionCommand[*p](),
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "subcommands",
+			args:       []string{"root", "completion", "__complete", ""},
+			wantOutbuf: "serve\nget\ncompletion\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "root_flags",
+			args:       []string{"root", "completion", "__complete", "-"},
+			wantOutbuf: "-env\n-log\n-retries\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "hidden_subcommand_prefix",
+			args:       []string{"root", "completion", "__complete", "__"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "subcommand_prefix",
+			args:       []string{"root", "completion", "__complete", "-env", "dev", "-v", "s"},
+			wantOutbuf: "serve\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "flags",
+			args:       []string{"root", "completion", "__complete", "serve", "-"},
+			wantOutbuf: "-env\n-format\n-log\n-port\n-retries\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "flag_value",
+			args:       []string{"root", "completion", "__complete", "serve", "-port", ""},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "enum_value",
+			args:       []string{"root", "completion", "__complete", "serve", "-format", ""},
+			wantOutbuf: "json\nyaml\ntable\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "persistent_enum_value",
+			args:       []string{"root", "completion", "__complete", "serve", "-log", ""},
+			wantOutbuf: "text\njson\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "persistent_partial_value",
+			args:       []string{"root", "completion", "__complete", "serve", "-retries=8x"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "enum_value_inline",
+			args:       []string{"root", "completion", "__complete", "serve", "--format=y"},
+			wantOutbuf: "--format=yaml\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "complete_hook",
+			args:       []string{"root", "completion", "__complete", "serve", "-env=dev", "x", ""},
+			wantOutbuf: "alpha+\nbeta+\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "param",
+			args:       []string{"root", "completion", "__complete", "get", "pods", ""},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "bash",
+			args:       []string{"root", "completion", "bash"},
+			wantOutbuf: "_root() {\n\tlocal IFS=$'\\n'\n\tCOMPREPLY=($(root completion __complete \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null))\n}\ncomplete -o default -F _root root\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "invalid_shell",
+			args:       []string{"root", "completion", "tcsh"},
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Out: &outbuf, Params: &p{}}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for _, shell := range []string{"zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var outbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: []string{"root", "completion", shell}, Out: &outbuf, Params: &p{}}
+			cmdFactory().Execute(t.Context(), e)
+			if want := "root completion __complete"; !strings.Contains(outbuf.String(), want) {
+				t.Errorf("%s script %q does not contain %q", shell, outbuf.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package tinycli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JSONConfig returns a [ConfigFunc] that reads flag values from the JSON
+// object in the file at path, keyed by flag name:
+//
+//	{"port": 8080, "host": "example.com", "tags": ["a", "b"]}
+//
+// Strings, numbers, and booleans are converted to text as given, arrays of
+// them are joined with commas, and null values are ignored. A file that does
+// not exist provides no values.
+func JSONConfig[P any](path string) ConfigFunc[P] {
+	return func(*Env[P]) (map[string]string, error) {
+		b, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %w", err)
+		}
+		config, err := parseJSONConfig(b)
+		if err != nil {
+			return nil, fmt.Errorf("reading config %s: %w", path, err)
+		}
+		return config, nil
+	}
+}
+
+func parseJSONConfig(b []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]string, len(raw))
+	for key, v := range raw {
+		if v == nil {
+			continue
+		}
+		if values, ok := v.([]any); ok {
+			parts := make([]string, 0, len(values))
+			for _, v := range values {
+				s, err := jsonConfigText(v)
+				if err != nil {
+					return nil, fmt.Errorf("key %s: %w", key, err)
+				}
+				parts = append(parts, s)
+			}
+			config[key] = strings.Join(parts, ",")
+			continue
+		}
+		s, err := jsonConfigText(v)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+		config[key] = s
+	}
+	return config, nil
+}
+
+func jsonConfigText(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// SaveConfig writes the resolved values of r that are not defaults to the
+// JSON file at path, in the format read by [JSONConfig], so that values
+// experimented with on the command line persist. Values of Secrets flags are
+// never written, nor are values from env vars, which belong to the
+// environment rather than the config. Booleans and numbers are written as
+// JSON booleans and numbers, and other values as strings. Keys of an existing
+// file that r does not resolve are kept as they are, and when commands on the
+// path define flags with the same name, the value of the innermost command is
+// written. Missing parent directories are created.
+func SaveConfig[P any](path string, r *Resolution[P]) error {
+	config := make(map[string]json.RawMessage)
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &config); err != nil {
+			return fmt.Errorf("reading config %s: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	for _, f := range r.Flags {
+		if f.Source == SourceDefault || f.Source == SourceVar || f.Secret {
+			continue
+		}
+		v, err := json.Marshal(configValue(f))
+		if err != nil {
+			return fmt.Errorf("saving config: flag -%s: %w", f.Name, err)
+		}
+		config[f.Name] = v
+	}
+
+	b, err = json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+	if err := os.WriteFile(path, append(b, '\n'), 0o600); err != nil {
+		return fmt.Errorf("saving config: %w", err)
+	}
+	return nil
+}
+
+// configValue returns the JSON value saved for f: its typed value when it is a
+// boolean or number that reads back as the same text, and otherwise its text.
+func configValue(f ResolvedFlag) any {
+	switch v := f.Typed.(type) {
+	case bool, int, int64, uint, uint64, float64:
+		if fmt.Sprint(v) == f.Value {
+			return v
+		}
+	}
+	return f.Value
+}
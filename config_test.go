@@ -0,0 +1,177 @@
+package tinycli_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestJSONConfig(t *testing.T) {
+	type p struct {
+		Port    int
+		Host    string
+		Verbose bool
+		Tags    string
+	}
+
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cmdFactory := func(path string) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.IntVar(&p.Port, "port", 5000, "")
+				fs.StringVar(&p.Host, "host", "localhost", "")
+				fs.BoolVar(&p.Verbose, "v", false, "")
+				fs.StringVar(&p.Tags, "tags", "", "")
+			},
+			Vars:   map[string]string{"host": "HOST"},
+			Config: cli.JSONConfig[*p](path),
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				return cli.ExitSuccess
+			},
+		}
+	}
+
+	tests := []struct {
+		tc[*p]
+		path string
+	}{
+		{
+			tc: tc[*p]{
+				name: "values",
+				args: []string{"root"},
+				vars: map[string]string{"HOST": "example.com"},
+
+				wantParams: &p{Port: 8080, Host: "example.com", Verbose: true, Tags: "a,b"},
+				wantStatus: cli.ExitSuccess,
+			},
+			path: write("values.json", `{"port": 8080, "host": "ignored", "v": true, "tags": ["a", "b"], "unset": null}`),
+		},
+		{
+			tc: tc[*p]{
+				name: "missing_file",
+				args: []string{"root"},
+
+				wantParams: &p{Port: 5000, Host: "localhost"},
+				wantStatus: cli.ExitSuccess,
+			},
+			path: filepath.Join(dir, "missing.json"),
+		},
+		{
+			tc: tc[*p]{
+				name: "invalid_value",
+				args: []string{"root"},
+
+				wantErrbuf: "root usage\ninvalid value \"1.5\" for config key port: parse error\n",
+				wantStatus: cli.ExitUsage,
+			},
+			path: write("invalid_value.json", `{"port": 1.5}`),
+		},
+		{
+			tc: tc[*p]{
+				name: "unsupported_value",
+				args: []string{"root"},
+
+				wantErrbuf: "root usage\nreading config " + filepath.Join(dir, "object.json") + ": key port: unsupported value map[]\n",
+				wantStatus: cli.ExitFailure,
+			},
+			path: write("object.json", `{"port": {}}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params p
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(tt.path), &params, tt.tc)
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("cmd.Execute()=%v, want %v", got, want)
+			}
+			if want, got := tt.wantErrbuf, gotErrbuf; want != got {
+				t.Errorf("cmd.Execute() err buffer = %q, want %q", got, want)
+			}
+			if tt.wantParams != nil && *tt.wantParams != *gotParams {
+				t.Errorf("cmd.Execute() params = %+v, want %+v", *gotParams, *tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestSaveConfig(t *testing.T) {
+	type p struct {
+		Port  int
+		Host  string
+		Token string
+		Debug bool
+	}
+	path := filepath.Join(t.TempDir(), "foo", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"ratio": 1.50, "debug": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cli.Command[*p]{
+		Name: "foo",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.IntVar(&p.Port, "port", 5000, "")
+			fs.StringVar(&p.Host, "host", "localhost", "")
+			fs.StringVar(&p.Token, "token", "", "")
+			fs.BoolVar(&p.Debug, "debug", false, "")
+		},
+		Vars:    map[string]string{"host": "HOST"},
+		Secrets: []string{"token"},
+		Config:  cli.JSONConfig[*p](path),
+		Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+			return cli.ExitSuccess
+		},
+	}
+	e := &cli.Env[*p]{
+		Args:   []string{"foo", "-port", "8080", "-token", "s3cret"},
+		Vars:   map[string]string{"HOST": "example.com"},
+		Params: &p{},
+	}
+	r, err := cmd.Resolve(e)
+	if err != nil {
+		t.Fatalf("cmd.Resolve() error = %v", err)
+	}
+	if err := cli.SaveConfig(path, r); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "debug": true,
+  "port": 8080,
+  "ratio": 1.50
+}
+`
+	if got := string(b); got != want {
+		t.Errorf("saved config = %s, want %s", got, want)
+	}
+
+	// the saved config is read back
+	e = &cli.Env[*p]{Args: []string{"foo"}, Params: &p{}}
+	if got := cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+		t.Fatalf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+	}
+	if want := (p{Port: 8080, Host: "localhost", Debug: true}); *e.Params != want {
+		t.Errorf("params = %+v, want %+v", *e.Params, want)
+	}
+}
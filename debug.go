@@ -0,0 +1,93 @@
+package tinycli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// debugVar is the env var that enables tracing of command resolution.
+const debugVar = "CLI_DEBUG"
+
+// A debugValue is the value of a flag defined by [DebugFlag].
+type debugValue struct {
+	p *bool
+}
+
+func (v *debugValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v.p)
+}
+
+func (v *debugValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errParse
+	}
+	*v.p = b
+	return nil
+}
+
+func (v *debugValue) Get() any {
+	return *v.p
+}
+
+func (v *debugValue) IsBoolFlag() bool { return true }
+
+// DebugFlag defines a -debug-cli flag, which traces command resolution to the
+// Env error output stream, as does setting the CLI_DEBUG env var to a true
+// value such as 1. The trace shows each matched subcommand and expanded alias,
+// and the value and source of every flag, with secrets redacted.
+func DebugFlag(fs *flag.FlagSet) {
+	fs.Var(&debugValue{p: new(bool)}, "debug-cli", "trace command resolution")
+}
+
+// debugging reports whether command resolution is traced.
+func (e Env[P]) debugging() bool {
+	if v, ok := e.getVar(debugVar); ok {
+		if b, err := strconv.ParseBool(v); err == nil && b {
+			return true
+		}
+	}
+	v, ok := lookupValue[*debugValue](e)
+	return ok && *v.p
+}
+
+// tracef writes a line of the resolution trace of c, when it is enabled.
+func (c *invocation[P]) tracef(e *Env[P], format string, args ...any) {
+	if !e.debugging() {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	e.Errorf("debug: %s: %s\n", strings.Join(e.CommandPath(), " "), e.Redactor.Redact(msg))
+}
+
+// traceFlags writes the value and source of each flag of c to the resolution
+// trace, in the order of keys.
+func (c *invocation[P]) traceFlags(e *Env[P], keys []string) {
+	if !e.debugging() {
+		return
+	}
+	for _, k := range keys {
+		value, source := c.describeValue(k)
+		c.tracef(e, "-%s=%s (%s)", k, value, source)
+	}
+}
+
+// describeValue returns the value of the named flag of c, redacted if it is a
+// secret, and a description of its source.
+func (c *invocation[P]) describeValue(flagName string) (value, source string) {
+	m := c.meta[flagName]
+	value = c.flagSet().Lookup(flagName).Value.String()
+	if m.isSecret && value != "" {
+		value = redacted
+	}
+	source = m.valueSource.String()
+	if m.valueSource == SourceVar {
+		source += " $" + m.varName
+	}
+	return value, source
+}
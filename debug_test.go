@@ -0,0 +1,100 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestDebugFlag(t *testing.T) {
+	type params struct {
+		host, token, mode string
+		port              int
+	}
+	cmdFactory := func() *cli.Command[*params] {
+		return &cli.Command[*params]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, p *params) {
+				cli.DebugFlag(fs)
+				fs.StringVar(&p.token, "token", "", "api token")
+			},
+			Vars:    map[string]string{"token": "ROOT_TOKEN"},
+			Secrets: []string{"token"},
+			UserAliases: func(e *cli.Env[*params]) (map[string]string, error) {
+				return map[string]string{"s": "serve -port 81"}, nil
+			},
+			Subcommands: []*cli.Command[*params]{
+				{
+					Name: "serve",
+					Flags: func(fs *flag.FlagSet, p *params) {
+						fs.StringVar(&p.host, "host", "localhost", "host")
+						fs.IntVar(&p.port, "port", 8080, "port")
+						fs.StringVar(&p.mode, "mode", "slow", "mode")
+					},
+					Config: func(e *cli.Env[*params]) (map[string]string, error) {
+						return map[string]string{"mode": "fast"}, nil
+					},
+					Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		wantErrbuf string
+	}{
+		{
+			name: "off",
+			args: []string{"root", "serve"},
+		},
+		{
+			name: "flag",
+			args: []string{"root", "-debug-cli", "s", "extra"},
+			vars: map[string]string{"ROOT_TOKEN": "s3cret"},
+			wantErrbuf: `debug: root: -debug-cli=true (flag)
+debug: root: -token=**** (var $ROOT_TOKEN)
+debug: root: expanded alias "s" to ["serve" "-port" "81" "extra"]
+debug: root: arg "serve" matched subcommand serve
+debug: root serve: -host=localhost (default)
+debug: root serve: -mode=fast (config)
+debug: root serve: -port=81 (flag)
+debug: root serve: running action with args ["extra"]
+`,
+		},
+		{
+			name: "env_var",
+			args: []string{"root", "serve"},
+			vars: map[string]string{"CLI_DEBUG": "1"},
+			wantErrbuf: `debug: root: -debug-cli=false (default)
+debug: root: -token= (default)
+debug: root: arg "serve" matched subcommand serve
+debug: root serve: -host=localhost (default)
+debug: root serve: -mode=fast (config)
+debug: root serve: -port=8080 (default)
+debug: root serve: running action with args []
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[*params]{Args: tt.args, Vars: tt.vars, Err: &errbuf, Params: &params{}}
+			if got := cmdFactory().Execute(t.Context(), e); got != cli.ExitSuccess {
+				t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
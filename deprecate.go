@@ -0,0 +1,130 @@
+package tinycli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Deprecation schedules the removal of a flag or command across releases.
+//
+// Compared against the Command Version, a deprecation is only noted in the
+// command spec before Since, produces a warning when used from Since, and is
+// reported as an error when used from Removal. When no Version is set, or
+// Since is empty, using a deprecated flag or command produces a warning.
+type Deprecation struct {
+	Since   string `json:"since,omitempty"`   // version deprecated in
+	Removal string `json:"removal,omitempty"` // version removed in
+	Message string `json:"message,omitempty"` // hint, such as a replacement
+}
+
+type deprecationStage int
+
+const (
+	deprecationNote deprecationStage = iota
+	deprecationWarning
+	deprecationRemoved
+)
+
+func (d *Deprecation) stage(version string) deprecationStage {
+	if version == "" {
+		return deprecationWarning
+	}
+	if d.Since != "" && compareVersions(version, d.Since) < 0 {
+		return deprecationNote
+	}
+	if d.Removal != "" && compareVersions(version, d.Removal) >= 0 {
+		return deprecationRemoved
+	}
+	return deprecationWarning
+}
+
+// describe returns a message describing the deprecation of subject, such as
+// `flag -old`, at the given stage.
+func (d *Deprecation) describe(subject string, stage deprecationStage) string {
+	var sb strings.Builder
+	if stage == deprecationRemoved {
+		fmt.Fprintf(&sb, "%s was removed in %s", subject, d.Removal)
+	} else {
+		fmt.Fprintf(&sb, "%s is deprecated", subject)
+		if d.Since != "" {
+			fmt.Fprintf(&sb, " since %s", d.Since)
+		}
+		if d.Removal != "" {
+			fmt.Fprintf(&sb, " and will be removed in %s", d.Removal)
+		}
+	}
+	if d.Message != "" {
+		fmt.Fprintf(&sb, ": %s", d.Message)
+	}
+	return sb.String()
+}
+
+// checkDeprecations warns about deprecated flags set from any source, and
+// about c itself if it is deprecated, and returns an error for any that have
+// been removed as of version.
+func (c *invocation[P]) checkDeprecations(e *Env[P], version string) error {
+	check := func(d *Deprecation, subject string) error {
+		switch stage := d.stage(version); stage {
+		case deprecationWarning:
+			e.Errorf("warning: %s\n", d.describe(subject, stage))
+		case deprecationRemoved:
+			return fmt.Errorf("%s", d.describe(subject, stage))
+		}
+		return nil
+	}
+
+	if c.Deprecated != nil {
+		if err := check(c.Deprecated, fmt.Sprintf("command %q", c.Name)); err != nil {
+			return err
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(c.DeprecatedFlags)) {
+		if m, ok := c.getMeta(k); !ok || m.valueSource == SourceDefault {
+			continue
+		}
+		d := c.DeprecatedFlags[k]
+		if err := check(&d, "flag -"+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareVersions compares dotted version strings such as "v1.2.3", returning
+// -1, 0, or +1. Numeric components are compared numerically, missing
+// components count as zero, and pre-release suffixes are ignored.
+func compareVersions(a, b string) int {
+	as := versionParts(a)
+	bs := versionParts(b)
+	for i := range max(len(as), len(bs)) {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -0,0 +1,112 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_deprecation(t *testing.T) {
+	type p struct {
+		Addr   string
+		Listen string
+	}
+
+	cmdFactory := func(version string) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:    "root",
+			Usage:   "root usage",
+			Version: version,
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name:  "serve",
+					Usage: "serve usage",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.StringVar(&p.Addr, "addr", "", "")
+						fs.StringVar(&p.Listen, "listen", "", "")
+					},
+					Vars: map[string]string{"addr": "ADDR"},
+					DeprecatedFlags: map[string]cli.Deprecation{
+						"addr": {Since: "v1.4.0", Removal: "v2.0.0", Message: "use -listen"},
+					},
+					Deprecated: &cli.Deprecation{Since: "v1.10"},
+					Action:     func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		version    string
+		args       []string
+		vars       map[string]string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "before_since",
+			version:    "v1.3.9",
+			args:       []string{"root", "serve", "-addr=:80"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "unset",
+			version:    "v1.5.0",
+			args:       []string{"root", "serve", "-listen=:80"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "warning",
+			version:    "v1.5.0",
+			args:       []string{"root", "serve", "-addr=:80"},
+			wantErrbuf: "warning: flag -addr is deprecated since v1.4.0 and will be removed in v2.0.0: use -listen\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "warning_var",
+			version:    "v1.5.0",
+			args:       []string{"root", "serve"},
+			vars:       map[string]string{"ADDR": ":80"},
+			wantErrbuf: "warning: flag -addr is deprecated since v1.4.0 and will be removed in v2.0.0: use -listen\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "warning_no_version",
+			args:       []string{"root", "serve", "-addr=:80"},
+			wantErrbuf: "warning: command \"serve\" is deprecated since v1.10\nwarning: flag -addr is deprecated since v1.4.0 and will be removed in v2.0.0: use -listen\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "command_numeric_compare",
+			version:    "v1.10.0",
+			args:       []string{"root", "serve"},
+			wantErrbuf: "warning: command \"serve\" is deprecated since v1.10\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "removed",
+			version:    "v2.0.0",
+			args:       []string{"root", "serve", "-addr=:80"},
+			wantErrbuf: "warning: command \"serve\" is deprecated since v1.10\nserve usage\nflag -addr was removed in v2.0.0: use -listen\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Vars: tt.vars, Err: &errbuf, Params: &p{}}
+			if got := cmdFactory(tt.version).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
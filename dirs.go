@@ -0,0 +1,101 @@
+package tinycli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ConfigDir returns the directory for the user's configuration files of the
+// named application: $XDG_CONFIG_HOME/appName, or ~/.config/appName, on Unix
+// systems, ~/Library/Application Support/appName on macOS, and
+// %AppData%\appName on Windows. The directory may not exist.
+func ConfigDir(appName string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// CacheDir returns the directory for the cached files of the named
+// application: $XDG_CACHE_HOME/appName, or ~/.cache/appName, on Unix systems,
+// ~/Library/Caches/appName on macOS, and %LocalAppData%\appName on Windows. The
+// directory may not exist.
+func CacheDir(appName string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// StateDir returns the directory for the persistent state of the named
+// application, such as history and logs: $XDG_STATE_HOME/appName, or
+// ~/.local/state/appName, on Unix systems, ~/Library/Application
+// Support/appName on macOS, and %LocalAppData%\appName on Windows. The
+// directory may not exist.
+func StateDir(appName string) (string, error) {
+	var dir string
+	switch runtime.GOOS {
+	case "windows":
+		dir = os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not defined")
+		}
+	case "darwin", "ios":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, "Library", "Application Support")
+	default:
+		dir = os.Getenv("XDG_STATE_HOME")
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".local", "state")
+		} else if !filepath.IsAbs(dir) {
+			return "", errors.New("path in $XDG_STATE_HOME is relative")
+		}
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// FindConfigFile returns the path of the first of the named files that exists
+// in the [ConfigDir] of the named application, or, on Unix systems other than
+// macOS, in the appName subdirectory of a directory listed in
+// $XDG_CONFIG_DIRS, which defaults to /etc/xdg. If none exists, it returns an
+// error wrapping [fs.ErrNotExist].
+func FindConfigFile(appName string, names ...string) (string, error) {
+	var dirs []string
+	if dir, err := ConfigDir(appName); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		sysDirs := os.Getenv("XDG_CONFIG_DIRS")
+		if sysDirs == "" {
+			sysDirs = "/etc/xdg"
+		}
+		for _, dir := range filepath.SplitList(sysDirs) {
+			if filepath.IsAbs(dir) {
+				dirs = append(dirs, filepath.Join(dir, appName))
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no config file for %s: %w", appName, fs.ErrNotExist)
+}
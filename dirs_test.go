@@ -0,0 +1,84 @@
+package tinycli_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestDirs_xdg(t *testing.T) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		t.Skipf("XDG dirs are not used on %s", runtime.GOOS)
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+
+	tests := []struct {
+		name string
+		fn   func(string) (string, error)
+		env  string
+		want string
+	}{
+		{name: "config", fn: cli.ConfigDir, env: "XDG_CONFIG_HOME", want: filepath.Join(home, ".config", "foo")},
+		{name: "cache", fn: cli.CacheDir, env: "XDG_CACHE_HOME", want: filepath.Join(home, ".cache", "foo")},
+		{name: "state", fn: cli.StateDir, env: "XDG_STATE_HOME", want: filepath.Join(home, ".local", "state", "foo")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.fn("foo"); err != nil || got != tt.want {
+				t.Errorf("default dir = %q, %v, want %q", got, err, tt.want)
+			}
+			t.Setenv(tt.env, "/xdg")
+			if got, err := tt.fn("foo"); err != nil || got != filepath.Join("/xdg", "foo") {
+				t.Errorf("$%s dir = %q, %v, want %q", tt.env, got, err, "/xdg/foo")
+			}
+			t.Setenv(tt.env, "relative")
+			if got, err := tt.fn("foo"); err == nil {
+				t.Errorf("relative $%s dir = %q, want error", tt.env, got)
+			}
+		})
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		t.Skipf("XDG dirs are not used on %s", runtime.GOOS)
+	}
+	userDir, sysDir := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("XDG_CONFIG_DIRS", sysDir)
+	write := func(path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := cli.FindConfigFile("foo", "config.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("FindConfigFile() error = %v, want fs.ErrNotExist", err)
+	}
+
+	sysConfig := filepath.Join(sysDir, "foo", "config.json")
+	write(sysConfig)
+	if got, err := cli.FindConfigFile("foo", "config.json"); err != nil || got != sysConfig {
+		t.Errorf("FindConfigFile() = %q, %v, want %q", got, err, sysConfig)
+	}
+
+	userConfig := filepath.Join(userDir, "foo", "foo.json")
+	write(userConfig)
+	if got, err := cli.FindConfigFile("foo", "config.json", "foo.json"); err != nil || got != userConfig {
+		t.Errorf("FindConfigFile() = %q, %v, want %q", got, err, userConfig)
+	}
+}
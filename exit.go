@@ -0,0 +1,42 @@
+package tinycli
+
+// An exitSignal is the panic value with which [Env.Exit] unwinds execution.
+type exitSignal struct {
+	status ExitStatus
+}
+
+// Exit ends execution with status, unwinding from the hook or action that
+// calls it, however deeply nested, as if it had returned status. Defer,
+// PersistentAfter, and OnExit hooks, and funcs registered with [Env.Defer],
+// still run, and receive status. Exit must be called from the goroutine
+// running the hook or action.
+func (e *Env[P]) Exit(status ExitStatus) {
+	panic(exitSignal{status: status})
+}
+
+// panicStatus returns the ExitStatus resulting from a panic with value r: the
+// status requested by [Env.Exit], or ExitFailure.
+func panicStatus(r any) ExitStatus {
+	if sig, ok := r.(exitSignal); ok {
+		return sig.status
+	}
+	return ExitFailure
+}
+
+// Defer registers fn to be called before Execute returns, after the tree
+// finishes and before the OnExit hook, including when a hook or action fails,
+// panics, or calls [Env.Exit]. Registered funcs are called in reverse order,
+// as deferred Go calls are.
+func (e *Env[P]) Defer(fn func()) {
+	e.deferred = append(e.deferred, fn)
+}
+
+// runDeferred calls the funcs registered with [Env.Defer] after the first n,
+// in reverse order, and unregisters them.
+func (e *Env[P]) runDeferred(n int) {
+	for len(e.deferred) > n {
+		fn := e.deferred[len(e.deferred)-1]
+		e.deferred = e.deferred[:len(e.deferred)-1]
+		fn()
+	}
+}
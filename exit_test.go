@@ -0,0 +1,113 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_Exit(t *testing.T) {
+	tests := []struct {
+		name       string
+		noRecover  bool
+		action     cli.ActionFunc[any]
+		wantEvents []string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name: "exit",
+			action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				e.Exit(cli.ExitUnavailable)
+				return cli.ExitSuccess
+			},
+			wantEvents: []string{"persistentAfter 69", "defer 69", "deferred 2", "deferred 1", "onExit 69"},
+			wantStatus: cli.ExitUnavailable,
+		},
+		{
+			name:      "exit_no_recover",
+			noRecover: true,
+			action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				e.Exit(cli.ExitSuccess)
+				return cli.ExitFailure
+			},
+			wantEvents: []string{"persistentAfter 0", "defer 0", "deferred 2", "deferred 1", "onExit 0"},
+		},
+		{
+			name: "return",
+			action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				return cli.ExitTempFail
+			},
+			wantEvents: []string{"persistentAfter 75", "defer 75", "deferred 2", "deferred 1", "onExit 75"},
+			wantStatus: cli.ExitTempFail,
+		},
+		{
+			name: "panic",
+			action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				panic(errors.New("boom"))
+			},
+			wantEvents: []string{"persistentAfter 1", "defer 1", "deferred 2", "deferred 1", "onExit 1"},
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			record := func(event string, n cli.ExitStatus) {
+				events = append(events, fmt.Sprintf("%s %d", event, n))
+			}
+			cmd := &cli.Command[any]{
+				Name:      "root",
+				NoRecover: tt.noRecover,
+				OnExit: func(e *cli.Env[any], status cli.ExitStatus) {
+					record("onExit", status)
+				},
+				Defer: func(e *cli.Env[any], status cli.ExitStatus) {
+					record("defer", status)
+				},
+				PersistentBefore: func(ctx context.Context, e *cli.Env[any]) error {
+					e.Defer(func() { record("deferred", 1) })
+					return nil
+				},
+				PersistentAfter: func(e *cli.Env[any], status cli.ExitStatus) {
+					record("persistentAfter", status)
+				},
+				Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+					e.Defer(func() { record("deferred", 2) })
+					return tt.action(ctx, e)
+				},
+			}
+			var errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: []string{"root"}, Err: &errbuf}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantEvents, events); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("before_hook", func(t *testing.T) {
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Before: func(e *cli.Env[any]) error {
+				e.Exit(cli.ExitSuccess)
+				return nil
+			},
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				t.Error("action called after Exit")
+				return cli.ExitFailure
+			},
+		}
+		e := &cli.Env[any]{Args: []string{"root"}}
+		if got := cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+			t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+		}
+	})
+}
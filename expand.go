@@ -0,0 +1,69 @@
+package tinycli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errUnterminatedRef = errors.New("unterminated variable reference")
+
+// expandVars replaces ${NAME} references in s with the values of the env vars
+// of e, and $$ with a literal $. Other $ characters are kept as they are. It
+// is an error to reference an undefined var.
+func expandVars[P any](e *Env[P], s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			name, rest, ok := strings.Cut(s[i+2:], "}")
+			if !ok {
+				return "", errUnterminatedRef
+			}
+			value, isSet := e.getVar(name)
+			if !isSet {
+				return "", fmt.Errorf("undefined variable ${%s}", name)
+			}
+			b.WriteString(value)
+			s = rest
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
+
+// expandFlags expands var references in the values of the Expand flags of c
+// that are set from the command line, env vars, or config.
+func (c *invocation[P]) expandFlags(e *Env[P]) error {
+	for _, name := range c.Expand {
+		m, ok := c.meta[name]
+		if !ok || m.valueSource == SourceDefault || m.valueSource == SourcePrompt {
+			continue
+		}
+		value, err := expandVars(e, m.value)
+		if err != nil {
+			return c.decorateValueError(&ValueError{Name: name, Err: err})
+		}
+		if value == m.value {
+			continue
+		}
+		if err := c.setFlag(name, value); err != nil {
+			return c.decorateValueError(&ValueError{Name: name, Err: err})
+		}
+		m.value = value
+	}
+	return nil
+}
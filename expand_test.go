@@ -0,0 +1,111 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_expand(t *testing.T) {
+	type params struct {
+		cache string
+		label string
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		config     map[string]string
+		wantCache  string
+		wantLabel  string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:      "flag",
+			args:      []string{"foo", "-cache", "${HOME}/.cache/foo", "-label", "${HOME}"},
+			vars:      map[string]string{"HOME": "/home/gopher"},
+			wantCache: "/home/gopher/.cache/foo",
+			wantLabel: "${HOME}",
+		},
+		{
+			name:      "var",
+			args:      []string{"foo"},
+			vars:      map[string]string{"HOME": "/home/gopher", "FOO_CACHE": "${HOME}/cache"},
+			wantCache: "/home/gopher/cache",
+		},
+		{
+			name:      "config",
+			args:      []string{"foo"},
+			vars:      map[string]string{"XDG_CACHE_HOME": "/tmp/cache"},
+			config:    map[string]string{"cache": "${XDG_CACHE_HOME}/foo"},
+			wantCache: "/tmp/cache/foo",
+		},
+		{
+			name:      "default",
+			args:      []string{"foo"},
+			wantCache: "${UNSET}",
+		},
+		{
+			name:      "escapes",
+			args:      []string{"foo", "-cache", "$$HOME/${EMPTY}$1$"},
+			vars:      map[string]string{"EMPTY": ""},
+			wantCache: "$HOME/$1$",
+		},
+		{
+			name:       "undefined",
+			args:       []string{"foo", "-cache", "${NOPE}/foo"},
+			wantCache:  "${NOPE}/foo",
+			wantErrbuf: "invalid value \"${NOPE}/foo\" for flag cache: undefined variable ${NOPE}\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "unterminated",
+			args:       []string{"foo"},
+			vars:       map[string]string{"FOO_CACHE": "${HOME"},
+			wantCache:  "${HOME",
+			wantErrbuf: "invalid value \"${HOME\" for var $FOO_CACHE: unterminated variable reference\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &params{}
+			cmd := &cli.Command[*params]{
+				Name:        "foo",
+				UsagePolicy: cli.UsageNever,
+				Flags: func(fs *flag.FlagSet, p *params) {
+					fs.StringVar(&p.cache, "cache", "${UNSET}", "cache directory")
+					fs.StringVar(&p.label, "label", "", "label")
+				},
+				Vars:   map[string]string{"cache": "FOO_CACHE"},
+				Expand: []string{"cache"},
+				Config: func(e *cli.Env[*params]) (map[string]string, error) {
+					return tt.config, nil
+				},
+				Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+					return cli.ExitSuccess
+				},
+			}
+			var errbuf bytes.Buffer
+			e := &cli.Env[*params]{Args: tt.args, Vars: tt.vars, Err: &errbuf, Params: p}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if p.cache != tt.wantCache {
+				t.Errorf("cache = %q, want %q", p.cache, tt.wantCache)
+			}
+			if p.label != tt.wantLabel {
+				t.Errorf("label = %q, want %q", p.label, tt.wantLabel)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
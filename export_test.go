@@ -0,0 +1,17 @@
+package tinycli
+
+// SetFoldVarCase sets whether env var names are matched case-insensitively,
+// returning a func that restores the previous setting.
+func SetFoldVarCase(fold bool) (restore func()) {
+	prev := foldVarCase
+	foldVarCase = fold
+	return func() { foldVarCase = prev }
+}
+
+// SetExit replaces the func used to exit the process, returning a func that
+// restores the previous one.
+func SetExit(fn func(code int)) (restore func()) {
+	prev := exit
+	exit = fn
+	return func() { exit = prev }
+}
@@ -0,0 +1,77 @@
+package tinycli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A GroupRule is the relationship enforced between the flags of a
+// [FlagGroup].
+type GroupRule int
+
+const (
+	GroupMutuallyExclusive GroupRule = iota // at most one of the flags may be set
+	GroupRequiredTogether                   // either all or none of the flags must be set
+)
+
+// A FlagGroup is a relationship between flags of a Command, checked after
+// flags, env vars, and config values are resolved.
+type FlagGroup struct {
+	Flags []string  // names of the flags in the group
+	Rule  GroupRule // relationship between the flags
+}
+
+// MutuallyExclusive returns a [FlagGroup] allowing at most one of the named
+// flags to be set.
+func MutuallyExclusive(names ...string) FlagGroup {
+	return FlagGroup{Flags: names, Rule: GroupMutuallyExclusive}
+}
+
+// RequiredTogether returns a [FlagGroup] requiring that either all or none of
+// the named flags are set.
+func RequiredTogether(names ...string) FlagGroup {
+	return FlagGroup{Flags: names, Rule: GroupRequiredTogether}
+}
+
+// checkGroups returns an error describing the first flag group whose rule is
+// violated, naming where each set flag's value came from.
+func (c *invocation[P]) checkGroups() error {
+	for _, g := range c.Groups {
+		var set, unset []string
+		for _, name := range g.Flags {
+			if meta, ok := c.getMeta(name); ok && meta.valueSource != SourceDefault {
+				set = append(set, c.describeSetFlag(meta))
+			} else if varName, ok := c.lookupVarName(name); ok {
+				unset = append(unset, fmt.Sprintf("-%s (or $%s)", name, varName))
+			} else {
+				unset = append(unset, "-"+name)
+			}
+		}
+
+		switch g.Rule {
+		case GroupMutuallyExclusive:
+			if len(set) > 1 {
+				return fmt.Errorf("mutually exclusive: %s", strings.Join(set, ", "))
+			}
+		case GroupRequiredTogether:
+			if len(set) > 0 && len(unset) > 0 {
+				return fmt.Errorf("missing: %s, required with %s", strings.Join(unset, ", "), strings.Join(set, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// describeSetFlag names a set flag along with the source of its value, when it
+// was not given on the command line.
+func (c *invocation[P]) describeSetFlag(meta *flagMeta) string {
+	switch meta.valueSource {
+	case SourceVar:
+		return fmt.Sprintf("-%s (from $%s)", meta.flagName, meta.varName)
+	case SourceConfig:
+		return fmt.Sprintf("-%s (from config)", meta.flagName)
+	case SourcePrompt:
+		return fmt.Sprintf("-%s (from prompt)", meta.flagName)
+	}
+	return "-" + meta.flagName
+}
@@ -0,0 +1,110 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_groups(t *testing.T) {
+	type p struct {
+		JSON     bool
+		YAML     bool
+		User     string
+		Password string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.BoolVar(&p.JSON, "json", false, "")
+				fs.BoolVar(&p.YAML, "yaml", false, "")
+				fs.StringVar(&p.User, "user", "", "")
+				fs.StringVar(&p.Password, "password", "", "")
+			},
+			VarPrefix: "FOO_",
+			Config: func(e *cli.Env[*p]) (map[string]string, error) {
+				if v, ok := e.Vars["CONFIG_YAML"]; ok {
+					return map[string]string{"yaml": v}, nil
+				}
+				return nil, nil
+			},
+			Groups: []cli.FlagGroup{
+				cli.MutuallyExclusive("json", "yaml"),
+				cli.RequiredTogether("user", "password"),
+			},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "none",
+			args:       []string{"root"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "exclusive_one",
+			args:       []string{"root", "-json"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "exclusive_flags",
+			args:       []string{"root", "-json", "-yaml"},
+			wantErrbuf: "root usage\nmutually exclusive: -json, -yaml\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "exclusive_var",
+			args:       []string{"root", "-json"},
+			vars:       map[string]string{"FOO_YAML": "true"},
+			wantErrbuf: "root usage\nmutually exclusive: -json, -yaml (from $FOO_YAML)\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "exclusive_config",
+			args:       []string{"root", "-json"},
+			vars:       map[string]string{"CONFIG_YAML": "true"},
+			wantErrbuf: "root usage\nmutually exclusive: -json, -yaml (from config)\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "together_all",
+			args:       []string{"root", "-user=alice"},
+			vars:       map[string]string{"FOO_PASSWORD": "hunter2"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "together_missing",
+			args:       []string{"root"},
+			vars:       map[string]string{"FOO_USER": "alice"},
+			wantErrbuf: "root usage\nmissing: -password (or $FOO_PASSWORD), required with -user (from $FOO_USER)\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Vars: tt.vars, Err: &errbuf, Params: &p{}}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
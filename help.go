@@ -0,0 +1,112 @@
+package tinycli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// GenerateHelp is a [HelpFunc] that renders help text for the executing
+// command from its definition: its subcommands, its help topics, and its flags
+// with their types, defaults, and env var bindings. It is opt-in, for commands
+// whose hand-maintained help would drift out of sync with their flags:
+//
+//	c := Command[*p]{
+//		HelpFunc: GenerateHelp[*p],
+//	}
+func GenerateHelp[P any](e *Env[P]) string {
+	if len(e.cmds) == 0 {
+		return ""
+	}
+	c := e.cmds[len(e.cmds)-1]
+
+	var sections []string
+	var subs []*Command[P]
+	for _, sub := range c.Subcommands {
+		if !sub.Hidden {
+			subs = append(subs, sub)
+		}
+	}
+	if len(subs) > 0 {
+		var sb strings.Builder
+		sb.WriteString("commands:")
+		for _, sub := range subs {
+			name := sub.Name
+			if param, ok := paramName(name); ok {
+				name = "<" + param + ">"
+			}
+			sb.WriteString("\n  " + name)
+		}
+		sections = append(sections, sb.String())
+	}
+
+	if len(c.Topics) > 0 {
+		var width int
+		for _, t := range c.Topics {
+			width = max(width, len(t.Name))
+		}
+		var sb strings.Builder
+		sb.WriteString("topics:")
+		for _, t := range c.Topics {
+			sb.WriteString(strings.TrimRight(fmt.Sprintf("\n  %-*s  %s", width, t.Name, t.Usage), " "))
+		}
+		sections = append(sections, sb.String())
+	}
+
+	var (
+		names  []string
+		usages []string
+		width  int
+	)
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		if c.isHidden(f.Name) {
+			return
+		}
+		s := c.flagSpec(f)
+		uf := *f
+		uf.Value = unwrapValue(f.Value)
+		typeName, usage := flag.UnquoteUsage(&uf)
+		if len(s.Choices) > 0 && typeName == "value" {
+			typeName = strings.Join(s.Choices, "|")
+		}
+		name := "-" + f.Name
+		if typeName != "" {
+			name += " " + typeName
+		}
+		if !s.Secret && !isZeroDefault(s.Default) {
+			if g, ok := f.Value.(flag.Getter); ok && isString(g.Get()) {
+				usage += fmt.Sprintf(" (default %q)", s.Default)
+			} else {
+				usage += fmt.Sprintf(" (default %s)", s.Default)
+			}
+		}
+		if s.Var != "" {
+			usage += fmt.Sprintf(" ($%s)", s.Var)
+		}
+		names = append(names, name)
+		usages = append(usages, strings.TrimSpace(usage))
+		width = max(width, len(name))
+	})
+	if len(names) > 0 {
+		var sb strings.Builder
+		sb.WriteString("flags:")
+		for i, name := range names {
+			sb.WriteString(strings.TrimRight(fmt.Sprintf("\n  %-*s  %s", width, name, usages[i]), " "))
+		}
+		sections = append(sections, sb.String())
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func isZeroDefault(value string) bool {
+	switch value {
+	case "", "0", "0s", "false", "[]", "<nil>":
+		return true
+	}
+	return false
+}
+
+func isString(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
@@ -0,0 +1,76 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestGenerateHelp(t *testing.T) {
+	type p struct {
+		Env     string
+		Verbose bool
+		Port    uint
+		Timeout time.Duration
+		Token   string
+		Format  string
+		Debug   bool
+	}
+
+	cmd := &cli.Command[*p]{
+		Name:      "foo",
+		Usage:     "usage: foo [flags] command",
+		HelpFunc:  cli.GenerateHelp[*p],
+		VarPrefix: "FOO_",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.StringVar(&p.Env, "env", "dev", "environment `name`")
+			fs.BoolVar(&p.Verbose, "v", false, "enable verbose output")
+			fs.UintVar(&p.Port, "port", 5000, "port number")
+			fs.DurationVar(&p.Timeout, "timeout", 0, "request timeout")
+			fs.StringVar(&p.Token, "token", "s3cr3t", "API token")
+			cli.EnumVar(fs, &p.Format, "format", "table", "output format", []string{"json", "yaml", "table"})
+			fs.BoolVar(&p.Debug, "debug", false, "dump internal state")
+		},
+		Vars:        map[string]string{"v": ""},
+		Secrets:     []string{"token"},
+		HiddenFlags: []string{"debug"},
+		Topics: []cli.HelpTopic{
+			{Name: "environment", Usage: "environment variables"},
+			{Name: "config", Usage: "configuration files"},
+		},
+		Subcommands: []*cli.Command[*p]{
+			{Name: "serve"},
+			{Name: "__dump-state", Hidden: true},
+			{Name: cli.Param("resource")},
+		},
+	}
+
+	var outbuf bytes.Buffer
+	cmd.Execute(t.Context(), &cli.Env[*p]{Args: []string{"foo", "-h"}, Out: &outbuf, Params: &p{}})
+
+	want := `usage: foo [flags] command
+
+commands:
+  serve
+  <resource>
+
+topics:
+  environment  environment variables
+  config       configuration files
+
+flags:
+  -env name                environment name (default "dev") ($FOO_ENV)
+  -format json|yaml|table  output format (default "table") ($FOO_FORMAT)
+  -port uint               port number (default 5000) ($FOO_PORT)
+  -timeout duration        request timeout ($FOO_TIMEOUT)
+  -token string            API token ($FOO_TOKEN)
+  -v                       enable verbose output
+`
+	if diff := cmp.Diff(want, outbuf.String()); diff != "" {
+		t.Errorf("help output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,134 @@
+package tinycli
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"weak"
+)
+
+// A subcommandIndex maps the names and aliases of a Command's subcommands to
+// them.
+type subcommandIndex[P any] struct {
+	subs  []*Command[P] // Subcommands the index was built from
+	names map[string]*Command[P]
+	param *Command[P] // parameter subcommand, if any
+	err   error       // duplicate names or aliases
+}
+
+// indexes caches the subcommandIndex of each Command, keyed by a weak pointer
+// to it, so that Commands remain copyable and are not kept alive by the cache.
+var indexes sync.Map // weak.Pointer[Command[P]] -> *subcommandIndex[P]
+
+// subcommandIndex returns the index of the Command's subcommands, building it
+// on first use, and again whenever Subcommands has since been replaced or
+// appended to. Changes to the names or aliases of existing subcommands after
+// the Command is first executed or validated are not detected.
+func (c *Command[P]) subcommandIndex() *subcommandIndex[P] {
+	key := weak.Make(c)
+	if v, ok := indexes.Load(key); ok {
+		if idx := v.(*subcommandIndex[P]); idx.current(c) {
+			return idx
+		}
+	}
+	idx := newSubcommandIndex(c)
+	if _, loaded := indexes.Swap(key, idx); !loaded {
+		runtime.AddCleanup(c, func(key weak.Pointer[Command[P]]) { indexes.Delete(key) }, key)
+	}
+	return idx
+}
+
+// current reports whether idx was built from the current Subcommands of c.
+func (idx *subcommandIndex[P]) current(c *Command[P]) bool {
+	if len(idx.subs) != len(c.Subcommands) {
+		return false
+	}
+	return len(idx.subs) == 0 || &idx.subs[0] == &c.Subcommands[0]
+}
+
+func newSubcommandIndex[P any](c *Command[P]) *subcommandIndex[P] {
+	idx := &subcommandIndex[P]{
+		subs:  c.Subcommands,
+		names: make(map[string]*Command[P], len(c.Subcommands)),
+	}
+	var errs []error
+	for _, sub := range c.Subcommands {
+		if _, ok := paramName(sub.Name); ok {
+			if idx.param != nil {
+				errs = append(errs, fmt.Errorf("parameter subcommands %s and %s conflict", idx.param.Name, sub.Name))
+				continue
+			}
+			idx.param = sub
+			continue
+		}
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			if prev, ok := idx.names[name]; ok {
+				errs = append(errs, fmt.Errorf("subcommand name %q of %s is already used by %s", name, sub.Name, prev.Name))
+				continue
+			}
+			idx.names[name] = sub
+		}
+	}
+	idx.err = errors.Join(errs...)
+	return idx
+}
+
+// Validate reports problems with the definition of the command tree, such as
+// subcommands with duplicate names or aliases, which Execute otherwise reports
+// when it reaches them. Validating a tree also builds the indexes used to look
+// up its subcommands.
+func (c *Command[P]) Validate() error {
+	var errs []error
+	var walk func(c *Command[P], path []string)
+	walk = func(c *Command[P], path []string) {
+		path = append(path, c.Name)
+		if err := c.subcommandIndex().err; err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", strings.Join(path, " "), err))
+		}
+		for _, sub := range c.Subcommands {
+			walk(sub, path)
+		}
+	}
+	walk(c, nil)
+	return errors.Join(errs...)
+}
+
+// prefixMatches returns the visible, literally named subcommands with a name
+// or alias that begins with prefix, ignoring case if fold is set.
+func (c *Command[P]) prefixMatches(prefix string, fold bool) []*Command[P] {
+	if prefix == "" {
+		return nil
+	}
+	var matches []*Command[P]
+	for _, sub := range c.Subcommands {
+		if _, ok := paramName(sub.Name); ok || sub.Hidden {
+			continue
+		}
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			if strings.HasPrefix(name, prefix) || fold && len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				matches = append(matches, sub)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// checkAmbiguous returns an error listing the candidates when name is a prefix
+// of the names of more than one subcommand of a Command with Abbrev set.
+func (c *Command[P]) checkAmbiguous(name string, fold bool) error {
+	if !c.Abbrev {
+		return nil
+	}
+	matches := c.prefixMatches(name, fold)
+	if len(matches) < 2 {
+		return nil
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.Name
+	}
+	return fmt.Errorf("ambiguous command %q: could be %s", name, joinOr(names))
+}
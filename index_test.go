@@ -0,0 +1,305 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Validate(t *testing.T) {
+	action := func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus { return cli.ExitSuccess }
+
+	valid := &cli.Command[any]{
+		Name: "root",
+		Subcommands: []*cli.Command[any]{
+			{Name: "list", Aliases: []string{"ls"}, Action: action},
+			{Name: "get", Subcommands: []*cli.Command[any]{{Name: cli.Param("name"), Action: action}}},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid.Validate() error: %v", err)
+	}
+
+	invalid := &cli.Command[any]{
+		Name: "root",
+		Subcommands: []*cli.Command[any]{
+			{Name: "list", Aliases: []string{"ls"}},
+			{Name: "ls"},
+			{
+				Name: "get",
+				Subcommands: []*cli.Command[any]{
+					{Name: cli.Param("name")},
+					{Name: cli.Param("id")},
+				},
+			},
+		},
+	}
+	want := `root: subcommand name "ls" of ls is already used by list
+root get: parameter subcommands {name} and {id} conflict`
+	if err := invalid.Validate(); err == nil || err.Error() != want {
+		t.Errorf("invalid.Validate() error = %v, want %q", err, want)
+	}
+}
+
+func TestCommand_Execute_modifiedSubcommands(t *testing.T) {
+	sub := func(name string) *cli.Command[any] {
+		return &cli.Command[any]{
+			Name: name,
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				e.Printf("%s\n", name)
+				return cli.ExitSuccess
+			},
+		}
+	}
+	cmd := &cli.Command[any]{Name: "root", Subcommands: []*cli.Command[any]{sub("list")}}
+	if err := cmd.Validate(); err != nil {
+		t.Fatalf("cmd.Validate() error: %v", err)
+	}
+	cmd.Subcommands = append(cmd.Subcommands, sub("get"))
+	copied := *cmd
+	copied.Subcommands = []*cli.Command[any]{sub("put")}
+
+	tests := []struct {
+		cmd  *cli.Command[any]
+		args []string
+		want string
+	}{
+		{cmd: cmd, args: []string{"root", "list"}, want: "list\n"},
+		{cmd: cmd, args: []string{"root", "get"}, want: "get\n"},
+		{cmd: &copied, args: []string{"root", "put"}, want: "put\n"},
+	}
+	for _, tt := range tests {
+		var outbuf, errbuf bytes.Buffer
+		e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Err: &errbuf}
+		if got := tt.cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+			t.Errorf("cmd.Execute(%q) = %d, want %d; stderr: %s", tt.args, got, cli.ExitSuccess, errbuf.String())
+		}
+		if diff := cmp.Diff(tt.want, outbuf.String()); diff != "" {
+			t.Errorf("cmd.Execute(%q) output mismatch (-want +got):\n%s", tt.args, diff)
+		}
+	}
+}
+
+func TestCommand_Execute_aliases(t *testing.T) {
+	cmdFactory := func(dup bool) *cli.Command[any] {
+		cmd := &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+						e.Printf("list\n")
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+		if dup {
+			cmd.Subcommands = append(cmd.Subcommands, &cli.Command[any]{Name: "dup", Aliases: []string{"list"}})
+		}
+		return cmd
+	}
+
+	tests := []struct {
+		name       string
+		dup        bool
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "alias",
+			wantOutbuf: "list\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "duplicate",
+			dup:        true,
+			wantErrbuf: "root usage\nsubcommand name \"list\" of dup is already used by list\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: []string{"root", "ls"}, Out: &outbuf, Err: &errbuf}
+			if got := cmdFactory(tt.dup).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_abbrev(t *testing.T) {
+	cmdFactory := func(got *string) *cli.Command[any] {
+		record := func(name string) cli.ActionFunc[any] {
+			return func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				*got = name
+				return cli.ExitSuccess
+			}
+		}
+		return &cli.Command[any]{
+			Name:   "root",
+			Usage:  "root usage",
+			Abbrev: true,
+			Subcommands: []*cli.Command[any]{
+				{Name: "status", Action: record("status")},
+				{Name: "stash", Action: record("stash")},
+				{Name: "stat", Action: record("stat")},
+				{Name: "list", Aliases: []string{"ls"}, Action: record("list")},
+				{Name: "secret", Hidden: true, Action: record("secret")},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantGot    string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{name: "unique", args: []string{"root", "statu"}, wantGot: "status"},
+		{name: "exact_over_prefix", args: []string{"root", "stat"}, wantGot: "stat"},
+		{name: "alias_prefix", args: []string{"root", "l"}, wantGot: "list"},
+		{
+			name:       "ambiguous",
+			args:       []string{"root", "st"},
+			wantErrbuf: "root usage\nambiguous command \"st\": could be status, stash, or stat\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "hidden",
+			args:       []string{"root", "sec"},
+			wantErrbuf: "root usage\nunknown command\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				errbuf bytes.Buffer
+				got    string
+			)
+			e := &cli.Env[any]{Args: tt.args, Err: &errbuf}
+			if status := cmdFactory(&got).Execute(t.Context(), e); status != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", status, tt.wantStatus)
+			}
+			if got != tt.wantGot {
+				t.Errorf("executed %q, want %q", got, tt.wantGot)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_matchCase(t *testing.T) {
+	type params struct {
+		port    int
+		verbose bool
+		v       bool
+		version bool
+	}
+	cmdFactory := func(policy cli.MatchPolicy, got *string) *cli.Command[*params] {
+		return &cli.Command[*params]{
+			Name:      "root",
+			Usage:     "root usage",
+			MatchCase: policy,
+			Flags: func(fs *flag.FlagSet, p *params) {
+				fs.BoolVar(&p.verbose, "verbose", false, "verbose output")
+			},
+			Persistent: []string{"verbose"},
+			Subcommands: []*cli.Command[*params]{
+				{
+					Name: "serve",
+					Flags: func(fs *flag.FlagSet, p *params) {
+						fs.IntVar(&p.port, "port", 8080, "port")
+						fs.BoolVar(&p.v, "v", false, "verbose output")
+						fs.BoolVar(&p.version, "V", false, "print version")
+					},
+					Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+						*got = fmt.Sprintf("serve port=%d verbose=%t version=%t args=%v", e.Params.port, e.Params.verbose, e.Params.version, e.Args)
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     cli.MatchPolicy
+		args       []string
+		wantGot    string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "exact_default",
+			args:       []string{"root", "Serve"},
+			wantErrbuf: "root usage\nunknown command\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:    "fold_subcommand_and_flags",
+			policy:  cli.MatchFold,
+			args:    []string{"root", "-VERBOSE", "Serve", "-PORT=80", "--Port", "81", "X"},
+			wantGot: "serve port=81 verbose=true version=false args=[X]",
+		},
+		{
+			name:    "fold_persistent_after_subcommand",
+			policy:  cli.MatchFold,
+			args:    []string{"root", "SERVE", "-Verbose"},
+			wantGot: "serve port=8080 verbose=true version=false args=[]",
+		},
+		{
+			name:    "exact_precedence",
+			policy:  cli.MatchFold,
+			args:    []string{"root", "serve", "-V"},
+			wantGot: "serve port=8080 verbose=false version=true args=[]",
+		},
+		{
+			name:       "exact_flags",
+			policy:     cli.MatchExact,
+			args:       []string{"root", "serve", "-PORT=80"},
+			wantErrbuf: "\nflag provided but not defined: -PORT\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				errbuf bytes.Buffer
+				got    string
+			)
+			e := &cli.Env[*params]{Args: tt.args, Err: &errbuf, Params: &params{}}
+			if status := cmdFactory(tt.policy, &got).Execute(t.Context(), e); status != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", status, tt.wantStatus)
+			}
+			if got != tt.wantGot {
+				t.Errorf("executed %q, want %q", got, tt.wantGot)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
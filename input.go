@@ -0,0 +1,109 @@
+package tinycli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// An InputMode describes what is connected to the input stream of an [Env].
+type InputMode int
+
+const (
+	InputClosed   InputMode = iota // no input is available
+	InputTerminal                  // input is an interactive terminal
+	InputPiped                     // input is a pipe, file, or other reader
+)
+
+func (m InputMode) String() string {
+	switch m {
+	case InputClosed:
+		return "closed"
+	case InputTerminal:
+		return "terminal"
+	case InputPiped:
+		return "piped"
+	}
+	return "unknown"
+}
+
+var (
+	errInputTerminal = errors.New("input must be piped, not read from a terminal")
+	errInputClosed   = errors.New("input must be piped, but none was provided")
+)
+
+// InputMode reports what is connected to the Env input stream.
+//
+// A nil In, or a file that is neither a terminal nor readable data such as
+// [os.DevNull], is closed. Readers that are not files are considered piped.
+func (e Env[P]) InputMode() InputMode {
+	if e.In == nil {
+		return InputClosed
+	}
+	f, ok := e.In.(*os.File)
+	if !ok {
+		return InputPiped
+	}
+	if isTerminal(f) {
+		return InputTerminal
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return InputClosed
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return InputClosed
+	}
+	return InputPiped
+}
+
+// RequirePipedInput returns an error unless the Env input stream is piped, so
+// commands that consume piped data fail clearly instead of waiting on a
+// terminal.
+func (e Env[P]) RequirePipedInput() error {
+	switch e.InputMode() {
+	case InputTerminal:
+		return errInputTerminal
+	case InputClosed:
+		return errInputClosed
+	}
+	return nil
+}
+
+// ReadLine returns a line read from the Env input stream, without its line
+// ending. It returns [io.EOF] when the stream is exhausted or In is nil.
+//
+// The line is read one byte at a time, so that no input beyond it is
+// consumed, and the read is bound by the Env Deadline when In is a file.
+func (e *Env[P]) ReadLine() (string, error) {
+	if e.In == nil {
+		return "", io.EOF
+	}
+	if f, ok := e.In.(*os.File); ok && !e.Deadline.IsZero() {
+		if err := f.SetReadDeadline(e.Deadline); err == nil {
+			defer f.SetReadDeadline(time.Time{})
+		}
+	}
+
+	var (
+		sb  strings.Builder
+		buf [1]byte
+	)
+	for {
+		n, err := e.In.Read(buf[:])
+		if n > 0 {
+			if buf[0] == '\n' {
+				return strings.TrimSuffix(sb.String(), "\r"), nil
+			}
+			sb.WriteByte(buf[0])
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) && sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package tinycli_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	cli "github.com/jonathonwebb/tinycli"
+	"github.com/jonathonwebb/tinycli/clitest"
+)
+
+func TestEnv_InputMode(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	tests := []struct {
+		name    string
+		in      io.Reader
+		want    cli.InputMode
+		wantErr bool
+	}{
+		{name: "nil", in: nil, want: cli.InputClosed, wantErr: true},
+		{name: "dev_null", in: devNull, want: cli.InputClosed, wantErr: true},
+		{name: "pipe", in: r, want: cli.InputPiped},
+		{name: "reader", in: strings.NewReader("data"), want: cli.InputPiped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := cli.Env[any]{In: tt.in}
+			if got := e.InputMode(); tt.want != got {
+				t.Errorf("env.InputMode()=%v, want %v", got, tt.want)
+			}
+			if err := e.RequirePipedInput(); (err != nil) != tt.wantErr {
+				t.Errorf("env.RequirePipedInput()=%v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("terminal", func(t *testing.T) {
+		var got cli.InputMode
+		var gotErr error
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				got, gotErr = e.InputMode(), e.RequirePipedInput()
+				return cli.ExitSuccess
+			},
+		}
+		_, err := clitest.RunPTY(t.Context(), cmd, &cli.Env[any]{Args: []string{"root"}})
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatalf("RunPTY() error: %v", err)
+		}
+		if want := cli.InputTerminal; want != got {
+			t.Errorf("env.InputMode()=%v, want %v", got, want)
+		}
+		if gotErr == nil {
+			t.Errorf("env.RequirePipedInput() on terminal returned nil error")
+		}
+	})
+}
+
+func TestEnv_ReadLine(t *testing.T) {
+	e := &cli.Env[any]{In: strings.NewReader("first\r\nsecond\nlast")}
+
+	for _, want := range []string{"first", "second", "last"} {
+		got, err := e.ReadLine()
+		if err != nil {
+			t.Fatalf("e.ReadLine() error: %v", err)
+		}
+		if got != want {
+			t.Errorf("e.ReadLine() = %q, want %q", got, want)
+		}
+	}
+	if _, err := e.ReadLine(); !errors.Is(err, io.EOF) {
+		t.Errorf("e.ReadLine() error = %v, want %v", err, io.EOF)
+	}
+
+	if _, err := (&cli.Env[any]{}).ReadLine(); !errors.Is(err, io.EOF) {
+		t.Errorf("nil input e.ReadLine() error = %v, want %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,72 @@
+package tinycli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A NormalizeFunc rewrites a resolved flag value into a canonical form.
+type NormalizeFunc = func(string) (string, error)
+
+// TrimSpace is a [NormalizeFunc] removing leading and trailing white space.
+func TrimSpace(s string) (string, error) {
+	return strings.TrimSpace(s), nil
+}
+
+// ToLower is a [NormalizeFunc] mapping letters to lower case.
+func ToLower(s string) (string, error) {
+	return strings.ToLower(s), nil
+}
+
+// ExpandHome is a [NormalizeFunc] replacing a leading ~ path element with the
+// user's home directory, as a shell does.
+func ExpandHome(s string) (string, error) {
+	if s != "~" && !strings.HasPrefix(s, "~/") && !strings.HasPrefix(s, "~"+string(filepath.Separator)) {
+		return s, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, s[1:]), nil
+}
+
+// AbsPath is a [NormalizeFunc] resolving a relative path against the current
+// working directory. Empty values are left empty.
+func AbsPath(s string) (string, error) {
+	if s == "" {
+		return s, nil
+	}
+	return filepath.Abs(s)
+}
+
+// normalizeFlags applies the Normalize funcs of c to the values of its flags
+// that are not defaults, in order.
+func (c *invocation[P]) normalizeFlags(e *Env[P], keys []string) error {
+	for _, k := range keys {
+		fns := c.Normalize[k]
+		m := c.meta[k]
+		if len(fns) == 0 || m.valueSource == SourceDefault {
+			continue
+		}
+		value := m.value
+		for _, fn := range fns {
+			var err error
+			if value, err = fn(value); err != nil {
+				return c.decorateValueError(&ValueError{Name: k, Err: err})
+			}
+		}
+		if value == m.value {
+			continue
+		}
+		if err := c.setFlag(k, value); err != nil {
+			return c.decorateValueError(&ValueError{Name: k, Err: err})
+		}
+		m.value = value
+		if m.isSecret {
+			e.redactor().AddValue(value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_normalize(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skipf("home directory is not $HOME on %s", runtime.GOOS)
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type params struct {
+		region string
+		dir    string
+		name   string
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		want       params
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name: "flags",
+			args: []string{"foo", "-region", " US-East ", "-dir", "~/data", "-name", " web "},
+			want: params{region: "us-east", dir: filepath.Join(home, "data"), name: " web "},
+		},
+		{
+			name: "var",
+			args: []string{"foo"},
+			vars: map[string]string{"FOO_DIR": "data"},
+			want: params{region: "US", dir: filepath.Join(wd, "data")},
+		},
+		{
+			name: "defaults",
+			args: []string{"foo"},
+			want: params{region: "US"},
+		},
+		{
+			name:       "error",
+			args:       []string{"foo", "-region", "mars"},
+			want:       params{region: "mars"},
+			wantErrbuf: "invalid value \"mars\" for flag region: unknown region\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inAfter *params
+			cmd := &cli.Command[*params]{
+				Name:        "foo",
+				UsagePolicy: cli.UsageNever,
+				Flags: func(fs *flag.FlagSet, p *params) {
+					fs.StringVar(&p.region, "region", "US", "")
+					fs.StringVar(&p.dir, "dir", "", "")
+					fs.StringVar(&p.name, "name", "", "")
+				},
+				Vars: map[string]string{"dir": "FOO_DIR"},
+				Normalize: map[string][]cli.NormalizeFunc{
+					"region": {cli.TrimSpace, cli.ToLower, func(s string) (string, error) {
+						if s == "mars" {
+							return "", errors.New("unknown region")
+						}
+						return s, nil
+					}},
+					"dir": {cli.ExpandHome, cli.AbsPath},
+				},
+				After: func(e *cli.Env[*params]) error {
+					p := *e.Params
+					inAfter = &p
+					return nil
+				},
+				Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+					return cli.ExitSuccess
+				},
+			}
+			var errbuf bytes.Buffer
+			e := &cli.Env[*params]{Args: tt.args, Vars: tt.vars, Err: &errbuf, Params: &params{}}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.want, *e.Params, cmp.AllowUnexported(params{})); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantStatus == cli.ExitSuccess && (inAfter == nil || *inAfter != tt.want) {
+				t.Errorf("params in After = %+v, want %+v", inAfter, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package tinycli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// outputFormats are the formats accepted by an output flag, other than
+// go-template, which takes the template text as an argument.
+var outputFormats = []string{"json", "yaml", "table"}
+
+// An outputValue is the value of an output flag defined by [OutputFlag].
+type outputValue struct {
+	p *string
+}
+
+func (v *outputValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *outputValue) Set(s string) error {
+	if text, ok := strings.CutPrefix(s, "go-template="); ok {
+		if _, err := template.New("output").Parse(text); err != nil {
+			return err
+		}
+	} else if !slices.Contains(outputFormats, s) {
+		return errors.New("must be one of " + quoteChoices(append(slices.Clone(outputFormats), "go-template=...")))
+	}
+	*v.p = s
+	return nil
+}
+
+func (v *outputValue) Get() any {
+	return *v.p
+}
+
+func (v *outputValue) choices() []string {
+	return outputFormats
+}
+
+// OutputFlag defines -o and -output flags with the same value, selecting the
+// format in which [Env.Emit] writes results: "json", "yaml", "table", or
+// "go-template=TEMPLATE", which executes a [text/template] with the result as
+// its data, as in -o go-template='{{.Name}}'. The selected format is stored in
+// p, which may be nil, and defaults to value.
+//
+// Commands typically define the flags once, on the root, and list them as
+// Persistent:
+//
+//	c := Command[*p]{
+//		Flags: func(fs *flag.FlagSet, p *p) {
+//			OutputFlag(fs, nil, "table")
+//		},
+//		Persistent: []string{"o", "output"},
+//	}
+func OutputFlag(fs *flag.FlagSet, p *string, value string) {
+	if p == nil {
+		p = new(string)
+	}
+	*p = value
+	v := &outputValue{p: p}
+	usage := "output format: " + strings.Join(outputFormats, ", ") + ", or go-template=TEMPLATE"
+	fs.Var(v, "o", usage)
+	fs.Var(v, "output", usage)
+}
+
+// outputFormat returns the format selected by the output flag of the nearest
+// executing command that defines one, or "table" if there is none.
+func (e Env[P]) outputFormat() string {
+	if v, ok := lookupValue[*outputValue](e); ok && v.String() != "" {
+		return v.String()
+	}
+	return "table"
+}
+
+// lookupValue returns the value of type V of a flag of the nearest executing
+// command that defines one.
+func lookupValue[V flag.Value, P any](e Env[P]) (V, bool) {
+	for _, c := range slices.Backward(e.cmds) {
+		var (
+			found V
+			ok    bool
+		)
+		c.flagSet().VisitAll(func(f *flag.Flag) {
+			if v, isV := unwrapValue(f.Value).(V); isV {
+				found, ok = v, true
+			}
+		})
+		if ok {
+			return found, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Emit writes v to the Env standard output stream in the format selected by
+// the [OutputFlag] of the executing command or its nearest ancestor.
+//
+// Go templates are executed with v itself as their data. For other formats,
+// the value is first marshaled with [encoding/json], so that JSON struct tags
+// name its fields. The table format writes a slice of structs or maps as one
+// aligned row per element, with a header row of upper case field names; a
+// single struct or map is written as one row. Tables are written as by
+// [Env.Table].
+func (e Env[P]) Emit(v any) error {
+	return e.emit(v, e.outputFormat())
+}
+
+// emit writes v to the Env standard output stream in the given format.
+func (e Env[P]) emit(v any, format string) error {
+	if text, ok := strings.CutPrefix(format, "go-template="); ok {
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, v); err != nil {
+			return err
+		}
+		_, err = e.Printf("%s", buf.Bytes())
+		return err
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	n, err := decodeOutNode(dec)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "json":
+		if err := json.Indent(&buf, b, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	case "yaml":
+		writeYAML(&buf, n, "")
+	case "table":
+		headers, rows := tableRows(n)
+		t := e.Table(headers...)
+		t.out = &buf
+		for _, row := range rows {
+			io.WriteString(t, strings.Join(row, "\t")+"\n")
+		}
+		if err := t.Flush(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	_, err = e.Printf("%s", buf.Bytes())
+	return err
+}
+
+// An outNode is a decoded JSON value that preserves the order of object keys.
+type outNode struct {
+	keys   []string   // object keys
+	elems  []*outNode // object values or array elements
+	scalar any        // string, json.Number, bool, or nil
+	kind   byte       // '{', '[', or 0 for scalars
+}
+
+func decodeOutNode(dec *json.Decoder) (*outNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &outNode{scalar: tok}, nil
+	}
+	n := &outNode{kind: byte(delim)}
+	for dec.More() {
+		if n.kind == '{' {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, key.(string))
+		}
+		elem, err := decodeOutNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		n.elems = append(n.elems, elem)
+	}
+	// consume the closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// inline returns the single-line form of n and true if n is a scalar or an
+// empty object or array.
+func (n *outNode) inline() (string, bool) {
+	switch {
+	case n.kind == '{' && len(n.elems) == 0:
+		return "{}", true
+	case n.kind == '[' && len(n.elems) == 0:
+		return "[]", true
+	case n.kind != 0:
+		return "", false
+	}
+	switch v := n.scalar.(type) {
+	case string:
+		return yamlString(v), true
+	case nil:
+		return "null", true
+	}
+	return fmt.Sprint(n.scalar), true
+}
+
+// writeYAML writes n as a YAML block, indenting each line by indent.
+func writeYAML(buf *bytes.Buffer, n *outNode, indent string) {
+	if s, ok := n.inline(); ok {
+		buf.WriteString(indent + s + "\n")
+		return
+	}
+	for i, elem := range n.elems {
+		prefix := indent + "-"
+		if n.kind == '{' {
+			prefix = indent + yamlString(n.keys[i]) + ":"
+		}
+		if s, ok := elem.inline(); ok {
+			buf.WriteString(prefix + " " + s + "\n")
+			continue
+		}
+		if n.kind == '{' {
+			buf.WriteString(prefix + "\n")
+			writeYAML(buf, elem, indent+"  ")
+			continue
+		}
+		// start the first line of the element's block on the "-" line
+		var block bytes.Buffer
+		writeYAML(&block, elem, indent+"  ")
+		buf.WriteString(prefix + " ")
+		buf.Write(block.Bytes()[len(indent)+2:])
+	}
+}
+
+// yamlReserved are plain scalars that YAML parsers may read as non-strings.
+var yamlReserved = []string{"", "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n"}
+
+// yamlString returns s as a plain YAML scalar when it cannot be mistaken for
+// another type or syntax, and as a double-quoted scalar otherwise.
+func yamlString(s string) string {
+	if slices.Contains(yamlReserved, strings.ToLower(s)) || strings.TrimSpace(s) != s {
+		return strconv.Quote(s)
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || r == '_' || r == '/' || (i > 0 && (unicode.IsDigit(r) || strings.ContainsRune(" .-@", r))) {
+			continue
+		}
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// tableRows returns the header and cells of each row of a table of n. Arrays
+// of objects have a column for each key, arrays of other values have a single
+// VALUE column, and other values are a single row.
+func tableRows(n *outNode) (headers []string, rows [][]string) {
+	var elems []*outNode
+	switch n.kind {
+	case '[':
+		elems = n.elems
+	case '{':
+		elems = []*outNode{n}
+	default:
+		return nil, [][]string{{tableCell(n)}}
+	}
+
+	var keys []string
+	for _, elem := range elems {
+		for _, k := range elem.keys {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		if len(elems) == 0 {
+			return nil, nil
+		}
+		for _, elem := range elems {
+			rows = append(rows, []string{tableCell(elem)})
+		}
+		return []string{"VALUE"}, rows
+	}
+
+	for _, k := range keys {
+		headers = append(headers, strings.ToUpper(k))
+	}
+	for _, elem := range elems {
+		row := make([]string, len(keys))
+		for i, k := range keys {
+			if j := slices.Index(elem.keys, k); j >= 0 {
+				row[i] = tableCell(elem.elems[j])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+// tableCell returns the text of a table cell holding n: scalars as plain text,
+// and objects and arrays as compact JSON.
+func tableCell(n *outNode) string {
+	var s string
+	switch v := n.scalar.(type) {
+	case string:
+		s = v
+	case nil:
+		if n.kind != 0 {
+			s = n.compactJSON()
+		}
+	default:
+		s = fmt.Sprint(v)
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+func (n *outNode) compactJSON() string {
+	switch n.kind {
+	case 0:
+		b, _ := json.Marshal(n.scalar)
+		return string(b)
+	case '{':
+		parts := make([]string, len(n.elems))
+		for i, elem := range n.elems {
+			parts[i] = strconv.Quote(n.keys[i]) + ":" + elem.compactJSON()
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	}
+	parts := make([]string, len(n.elems))
+	for i, elem := range n.elems {
+		parts[i] = elem.compactJSON()
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
@@ -0,0 +1,171 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_Emit(t *testing.T) {
+	type server struct {
+		Name   string            `json:"name"`
+		Port   int               `json:"port"`
+		Tags   []string          `json:"tags"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}
+	servers := []server{
+		{Name: "web", Port: 8080, Tags: []string{"public", "http"}, Labels: map[string]string{"team": "edge"}},
+		{Name: "db", Port: 5432, Tags: []string{}},
+	}
+
+	cmdFactory := func() *cli.Command[any] {
+		return &cli.Command[any]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, _ any) {
+				cli.OutputFlag(fs, nil, "table")
+			},
+			Persistent: []string{"o", "output"},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name: "list",
+					ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+						return e.Emit(servers)
+					},
+				},
+				{
+					Name: "get",
+					ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+						return e.Emit(servers[1])
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name: "default_table",
+			args: []string{"root", "list"},
+			wantOutbuf: "NAME   PORT   TAGS                LABELS\n" +
+				"web    8080   [\"public\",\"http\"]   {\"team\":\"edge\"}\n" +
+				"db     5432   []\n",
+		},
+		{
+			name:       "table_object",
+			args:       []string{"root", "get", "-o", "table"},
+			wantOutbuf: "NAME   PORT   TAGS\ndb     5432   []\n",
+		},
+		{
+			name: "json",
+			args: []string{"root", "-o", "json", "get"},
+			wantOutbuf: `{
+  "name": "db",
+  "port": 5432,
+  "tags": []
+}
+`,
+		},
+		{
+			name: "yaml",
+			args: []string{"root", "list", "-output=yaml"},
+			wantOutbuf: `- name: web
+  port: 8080
+  tags:
+    - public
+    - http
+  labels:
+    team: edge
+- name: db
+  port: 5432
+  tags: []
+`,
+		},
+		{
+			name:       "go_template",
+			args:       []string{"root", "get", "-o", "go-template={{.Name}}:{{.Port}}"},
+			wantOutbuf: "db:5432",
+		},
+		{
+			name:       "go_template_range",
+			args:       []string{"root", "list", "-o", `go-template={{range .}}{{.Name}}{{"\n"}}{{end}}`},
+			wantOutbuf: "web\ndb\n",
+		},
+		{
+			name:       "go_template_invalid",
+			args:       []string{"root", "list", "-o", "go-template={{.Name"},
+			wantErrbuf: "\ninvalid value \"go-template={{.Name\" for flag -o: template: output:1: unclosed action\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "unknown_format",
+			args:       []string{"root", "list", "-o", "xml"},
+			wantErrbuf: "\ninvalid value \"xml\" for flag -o: must be one of \"json\", \"yaml\", \"table\", or \"go-template=...\"\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Err: &errbuf}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnv_Emit_yamlScalars(t *testing.T) {
+	var outbuf bytes.Buffer
+	e := cli.Env[any]{Out: &outbuf}
+	cmd := &cli.Command[any]{
+		Name:  "root",
+		Flags: func(fs *flag.FlagSet, _ any) { cli.OutputFlag(fs, nil, "yaml") },
+		ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+			return e.Emit(map[string]any{
+				"bool":   "true",
+				"empty":  "",
+				"nested": [][]int{{1, 2}, {}},
+				"null":   nil,
+				"number": "8080",
+				"plain":  "hello world",
+				"quoted": "a: b",
+			})
+		},
+	}
+	e.Args = []string{"root"}
+	if got := cmd.Execute(t.Context(), &e); got != cli.ExitSuccess {
+		t.Fatalf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+	}
+
+	want := `bool: "true"
+empty: ""
+nested:
+  - - 1
+    - 2
+  - []
+"null": null
+number: "8080"
+plain: hello world
+quoted: "a: b"
+`
+	if diff := cmp.Diff(want, outbuf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
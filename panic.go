@@ -0,0 +1,63 @@
+package tinycli
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/debug"
+)
+
+// recoverPanic reports a panic recovered during execution of the tree rooted
+// at c, writing the panic value and a stack trace to the Env error output and
+// calling the OnPanic hook. It must be called from the deferred function that
+// recovered the panic, so that the panicking frames are still on the stack.
+func (c *Command[P]) recoverPanic(e *Env[P], v any) {
+	stack := debug.Stack()
+	if p, ok := v.(actionPanic); ok {
+		v, stack = p.value, p.stack
+	}
+	msg := fmt.Sprintf("panic: %v\n\n%s", v, trimStack(stack))
+	e.Errorf("%s", e.Redactor.Redact(msg))
+	if c.OnPanic != nil {
+		c.OnPanic(e, v)
+	}
+}
+
+// An actionPanic is a panic recovered from an action run in its own goroutine,
+// raised again by the executing goroutine along with the stack of the action's.
+type actionPanic struct {
+	value any
+	stack []byte
+}
+
+// panicValue returns the value passed to panic for a recovered value r.
+func panicValue(r any) any {
+	if p, ok := r.(actionPanic); ok {
+		return p.value
+	}
+	return r
+}
+
+// trimStack removes the frames above the function that panicked from a stack
+// trace returned by debug.Stack, keeping the goroutine header.
+func trimStack(stack []byte) []byte {
+	header, frames, ok := bytes.Cut(stack, []byte("\n"))
+	if !ok {
+		return stack
+	}
+	_, after, ok := bytes.Cut(frames, []byte("\npanic("))
+	if !ok {
+		return stack
+	}
+	// skip the rest of the panic frame and its file line
+	for range 2 {
+		if _, after, ok = bytes.Cut(after, []byte("\n")); !ok {
+			return stack
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(after)
+	return buf.Bytes()
+}
@@ -0,0 +1,97 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_recover(t *testing.T) {
+	cmdFactory := func(hook string, recovered *[]any, exits *[]cli.ExitStatus) *cli.Command[any] {
+		panicIn := func(name string) {
+			if hook == name {
+				panic("boom in " + name)
+			}
+		}
+		return &cli.Command[any]{
+			Name: "root",
+			OnPanic: func(e *cli.Env[any], v any) {
+				*recovered = append(*recovered, v)
+			},
+			OnExit: func(e *cli.Env[any], status cli.ExitStatus) {
+				*exits = append(*exits, status)
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name: "sub",
+					Flags: func(fs *flag.FlagSet, _ any) {
+						panicIn("flags")
+					},
+					After: func(e *cli.Env[any]) error {
+						panicIn("after")
+						return nil
+					},
+					Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+						panicIn("action")
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	for _, hook := range []string{"flags", "after", "action"} {
+		t.Run(hook, func(t *testing.T) {
+			var (
+				errbuf    bytes.Buffer
+				recovered []any
+				exits     []cli.ExitStatus
+			)
+			e := &cli.Env[any]{Args: []string{"root", "sub"}, Err: &errbuf}
+			if got := cmdFactory(hook, &recovered, &exits).Execute(t.Context(), e); got != cli.ExitFailure {
+				t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitFailure)
+			}
+
+			if diff := cmp.Diff([]any{"boom in " + hook}, recovered); diff != "" {
+				t.Errorf("OnPanic calls mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff([]cli.ExitStatus{cli.ExitFailure}, exits); diff != "" {
+				t.Errorf("OnExit calls mismatch (-want +got):\n%s", diff)
+			}
+
+			got := errbuf.String()
+			if want := "panic: boom in " + hook + "\n\ngoroutine "; !strings.HasPrefix(got, want) {
+				t.Errorf("error output %q does not start with %q", got, want)
+			}
+			if want := "TestCommand_Execute_recover"; !strings.Contains(got, want) {
+				t.Errorf("error output %q does not contain %q", got, want)
+			}
+			if unwanted := "runtime/debug.Stack"; strings.Contains(got, unwanted) {
+				t.Errorf("error output %q contains %q", got, unwanted)
+			}
+		})
+	}
+
+	t.Run("no_recover", func(t *testing.T) {
+		var (
+			recovered []any
+			exits     []cli.ExitStatus
+		)
+		cmd := cmdFactory("action", &recovered, &exits)
+		cmd.NoRecover = true
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("cmd.Execute() did not panic")
+			}
+			if len(recovered) != 0 {
+				t.Errorf("OnPanic called with %v, want no calls", recovered)
+			}
+		}()
+		cmd.Execute(t.Context(), &cli.Env[any]{Args: []string{"root", "sub"}})
+	})
+}
@@ -0,0 +1,84 @@
+package tinycli
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Plugins returns a Fallback action that dispatches args matching no
+// subcommand to external executables, in the style of git: "foo bar baz" runs
+// an executable named "foo-bar" found in the directories of the Env $PATH,
+// with the arg "baz". Executables of nested commands are named after the full
+// command path, as in "foo-remote-bar", with the values of [Param] commands
+// in place of their names.
+//
+// The executable runs with the Env input and output streams, and with the
+// environment returned by [Env.ChildEnv] with no options, so that vars holding
+// known secrets are not forwarded. Its exit code is returned as the status, or,
+// if it is killed by a signal, 128 plus the signal number, as by shells. When
+// no executable is found, the args are reported as an unknown command. Like
+// any Fallback, it runs with the PersistentBefore and PersistentAfter hooks,
+// Timeout, and tracing of an action.
+//
+//	c := Command[*p]{
+//		Name:     "foo",
+//		Fallback: Plugins[*p](),
+//	}
+func Plugins[P any]() ActionFunc[P] {
+	return func(ctx context.Context, e *Env[P]) ExitStatus {
+		c := e.cmds[len(e.cmds)-1]
+		path, ok := e.lookPlugin(e.Args[0])
+		if !ok {
+			return c.onUsageErr(e, errUnknownCommand, ExitFailure)
+		}
+
+		cmd := exec.CommandContext(ctx, path, e.Args[1:]...)
+		cmd.Stdin = e.In
+		cmd.Stdout = e.Out
+		cmd.Stderr = e.Err
+		cmd.Env = e.ChildEnv(ChildEnvOptions{})
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			return ExitCanceled
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if status, ok := signalStatus(exitErr.ProcessState); ok {
+				return status
+			}
+			if code := exitErr.ExitCode(); code >= 0 {
+				return ExitStatus(code)
+			}
+			return ExitFailure
+		}
+		if err != nil {
+			return c.onErr(e, err, ExitFailure)
+		}
+		return ExitSuccess
+	}
+}
+
+// lookPlugin returns the path of the plugin executable for the named
+// subcommand of the executing command, searching the directories in the Env
+// $PATH.
+func (e Env[P]) lookPlugin(name string) (string, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	file := strings.Join(append(e.pathTokens(), name), "-")
+	pathVar, _ := e.getVar("PATH")
+	for _, dir := range filepath.SplitList(pathVar) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			// relative entries would resolve against the working
+			// directory, which os/exec also refuses by default
+			continue
+		}
+		if path, err := exec.LookPath(filepath.Join(dir, file)); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
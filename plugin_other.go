@@ -0,0 +1,9 @@
+//go:build !unix
+
+package tinycli
+
+import "os"
+
+func signalStatus(state *os.ProcessState) (ExitStatus, bool) {
+	return 0, false
+}
@@ -0,0 +1,122 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$0 $*\"\necho \"greeting=$GREETING token=$TOKEN\"\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(dir, "root-sub-hello"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "root-myrepo-hello"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "root-sub-killed"), []byte("#!/bin/sh\nkill -TERM $$\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdFactory := func() *cli.Command[any] {
+		return &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			PersistentBefore: func(ctx context.Context, e *cli.Env[any]) error {
+				e.Printf("before\n")
+				return nil
+			},
+			Subcommands: []*cli.Command[any]{
+				{
+					Name:     "sub",
+					Usage:    "sub usage",
+					Fallback: cli.Plugins[any](),
+					Subcommands: []*cli.Command[any]{
+						{Name: "other"},
+					},
+				},
+				{
+					Name:     cli.Param("repo"),
+					Fallback: cli.Plugins[any](),
+					Subcommands: []*cli.Command[any]{
+						{Name: "other"},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "found",
+			args:       []string{"root", "sub", "hello", "a", "-b"},
+			wantOutbuf: "before\n" + filepath.Join(dir, "root-sub-hello") + " a -b\ngreeting=hi token=\n",
+			wantStatus: 3,
+		},
+		{
+			name:       "param",
+			args:       []string{"root", "myrepo", "hello"},
+			wantOutbuf: "before\n" + filepath.Join(dir, "root-myrepo-hello") + " \ngreeting=hi token=\n",
+			wantStatus: 3,
+		},
+		{
+			name:       "killed",
+			args:       []string{"root", "sub", "killed"},
+			wantOutbuf: "before\n",
+			wantStatus: 128 + 15, // SIGTERM
+		},
+		{
+			name:       "not_found",
+			args:       []string{"root", "sub", "goodbye"},
+			wantOutbuf: "before\n",
+			wantErrbuf: "sub usage\nunknown command\n",
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "path_name",
+			args:       []string{"root", "sub", "../root-sub-hello"},
+			wantOutbuf: "before\n",
+			wantErrbuf: "sub usage\nunknown command\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[any]{
+				Args:     tt.args,
+				Vars:     map[string]string{"PATH": dir, "GREETING": "hi", "TOKEN": "hunter2"},
+				Out:      &outbuf,
+				Err:      &errbuf,
+				Redactor: &cli.Redactor{},
+			}
+			e.Redactor.AddValue("hunter2")
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package tinycli
+
+import (
+	"os"
+	"syscall"
+)
+
+// signalStatus returns the status of a process killed by a signal, 128 plus
+// the signal number as reported by shells.
+func signalStatus(state *os.ProcessState) (ExitStatus, bool) {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return 0, false
+	}
+	return ExitStatus(128 + int(ws.Signal())), true
+}
@@ -0,0 +1,45 @@
+package tinycli
+
+import (
+	"fmt"
+	"slices"
+)
+
+// defaultPrecedence is the order of flag sources, highest first, used when no
+// visited command sets a Precedence.
+var defaultPrecedence = []Source{SourceFlag, SourceVar, SourceConfig}
+
+// precedence returns the Precedence of the nearest visited command that sets
+// one, or the default order.
+func (c *Command[P]) precedence(e *Env[P]) []Source {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.Precedence != nil {
+			return cmd.Precedence
+		}
+	}
+	return defaultPrecedence
+}
+
+// checkPrecedence returns an error if order does not list each of SourceFlag,
+// SourceVar, and SourceConfig exactly once.
+func checkPrecedence(order []Source) error {
+	if len(order) != len(defaultPrecedence) {
+		return fmt.Errorf("invalid Precedence %v: must list flag, var, and config", order)
+	}
+	for _, s := range defaultPrecedence {
+		if !slices.Contains(order, s) {
+			return fmt.Errorf("invalid Precedence %v: must list flag, var, and config", order)
+		}
+	}
+	return nil
+}
+
+// outranks reports whether a value from source s replaces a value from cur,
+// according to order. Every source outranks SourceDefault.
+func outranks(order []Source, s, cur Source) bool {
+	if cur == SourceDefault {
+		return true
+	}
+	i, j := slices.Index(order, s), slices.Index(order, cur)
+	return i >= 0 && (j < 0 || i < j)
+}
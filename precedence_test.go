@@ -0,0 +1,114 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_precedence(t *testing.T) {
+	type params struct {
+		host   string
+		port   string
+		region string
+	}
+	tests := []struct {
+		name       string
+		precedence []cli.Source
+		args       []string
+		want       params
+		wantSource map[string]cli.Source
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "default_order",
+			args:       []string{"foo", "serve", "-host", "flag.example", "-port", "1"},
+			want:       params{host: "flag.example", port: "1", region: "var-region"},
+			wantSource: map[string]cli.Source{"host": cli.SourceFlag, "port": cli.SourceFlag, "region": cli.SourceVar},
+		},
+		{
+			name:       "var_over_flag",
+			precedence: []cli.Source{cli.SourceVar, cli.SourceFlag, cli.SourceConfig},
+			args:       []string{"foo", "serve", "-host", "flag.example", "-port", "1"},
+			want:       params{host: "var.example", port: "1", region: "var-region"},
+			wantSource: map[string]cli.Source{"host": cli.SourceVar, "port": cli.SourceFlag, "region": cli.SourceVar},
+		},
+		{
+			name:       "config_over_var",
+			precedence: []cli.Source{cli.SourceFlag, cli.SourceConfig, cli.SourceVar},
+			args:       []string{"foo", "serve", "-port", "1"},
+			want:       params{host: "config.example", port: "1", region: "config-region"},
+			wantSource: map[string]cli.Source{"host": cli.SourceConfig, "port": cli.SourceFlag, "region": cli.SourceConfig},
+		},
+		{
+			name:       "config_first",
+			precedence: []cli.Source{cli.SourceConfig, cli.SourceVar, cli.SourceFlag},
+			args:       []string{"foo", "serve", "-host", "flag.example", "-port", "1"},
+			want:       params{host: "config.example", port: "1", region: "config-region"},
+			wantSource: map[string]cli.Source{"host": cli.SourceConfig, "port": cli.SourceFlag, "region": cli.SourceConfig},
+		},
+		{
+			name:       "invalid",
+			precedence: []cli.Source{cli.SourceVar, cli.SourceFlag},
+			args:       []string{"foo", "serve"},
+			wantErrbuf: "invalid Precedence [var flag]: must list flag, var, and config\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSource map[string]cli.Source
+			cmd := &cli.Command[*params]{
+				Name:        "foo",
+				UsagePolicy: cli.UsageNever,
+				Precedence:  tt.precedence,
+				Subcommands: []*cli.Command[*params]{
+					{
+						Name: "serve",
+						Flags: func(fs *flag.FlagSet, p *params) {
+							fs.StringVar(&p.host, "host", "localhost", "")
+							fs.StringVar(&p.port, "port", "80", "")
+							fs.StringVar(&p.region, "region", "us", "")
+						},
+						Vars: map[string]string{"host": "FOO_HOST", "region": "FOO_REGION"},
+						Config: func(e *cli.Env[*params]) (map[string]string, error) {
+							return map[string]string{"host": "config.example", "region": "config-region"}, nil
+						},
+						Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+							gotSource = make(map[string]cli.Source)
+							for _, name := range []string{"host", "port", "region"} {
+								gotSource[name], _ = e.Source(name)
+							}
+							return cli.ExitSuccess
+						},
+					},
+				},
+			}
+			var errbuf bytes.Buffer
+			e := &cli.Env[*params]{
+				Args:   tt.args,
+				Vars:   map[string]string{"FOO_HOST": "var.example", "FOO_REGION": "var-region"},
+				Err:    &errbuf,
+				Params: &params{},
+			}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.want, *e.Params, cmp.AllowUnexported(params{})); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantSource, gotSource); diff != "" {
+				t.Errorf("sources mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package tinycli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Prompt writes msg to the Env error output stream and returns a line read
+// from the Env input stream, without its line ending.
+//
+// When the Env has a [PromptLog], the interaction is recorded to it, or, if
+// the log is replaying, the recorded answer is returned without reading
+// input.
+func (e *Env[P]) Prompt(msg string) (string, error) {
+	if e.Prompts != nil && e.Prompts.Replay {
+		return e.Prompts.answer(msg)
+	}
+
+	e.Errorf("%s", msg)
+	answer, err := e.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if e.Prompts != nil {
+		e.Prompts.Records = append(e.Prompts.Records, PromptRecord{Prompt: msg, Answer: answer})
+	}
+	return answer, nil
+}
+
+// Confirm asks a yes or no question with [Env.Prompt], appending a hint that
+// shows the default to msg. It accepts y, yes, n, and no in any case, returns
+// def for an empty answer, and asks again after any other answer.
+func (e *Env[P]) Confirm(msg string, def bool) (bool, error) {
+	hint := " [y/N] "
+	if def {
+		hint = " [Y/n] "
+	}
+	for {
+		answer, err := e.Prompt(msg + hint)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		e.Errorf("please answer y or n\n")
+	}
+}
+
+// PromptSecret is like [Env.Prompt], but turns off echo while reading when In
+// is a terminal, so that the answer is not displayed. Input that is not a
+// terminal is read as is.
+//
+// The answer is registered with the Env Redactor. When the Env [PromptLog] is
+// replaying, the recorded answer is returned without reading input, as by
+// Prompt, but answers read from input are not recorded, so that secrets are
+// not written to the log; replayed logs must have them added by hand.
+func (e *Env[P]) PromptSecret(msg string) (string, error) {
+	if e.Prompts != nil && e.Prompts.Replay {
+		answer, err := e.Prompts.answer(msg)
+		if err != nil {
+			return "", err
+		}
+		e.redactor().AddValue(answer)
+		return answer, nil
+	}
+
+	e.Errorf("%s", msg)
+	if f, ok := e.In.(*os.File); ok && isTerminal(f) {
+		if restore, err := disableEcho(f); err == nil {
+			defer func() {
+				restore()
+				// the line ending typed by the user was not echoed either
+				e.Errorf("\n")
+			}()
+		}
+	}
+	answer, err := e.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	e.redactor().AddValue(answer)
+	return answer, nil
+}
+
+// promptRequired asks for the value of each Required flag of c that is still
+// unset, asking again while an answer is invalid. Empty answers leave the flag
+// unset, to be reported by checkRequired.
+func (c *invocation[P]) promptRequired(e *Env[P]) error {
+	for _, name := range c.Required {
+		m, ok := c.getMeta(name)
+		if !ok || m.valueSource != SourceDefault {
+			continue
+		}
+		msg := name + ": "
+		if usage := c.flagSet().Lookup(name).Usage; usage != "" {
+			msg = fmt.Sprintf("%s (%s): ", name, usage)
+		}
+		for {
+			var (
+				answer string
+				err    error
+			)
+			if m.isSecret {
+				answer, err = e.PromptSecret(msg)
+			} else {
+				answer, err = e.Prompt(msg)
+			}
+			if err != nil {
+				return fmt.Errorf("prompting for -%s: %w", name, err)
+			}
+			if answer == "" {
+				break
+			}
+			if err := c.setFlag(name, answer); err != nil {
+				e.Errorf("%s\n", &decoratedValueError{
+					rawValue: answer,
+					flagName: name,
+					source:   SourcePrompt,
+					isBool:   m.isBool,
+					isSecret: m.isSecret,
+					err:      err,
+				})
+				continue
+			}
+			m.value = answer
+			m.valueSource = SourcePrompt
+			break
+		}
+	}
+	return nil
+}
+
+// A PromptRecord is a question asked by a prompt and the answer given to it.
+type PromptRecord struct {
+	Prompt string `json:"prompt"` // prompt message
+	Answer string `json:"answer"` // answer given
+}
+
+// A PromptLog records prompt interactions, or replays previously recorded
+// answers so that interactive flows can run non-interactively.
+//
+// A PromptLog implements [flag.Value]: setting it to a file path loads the
+// records in that file and enables replay, so it can back an -answers flag.
+type PromptLog struct {
+	Records []PromptRecord // interactions, in order
+	Replay  bool           // whether prompts are answered from Records
+
+	path string
+	next int
+}
+
+// ReadPromptLog reads a replaying PromptLog from the JSON records in r.
+func ReadPromptLog(r io.Reader) (*PromptLog, error) {
+	var records []PromptRecord
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("reading prompt log: %w", err)
+	}
+	return &PromptLog{Records: records, Replay: true}, nil
+}
+
+// WriteTo writes the log records to w as JSON.
+func (l *PromptLog) WriteTo(w io.Writer) (int64, error) {
+	b, err := json.MarshalIndent(l.Records, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// String returns the path of the file the log was loaded from, if any.
+func (l *PromptLog) String() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Set loads the records in the file at path and enables replay.
+func (l *PromptLog) Set(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	loaded, err := ReadPromptLog(f)
+	if err != nil {
+		return err
+	}
+	*l = *loaded
+	l.path = path
+	return nil
+}
+
+func (l *PromptLog) answer(prompt string) (string, error) {
+	if l.next >= len(l.Records) {
+		return "", fmt.Errorf("no recorded answer for prompt %q", prompt)
+	}
+	r := l.Records[l.next]
+	if r.Prompt != prompt {
+		return "", fmt.Errorf("prompt %q does not match recorded prompt %q", prompt, r.Prompt)
+	}
+	l.next++
+	return r.Answer, nil
+}
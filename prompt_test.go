@@ -0,0 +1,289 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_Prompt(t *testing.T) {
+	var errbuf bytes.Buffer
+	e := cli.Env[any]{
+		In:  strings.NewReader("gopher\r\nblue\nleftover"),
+		Err: &errbuf,
+	}
+
+	for _, want := range []string{"gopher", "blue", "leftover"} {
+		got, err := e.Prompt("? ")
+		if err != nil {
+			t.Fatalf("env.Prompt() error: %v", err)
+		}
+		if want != got {
+			t.Errorf("env.Prompt()=%q, want %q", got, want)
+		}
+	}
+	if want, got := "? ? ? ", errbuf.String(); want != got {
+		t.Errorf("env.Prompt() wrote %q, want %q", got, want)
+	}
+	if _, err := e.Prompt("? "); err == nil {
+		t.Errorf("env.Prompt() at EOF returned nil error")
+	}
+}
+
+func TestPromptLog(t *testing.T) {
+	log := &cli.PromptLog{}
+	e := cli.Env[any]{
+		In:      strings.NewReader("gopher\nblue\n"),
+		Prompts: log,
+	}
+	e.Prompt("name? ")
+	e.Prompt("color? ")
+
+	wantRecords := []cli.PromptRecord{
+		{Prompt: "name? ", Answer: "gopher"},
+		{Prompt: "color? ", Answer: "blue"},
+	}
+	if diff := cmp.Diff(wantRecords, log.Records); diff != "" {
+		t.Fatalf("recorded prompts mismatch (-want +got):\n%s", diff)
+	}
+
+	path := filepath.Join(t.TempDir(), "answers.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.WriteTo(f); err != nil {
+		t.Fatalf("log.WriteTo() error: %v", err)
+	}
+	f.Close()
+
+	var replay cli.PromptLog
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&replay, "answers", "")
+	if err := fs.Parse([]string{"-answers", path}); err != nil {
+		t.Fatalf("fs.Parse() error: %v", err)
+	}
+
+	var errbuf bytes.Buffer
+	e = cli.Env[any]{Err: &errbuf, Prompts: &replay}
+	for _, r := range wantRecords {
+		got, err := e.Prompt(r.Prompt)
+		if err != nil {
+			t.Fatalf("replayed env.Prompt(%q) error: %v", r.Prompt, err)
+		}
+		if want := r.Answer; want != got {
+			t.Errorf("replayed env.Prompt(%q)=%q, want %q", r.Prompt, got, want)
+		}
+	}
+	if errbuf.Len() != 0 {
+		t.Errorf("replayed env.Prompt() wrote %q, want nothing", errbuf.String())
+	}
+	if _, err := e.Prompt("extra? "); err == nil {
+		t.Errorf("replayed env.Prompt() past end of log returned nil error")
+	}
+
+	mismatch, err := cli.ReadPromptLog(strings.NewReader(`[{"prompt":"name? ","answer":"gopher"}]`))
+	if err != nil {
+		t.Fatalf("ReadPromptLog() error: %v", err)
+	}
+	e = cli.Env[any]{Prompts: mismatch}
+	if _, err := e.Prompt("color? "); err == nil {
+		t.Errorf("replayed env.Prompt() with mismatched prompt returned nil error")
+	}
+}
+
+func TestEnv_Confirm(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		def        bool
+		want       bool
+		wantErrbuf string
+	}{
+		{name: "yes", input: "y\n", want: true, wantErrbuf: "ok? [y/N] "},
+		{name: "no", input: "No\n", def: true, want: false, wantErrbuf: "ok? [Y/n] "},
+		{name: "default_true", input: "\n", def: true, want: true, wantErrbuf: "ok? [Y/n] "},
+		{name: "default_false", input: "\n", want: false, wantErrbuf: "ok? [y/N] "},
+		{name: "retry", input: "maybe\nYES\n", want: true, wantErrbuf: "ok? [y/N] please answer y or n\nok? [y/N] "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := cli.Env[any]{In: strings.NewReader(tt.input), Err: &errbuf}
+			got, err := e.Confirm("ok?", tt.def)
+			if err != nil {
+				t.Fatalf("env.Confirm() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("env.Confirm()=%t, want %t", got, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	e := cli.Env[any]{In: strings.NewReader("")}
+	if _, err := e.Confirm("ok?", true); err == nil {
+		t.Errorf("env.Confirm() at EOF returned nil error")
+	}
+}
+
+func TestEnv_PromptSecret(t *testing.T) {
+	var errbuf bytes.Buffer
+	log := &cli.PromptLog{}
+	e := cli.Env[any]{
+		In:      strings.NewReader("hunter2\n"),
+		Err:     &errbuf,
+		Prompts: log,
+	}
+
+	got, err := e.PromptSecret("password: ")
+	if err != nil {
+		t.Fatalf("env.PromptSecret() error: %v", err)
+	}
+	if want := "hunter2"; want != got {
+		t.Errorf("env.PromptSecret()=%q, want %q", got, want)
+	}
+	if want, got := "password: ", errbuf.String(); want != got {
+		t.Errorf("env.PromptSecret() wrote %q, want %q", got, want)
+	}
+	if len(log.Records) != 0 {
+		t.Errorf("env.PromptSecret() recorded %v, want no records", log.Records)
+	}
+	if want, got := "token=****", e.Redactor.Redact("token=hunter2"); want != got {
+		t.Errorf("Redact() after env.PromptSecret()=%q, want %q", got, want)
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		var errbuf bytes.Buffer
+		e := cli.Env[any]{
+			In:  strings.NewReader("typed\n"),
+			Err: &errbuf,
+			Prompts: &cli.PromptLog{
+				Records: []cli.PromptRecord{{Prompt: "password: ", Answer: "s3cr3t"}},
+				Replay:  true,
+			},
+		}
+
+		got, err := e.PromptSecret("password: ")
+		if err != nil {
+			t.Fatalf("env.PromptSecret() error: %v", err)
+		}
+		if want := "s3cr3t"; want != got {
+			t.Errorf("env.PromptSecret()=%q, want %q", got, want)
+		}
+		if errbuf.Len() > 0 {
+			t.Errorf("env.PromptSecret() wrote %q, want nothing", errbuf.String())
+		}
+		if want, got := "token=****", e.Redactor.Redact("token=s3cr3t"); want != got {
+			t.Errorf("Redact() after env.PromptSecret()=%q, want %q", got, want)
+		}
+	})
+}
+
+func TestCommand_Execute_promptRequired(t *testing.T) {
+	type p struct {
+		User     string
+		Password string
+		Port     int
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.User, "user", "", "user name")
+				fs.StringVar(&p.Password, "password", "", "")
+				fs.IntVar(&p.Port, "port", 0, "")
+			},
+			VarPrefix:      "FOO_",
+			Required:       []string{"user", "password", "port"},
+			Secrets:        []string{"password"},
+			PromptRequired: true,
+			Action:         func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		input      string
+		wantParams p
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "prompted",
+			args:       []string{"root", "-interactive"},
+			input:      "alice\nhunter2\n8080\n",
+			wantParams: p{User: "alice", Password: "hunter2", Port: 8080},
+			wantErrbuf: "user (user name): password: port: ",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "partly_set",
+			args:       []string{"root", "-interactive", "-port=8080"},
+			vars:       map[string]string{"FOO_USER": "alice"},
+			input:      "hunter2\n",
+			wantParams: p{User: "alice", Password: "hunter2", Port: 8080},
+			wantErrbuf: "password: ",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "invalid_answer",
+			args:       []string{"root", "-interactive", "-user=alice", "-password=hunter2"},
+			input:      "http\n8080\n",
+			wantParams: p{User: "alice", Password: "hunter2", Port: 8080},
+			wantErrbuf: "port: invalid value \"http\" for -port: parse error\nport: ",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "empty_answer",
+			args:       []string{"root", "-interactive", "-user=alice", "-password=hunter2"},
+			input:      "\n",
+			wantParams: p{User: "alice", Password: "hunter2"},
+			wantErrbuf: "port: root usage\nmissing: -port (or $FOO_PORT)\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "not_interactive",
+			args:       []string{"root", "-user=alice", "-password=hunter2"},
+			input:      "8080\n",
+			wantParams: p{User: "alice", Password: "hunter2"},
+			wantErrbuf: "root usage\nmissing: -port (or $FOO_PORT)\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[*p]{
+				In:     strings.NewReader(tt.input),
+				Err:    &errbuf,
+				Args:   tt.args,
+				Vars:   tt.vars,
+				Params: &p{},
+			}
+			if got := cmdFactory().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantParams, *e.Params); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
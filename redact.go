@@ -0,0 +1,78 @@
+package tinycli
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// redacted replaces secret values in output.
+const redacted = "****"
+
+// minRedactLen is the length of the shortest literal value a Redactor
+// replaces, since shorter values such as "1" or "on" occur throughout output.
+const minRedactLen = 4
+
+// A Redactor replaces secret values in text.
+//
+// Execute registers the values of a Command's Secrets flags with the Env
+// Redactor, and redacts error output written by the framework. The zero value
+// is an empty Redactor ready to use.
+type Redactor struct {
+	values   []string
+	patterns []*regexp.Regexp
+}
+
+// AddValue registers a literal secret value. Values shorter than 4 bytes are
+// ignored, since they cannot be redacted without mangling unrelated text.
+func (r *Redactor) AddValue(value string) {
+	if len(value) < minRedactLen || slices.Contains(r.values, value) {
+		return
+	}
+	r.values = append(r.values, value)
+	// replace longer values first, so that a secret containing another
+	// secret is not partially revealed
+	slices.SortFunc(r.values, func(a, b string) int { return len(b) - len(a) })
+}
+
+// AddPattern registers a pattern matching secret values.
+func (r *Redactor) AddPattern(re *regexp.Regexp) {
+	r.patterns = append(r.patterns, re)
+}
+
+// Redact returns s with every registered secret value replaced. A nil
+// Redactor returns s unchanged.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, redacted)
+	}
+	return s
+}
+
+// redactor returns the Env Redactor, creating it if needed.
+func (e *Env[P]) redactor() *Redactor {
+	if e.Redactor == nil {
+		e.Redactor = &Redactor{}
+	}
+	return e.Redactor
+}
+
+// addSecretArgs registers the values of secret flags given in args, so that
+// errors reported by the flag package do not reveal them.
+func (c *invocation[P]) addSecretArgs(e *Env[P], args []string) {
+	if len(c.Secrets) == 0 {
+		return
+	}
+	visitFlagArgs(c.flagSet(), args, func(name, value string) bool {
+		if slices.Contains(c.Secrets, name) {
+			e.redactor().AddValue(value)
+		}
+		return true
+	})
+}
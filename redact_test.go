@@ -0,0 +1,241 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestRedactor(t *testing.T) {
+	var r cli.Redactor
+	r.AddValue("")
+	r.AddValue("ab1")
+	r.AddValue("abcd")
+	r.AddValue("abcdef")
+	r.AddPattern(regexp.MustCompile(`ghp_[a-zA-Z0-9]+`))
+
+	in := "a=abcdef b=abcd c=ghp_XyZ123 d=ab1"
+	want := "a=**** b=**** c=**** d=ab1"
+	if got := r.Redact(in); want != got {
+		t.Errorf("r.Redact(%q)=%q, want %q", in, got, want)
+	}
+
+	var nilRedactor *cli.Redactor
+	if got := nilRedactor.Redact(in); in != got {
+		t.Errorf("nil Redactor.Redact(%q)=%q, want unchanged", in, got)
+	}
+}
+
+func TestCommand_Execute_secrets(t *testing.T) {
+	type p struct {
+		Token   int
+		Region  string
+		Message string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.IntVar(&p.Token, "token", 0, "")
+				fs.StringVar(&p.Region, "region", "", "")
+			},
+			Vars: map[string]string{
+				"token": "TOKEN",
+			},
+			Secrets: []string{"token"},
+			After: func(e *cli.Env[*p]) error {
+				switch e.Params.Region {
+				case "value_err":
+					return &cli.ValueError{Name: "token", Err: errCustomTest}
+				case "generic_err":
+					return fmt.Errorf("token %d rejected", e.Params.Token)
+				}
+				return nil
+			},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				return cli.ExitSuccess
+			},
+		}
+	}
+
+	tests := []tc[*p]{
+		{
+			name: "invalid_flag",
+			args: []string{"root", "-token", "s3cr3t"},
+
+			wantErrbuf: "root usage\ninvalid value \"****\" for flag -token: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name: "invalid_var",
+			args: []string{"root"},
+			vars: map[string]string{"TOKEN": "s3cr3t"},
+
+			wantErrbuf: "root usage\ninvalid value \"****\" for var $TOKEN: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name: "after_value_err",
+			args: []string{"root", "-region=value_err"},
+			vars: map[string]string{"TOKEN": "12345"},
+
+			wantErrbuf: "root usage\ninvalid value \"****\" for var $TOKEN: custom test error\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name: "after_generic_err",
+			args: []string{"root", "-token=12345", "-region=generic_err"},
+
+			wantErrbuf: "root usage\ntoken **** rejected\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var params p
+			_, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(), &params, tt)
+
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("%s: cmd.Execute()=%v, want %v", tt.name, got, want)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("%s: cmd.Execute err buffer mismatch (-want +got):\n%s", tt.name, diff)
+			}
+		})
+	}
+
+	t.Run("spec_default", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, _ any) {
+				fs.String("token", "s3cr3t", "")
+			},
+			Secrets: []string{"token"},
+		}
+		cmd.Execute(t.Context(), &cli.Env[any]{Out: &outbuf, Args: []string{"root", "-help=json"}})
+
+		if got := outbuf.String(); strings.Contains(got, "s3cr3t") || !strings.Contains(got, `"default": "****"`) {
+			t.Errorf("cmd.Execute spec output %q does not redact the secret default", got)
+		}
+	})
+
+	t.Run("registered_pattern", func(t *testing.T) {
+		var errbuf bytes.Buffer
+		r := &cli.Redactor{}
+		r.AddPattern(regexp.MustCompile(`key-[0-9]+`))
+		cmd := &cli.Command[any]{
+			Name:  "root",
+			Usage: "root usage",
+			After: func(e *cli.Env[any]) error {
+				return fmt.Errorf("bad key-42")
+			},
+		}
+		cmd.Execute(t.Context(), &cli.Env[any]{Err: &errbuf, Args: []string{"root"}, Redactor: r})
+
+		if diff := cmp.Diff("root usage\nbad ****\n", errbuf.String()); diff != "" {
+			t.Errorf("cmd.Execute err buffer mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestCommand_Execute_secretFiles(t *testing.T) {
+	type p struct {
+		Password string
+		User     string
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "password")
+	if err := os.WriteFile(path, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdFactory := func(secretFiles bool) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.StringVar(&p.Password, "password", "", "")
+				fs.StringVar(&p.User, "user", "", "")
+			},
+			VarPrefix:   "FOO_",
+			Secrets:     []string{"password"},
+			SecretFiles: secretFiles,
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+				return cli.ExitSuccess
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		secretFiles bool
+		vars        map[string]string
+		wantParams  *p
+		wantErrbuf  string
+		wantStatus  cli.ExitStatus
+	}{
+		{
+			name:        "file",
+			secretFiles: true,
+			vars:        map[string]string{"FOO_PASSWORD_FILE": path, "FOO_USER_FILE": path},
+			wantParams:  &p{Password: "s3cr3t"},
+			wantStatus:  cli.ExitSuccess,
+		},
+		{
+			name:        "var_precedence",
+			secretFiles: true,
+			vars:        map[string]string{"FOO_PASSWORD": "hunter2", "FOO_PASSWORD_FILE": path},
+			wantParams:  &p{Password: "hunter2"},
+			wantStatus:  cli.ExitSuccess,
+		},
+		{
+			name:       "not_opted_in",
+			vars:       map[string]string{"FOO_PASSWORD_FILE": path},
+			wantParams: &p{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:        "missing_file",
+			secretFiles: true,
+			vars:        map[string]string{"FOO_PASSWORD_FILE": filepath.Join(dir, "missing")},
+			wantParams:  &p{},
+			wantErrbuf:  "root usage\nreading $FOO_PASSWORD_FILE: open " + filepath.Join(dir, "missing") + ": no such file or directory\n",
+			wantStatus:  cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(tt.secretFiles), &p{}, tc[*p]{
+				args: []string{"root"},
+				vars: tt.vars,
+			})
+
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("cmd.Execute()=%v, want %v", got, want)
+			}
+			if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("cmd.Execute err buffer mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
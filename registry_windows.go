@@ -0,0 +1,185 @@
+package tinycli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+const errorNoMoreItems syscall.Errno = 259
+
+var (
+	procRegEnumValueW             = syscall.NewLazyDLL("advapi32.dll").NewProc("RegEnumValueW")
+	procExpandEnvironmentStringsW = syscall.NewLazyDLL("kernel32.dll").NewProc("ExpandEnvironmentStringsW")
+)
+
+// RegistryConfig returns a [ConfigFunc] that reads flag values from the values
+// of Windows registry keys, such as `HKLM\Software\Policies\Foo`.
+//
+// Keys are read in order, and a value in an earlier key takes precedence over
+// the same value in a later one, so machine policy keys are usually listed
+// before per-user keys. Keys that do not exist are skipped. String, expandable
+// string, and integer values are supported; references to environment
+// variables in expandable strings, such as %USERPROFILE%, are expanded, and
+// multi-string values are joined with commas.
+func RegistryConfig[P any](keys ...string) ConfigFunc[P] {
+	return func(*Env[P]) (map[string]string, error) {
+		config := make(map[string]string)
+		for _, key := range keys {
+			values, err := readRegistryKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("reading registry key %s: %w", key, err)
+			}
+			for name, value := range values {
+				if _, exists := config[name]; !exists {
+					config[name] = value
+				}
+			}
+		}
+		return config, nil
+	}
+}
+
+func splitRegistryPath(path string) (syscall.Handle, string, error) {
+	rootName, subkey, _ := strings.Cut(path, `\`)
+	switch strings.ToUpper(rootName) {
+	case "HKCU", "HKEY_CURRENT_USER":
+		return syscall.HKEY_CURRENT_USER, subkey, nil
+	case "HKLM", "HKEY_LOCAL_MACHINE":
+		return syscall.HKEY_LOCAL_MACHINE, subkey, nil
+	case "HKCR", "HKEY_CLASSES_ROOT":
+		return syscall.HKEY_CLASSES_ROOT, subkey, nil
+	case "HKU", "HKEY_USERS":
+		return syscall.HKEY_USERS, subkey, nil
+	}
+	return 0, "", fmt.Errorf("unknown root key %q", rootName)
+}
+
+func readRegistryKey(path string) (map[string]string, error) {
+	root, subkey, err := splitRegistryPath(path)
+	if err != nil {
+		return nil, err
+	}
+	subkeyPtr, err := syscall.UTF16PtrFromString(subkey)
+	if err != nil {
+		return nil, err
+	}
+
+	var h syscall.Handle
+	if err := syscall.RegOpenKeyEx(root, subkeyPtr, 0, syscall.KEY_READ, &h); err != nil {
+		if errors.Is(err, syscall.ERROR_FILE_NOT_FOUND) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer syscall.RegCloseKey(h)
+
+	values := make(map[string]string)
+	name := make([]uint16, 16384) // maximum value name length
+	for i := uint32(0); ; i++ {
+		nameLen := uint32(len(name))
+		r, _, _ := procRegEnumValueW.Call(
+			uintptr(h),
+			uintptr(i),
+			uintptr(unsafe.Pointer(&name[0])),
+			uintptr(unsafe.Pointer(&nameLen)),
+			0, 0, 0, 0,
+		)
+		if errno := syscall.Errno(r); errno == errorNoMoreItems {
+			break
+		} else if errno != 0 {
+			return nil, errno
+		}
+
+		valueName := syscall.UTF16ToString(name[:nameLen])
+		value, ok, err := queryRegistryValue(h, &name[0])
+		if err != nil {
+			return nil, fmt.Errorf("value %s: %w", valueName, err)
+		}
+		if ok {
+			values[valueName] = value
+		}
+	}
+	return values, nil
+}
+
+func queryRegistryValue(h syscall.Handle, name *uint16) (value string, ok bool, err error) {
+	var typ, size uint32
+	if err := syscall.RegQueryValueEx(h, name, nil, &typ, nil, &size); err != nil {
+		return "", false, err
+	}
+	buf := make([]byte, size+2) // room for a missing string terminator
+	if err := syscall.RegQueryValueEx(h, name, nil, &typ, &buf[0], &size); err != nil {
+		return "", false, err
+	}
+	buf = buf[:size]
+
+	switch typ {
+	case syscall.REG_SZ:
+		return utf16BytesToString(buf), true, nil
+	case syscall.REG_EXPAND_SZ:
+		value, err := expandEnvironmentStrings(utf16BytesToString(buf))
+		return value, err == nil, err
+	case syscall.REG_MULTI_SZ:
+		var parts []string
+		for _, part := range strings.Split(utf16BytesToString(buf), "\x00") {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, ","), true, nil
+	case syscall.REG_DWORD:
+		if len(buf) < 4 {
+			return "", false, errors.New("short DWORD value")
+		}
+		return strconv.FormatUint(uint64(*(*uint32)(unsafe.Pointer(&buf[0]))), 10), true, nil
+	case syscall.REG_QWORD:
+		if len(buf) < 8 {
+			return "", false, errors.New("short QWORD value")
+		}
+		return strconv.FormatUint(*(*uint64)(unsafe.Pointer(&buf[0])), 10), true, nil
+	}
+	return "", false, nil
+}
+
+// expandEnvironmentStrings replaces the %NAME% references to environment
+// variables in s with their values, as for REG_EXPAND_SZ registry values.
+func expandEnvironmentStrings(s string) (string, error) {
+	src, err := syscall.UTF16PtrFromString(s)
+	if err != nil {
+		return "", err
+	}
+	n := uint32(len(s) + 1)
+	for {
+		buf := make([]uint16, n)
+		r, _, err := procExpandEnvironmentStringsW.Call(
+			uintptr(unsafe.Pointer(src)),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(n),
+		)
+		if r == 0 {
+			return "", err
+		}
+		if uint32(r) <= n {
+			return syscall.UTF16ToString(buf[:r]), nil
+		}
+		n = uint32(r) // buffer too small; r is the size needed
+	}
+}
+
+// utf16BytesToString decodes little-endian UTF-16 data up to its final
+// terminator, preserving embedded terminators.
+func utf16BytesToString(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+	}
+	for len(u) > 0 && u[len(u)-1] == 0 {
+		u = u[:len(u)-1]
+	}
+	return string(utf16.Decode(u))
+}
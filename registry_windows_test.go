@@ -0,0 +1,23 @@
+package tinycli_test
+
+import (
+	"testing"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestRegistryConfig(t *testing.T) {
+	config := cli.RegistryConfig[any](`HKCU\Software\tinycli-test-missing`)
+	got, err := config(&cli.Env[any]{})
+	if err != nil {
+		t.Fatalf("RegistryConfig() error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("RegistryConfig() for missing key=%v, want empty", got)
+	}
+
+	config = cli.RegistryConfig[any](`HKXX\Software`)
+	if _, err := config(&cli.Env[any]{}); err == nil {
+		t.Errorf("RegistryConfig() with unknown root key returned nil error")
+	}
+}
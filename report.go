@@ -0,0 +1,40 @@
+package tinycli
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// A Report describes an execution, for collecting usage metrics. It holds
+// flag names, but never their values.
+type Report struct {
+	Path     []string      // names of the visited commands, from the root
+	Flags    []string      // sorted names of the flags set on the command line
+	Status   ExitStatus    // resulting exit status
+	Start    time.Time     // start of the execution
+	Duration time.Duration // duration of the execution
+}
+
+// A ReportFunc is a hook called with the report of an execution.
+type ReportFunc[P any] = func(*Env[P], Report)
+
+// newReport returns the report of the execution of e that started at start
+// and resulted in status.
+func newReport[P any](e *Env[P], start time.Time, status ExitStatus) Report {
+	flags := make(map[string]bool)
+	for _, c := range e.cmds {
+		for name, m := range c.meta {
+			if m.valueSource == SourceFlag {
+				flags[name] = true
+			}
+		}
+	}
+	return Report{
+		Path:     e.CommandPath(),
+		Flags:    slices.Sorted(maps.Keys(flags)),
+		Status:   status,
+		Start:    start,
+		Duration: time.Since(start),
+	}
+}
@@ -0,0 +1,99 @@
+package tinycli_test
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_onReport(t *testing.T) {
+	type params struct {
+		verbose bool
+		port    int
+		token   string
+	}
+	cmdFactory := func(reports *[]cli.Report) *cli.Command[*params] {
+		return &cli.Command[*params]{
+			Name: "foo",
+			Flags: func(fs *flag.FlagSet, p *params) {
+				fs.BoolVar(&p.verbose, "v", false, "verbose output")
+			},
+			OnReport: func(e *cli.Env[*params], r cli.Report) {
+				*reports = append(*reports, r)
+			},
+			Subcommands: []*cli.Command[*params]{
+				{
+					Name: "serve",
+					Flags: func(fs *flag.FlagSet, p *params) {
+						fs.IntVar(&p.port, "port", 8080, "port")
+						fs.StringVar(&p.token, "token", "", "api token")
+					},
+					Vars: map[string]string{"token": "FOO_TOKEN"},
+					Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+						if e.Params.port == 0 {
+							panic("no port")
+						}
+						return cli.ExitUnavailable
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want cli.Report
+	}{
+		{
+			name: "action",
+			args: []string{"foo", "-v", "serve", "-port", "80", "-token=s3cret"},
+			want: cli.Report{Path: []string{"foo", "serve"}, Flags: []string{"port", "token", "v"}, Status: cli.ExitUnavailable},
+		},
+		{
+			name: "usage_error",
+			args: []string{"foo", "serve", "-port", "x"},
+			want: cli.Report{Path: []string{"foo", "serve"}, Status: cli.ExitUsage},
+		},
+		{
+			name: "panic",
+			args: []string{"foo", "serve", "-port", "0"},
+			want: cli.Report{Path: []string{"foo", "serve"}, Flags: []string{"port"}, Status: cli.ExitFailure},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []cli.Report
+			e := &cli.Env[*params]{
+				Args:   tt.args,
+				Vars:   map[string]string{"FOO_TOKEN": "from-env"},
+				Params: &params{},
+			}
+			cmdFactory(&reports).Execute(t.Context(), e)
+			if len(reports) != 1 {
+				t.Fatalf("OnReport called %d times, want 1", len(reports))
+			}
+			got := reports[0]
+			if got.Start.IsZero() || got.Duration <= 0 {
+				t.Errorf("report timing = (%v, %v), want non-zero", got.Start, got.Duration)
+			}
+			got.Start, got.Duration = tt.want.Start, tt.want.Duration
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("report mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	var reports []cli.Report
+	e := &cli.Env[*params]{Args: []string{"foo", "serve"}, Params: &params{}}
+	if _, err := cmdFactory(&reports).Resolve(e); err != nil {
+		t.Fatalf("cmd.Resolve() error: %v", err)
+	}
+	if len(reports) != 0 {
+		t.Errorf("cmd.Resolve() called OnReport %d times, want 0", len(reports))
+	}
+}
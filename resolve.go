@@ -0,0 +1,87 @@
+package tinycli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var errUnresolved = errors.New("command line could not be resolved")
+
+// A Resolution is the result of resolving a command line with
+// [Command.Resolve].
+type Resolution[P any] struct {
+	Command *Command[P]    // selected command
+	Path    []string       // names of the visited commands, from the root
+	Args    []string       // remaining positional args
+	Flags   []ResolvedFlag // flags of the visited commands
+}
+
+// A ResolvedFlag is the resolved value of a flag of a [Resolution].
+type ResolvedFlag struct {
+	Command string // path of the command defining the flag, such as "foo serve"
+	Name    string // flag name
+	Value   string // resolved value, not redacted
+	Typed   any    // resolved value from the flag's [flag.Getter], or nil
+	Source  Source // source of the value
+	VarName string // name of the env var the value is from, for SourceVar
+	Secret  bool   // whether the flag is listed in the command's Secrets
+}
+
+// Resolve resolves the command line e.Args as Execute would, selecting a
+// command and resolving its flags and those of its ancestors, without calling
+// an action, Fallback, or PersistentBefore hook. Since nothing runs, the
+// Defer, OnExit, and OnReport hooks are not called either. Hooks that resolve
+// flags, such as Before, After, and Config, are called, and the parameters in
+// e.Params hold the resolved values.
+//
+// Errors that Execute would report are returned instead, as an [*ExitError]
+// holding the status. Requests for help or the version are written to the Env
+// standard output stream as by Execute, and result in [flag.ErrHelp].
+func (c *Command[P]) Resolve(e *Env[P]) (*Resolution[P], error) {
+	var err error
+	re := *e
+	re.resolveOnly = true
+	re.errs = &err
+	status := c.Execute(context.Background(), &re)
+	if err != nil {
+		return nil, err
+	}
+	if status != ExitSuccess {
+		// failures not reported as errors, such as panics
+		return nil, &ExitError{Status: status, Err: errUnresolved}
+	}
+	if !re.resolved {
+		return nil, flag.ErrHelp
+	}
+
+	r := &Resolution[P]{
+		Command: re.Command(),
+		Path:    re.CommandPath(),
+		Args:    re.Args,
+	}
+	for i, inv := range re.cmds {
+		path := strings.Join(r.Path[:i+1], " ")
+		for _, k := range slices.Sorted(maps.Keys(inv.meta)) {
+			m := inv.meta[k]
+			v := inv.flagSet().Lookup(k).Value
+			var typed any
+			if g, ok := v.(flag.Getter); ok {
+				typed = g.Get()
+			}
+			r.Flags = append(r.Flags, ResolvedFlag{
+				Command: path,
+				Name:    k,
+				Value:   v.String(),
+				Typed:   typed,
+				Source:  m.valueSource,
+				VarName: m.varName,
+				Secret:  m.isSecret,
+			})
+		}
+	}
+	return r, nil
+}
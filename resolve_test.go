@@ -0,0 +1,137 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Resolve(t *testing.T) {
+	type params struct {
+		verbose bool
+		port    int
+		token   string
+	}
+	cmdFactory := func(ran *bool) *cli.Command[*params] {
+		action := func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+			*ran = true
+			return cli.ExitSuccess
+		}
+		return &cli.Command[*params]{
+			Name:  "foo",
+			Usage: "foo usage",
+			Flags: func(fs *flag.FlagSet, p *params) {
+				fs.BoolVar(&p.verbose, "v", false, "verbose output")
+			},
+			PersistentBefore: func(ctx context.Context, e *cli.Env[*params]) error {
+				*ran = true
+				return nil
+			},
+			Defer: func(e *cli.Env[*params], status cli.ExitStatus) {
+				*ran = true
+			},
+			OnExit: func(e *cli.Env[*params], status cli.ExitStatus) {
+				*ran = true
+			},
+			Subcommands: []*cli.Command[*params]{
+				{
+					Name:  "serve",
+					Usage: "serve usage",
+					Flags: func(fs *flag.FlagSet, p *params) {
+						fs.IntVar(&p.port, "port", 8080, "port")
+						fs.StringVar(&p.token, "token", "", "api token")
+					},
+					Vars:    map[string]string{"token": "FOO_TOKEN"},
+					Secrets: []string{"token"},
+					Action:  action,
+					Defer: func(e *cli.Env[*params], status cli.ExitStatus) {
+						*ran = true
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("resolved", func(t *testing.T) {
+		var (
+			errbuf bytes.Buffer
+			ran    bool
+		)
+		p := &params{}
+		e := &cli.Env[*params]{
+			Args:   []string{"foo", "-v", "serve", "-port", "80", "file"},
+			Vars:   map[string]string{"FOO_TOKEN": "s3cret"},
+			Err:    &errbuf,
+			Params: p,
+		}
+		cmd := cmdFactory(&ran)
+		r, err := cmd.Resolve(e)
+		if err != nil {
+			t.Fatalf("cmd.Resolve() error: %v", err)
+		}
+		if ran {
+			t.Error("cmd.Resolve() ran an action or a PersistentBefore, Defer, or OnExit hook")
+		}
+		if r.Command != cmd.Subcommands[0] {
+			t.Errorf("Resolution.Command = %q, want serve", r.Command.Name)
+		}
+		got := *r
+		got.Command = nil // compared above
+		want := cli.Resolution[*params]{
+			Path: []string{"foo", "serve"},
+			Args: []string{"file"},
+			Flags: []cli.ResolvedFlag{
+				{Command: "foo", Name: "v", Value: "true", Typed: true, Source: cli.SourceFlag},
+				{Command: "foo serve", Name: "port", Value: "80", Typed: 80, Source: cli.SourceFlag},
+				{Command: "foo serve", Name: "token", Value: "s3cret", Typed: "s3cret", Source: cli.SourceVar, VarName: "FOO_TOKEN", Secret: true},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Resolution mismatch (-want +got):\n%s", diff)
+		}
+		if want := (params{verbose: true, port: 80, token: "s3cret"}); *p != want {
+			t.Errorf("params = %+v, want %+v", *p, want)
+		}
+		if errbuf.Len() > 0 {
+			t.Errorf("unexpected error output: %q", errbuf.String())
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var (
+			errbuf bytes.Buffer
+			ran    bool
+		)
+		e := &cli.Env[*params]{Args: []string{"foo", "serve", "-port", "x"}, Err: &errbuf, Params: &params{}}
+		_, err := cmdFactory(&ran).Resolve(e)
+		var exitErr *cli.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Status != cli.ExitUsage {
+			t.Fatalf("cmd.Resolve() error = %v, want ExitError with status %d", err, cli.ExitUsage)
+		}
+		if want := `invalid value "x" for flag -port: parse error`; err.Error() != want {
+			t.Errorf("cmd.Resolve() error = %q, want %q", err, want)
+		}
+		if errbuf.Len() > 0 {
+			t.Errorf("unexpected error output: %q", errbuf.String())
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		var (
+			outbuf bytes.Buffer
+			ran    bool
+		)
+		e := &cli.Env[*params]{Args: []string{"foo", "serve", "-h"}, Out: &outbuf, Params: &params{}}
+		if _, err := cmdFactory(&ran).Resolve(e); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("cmd.Resolve() error = %v, want %v", err, flag.ErrHelp)
+		}
+		if outbuf.Len() == 0 {
+			t.Error("cmd.Resolve() wrote no help")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package tinycli
+
+import "context"
+
+// A Result is the outcome of an [ResultFunc], rendered by the framework
+// instead of written by the action itself.
+type Result struct {
+	Message string     // summary for people, written unless quiet
+	Data    any        // payload written by Env.Emit, omitted if nil
+	Status  ExitStatus // resulting exit status
+}
+
+// A ResultFunc is a function called when a Command is invoked that returns a
+// [Result], or reports failure with an error.
+type ResultFunc[P any] = func(context.Context, *Env[P]) (Result, error)
+
+// hasAction reports whether c defines an action.
+func (c *Command[P]) hasAction() bool {
+	return c.Action != nil || c.ActionE != nil || c.ActionR != nil
+}
+
+// callAction calls the action of c, returning its ExitStatus and the error it
+// failed with, which is not yet reported. The Result of an ResultFunc is
+// written before callAction returns.
+func (c *invocation[P]) callAction(ctx context.Context, e *Env[P]) (ExitStatus, error) {
+	switch {
+	case c.Action != nil:
+		return c.Action(ctx, e), nil
+	case c.ActionE != nil:
+		return ExitSuccess, c.ActionE(ctx, e)
+	}
+	res, err := c.ActionR(ctx, e)
+	if err != nil {
+		return ExitSuccess, err
+	}
+	if err := e.writeResult(res); err != nil {
+		return ExitSuccess, err
+	}
+	return res.Status, nil
+}
+
+// writeResult renders res: its Data in the format selected by the
+// [OutputFlag], and its Message unless quiet. The message is written to the
+// standard output stream with the table format, and otherwise to the error
+// output stream, so that the standard output stream remains machine-readable.
+func (e Env[P]) writeResult(res Result) error {
+	if res.Message != "" && !e.StandardOptions().Quiet {
+		format := "%s\n"
+		if e.outputFormat() == "table" {
+			_, err := e.Successf(format, res.Message)
+			if err != nil {
+				return err
+			}
+		} else {
+			e.Errorf(format, res.Message)
+		}
+	}
+	if res.Data == nil {
+		return nil
+	}
+	return e.Emit(res.Data)
+}
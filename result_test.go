@@ -0,0 +1,91 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_actionR(t *testing.T) {
+	type server struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		result     cli.Result
+		err        error
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "message_and_data",
+			args:       []string{"foo"},
+			result:     cli.Result{Message: "created web", Data: server{"web", 80}},
+			wantOutbuf: "created web\nNAME   PORT\nweb    80\n",
+		},
+		{
+			name:       "json",
+			args:       []string{"foo", "-o", "json"},
+			result:     cli.Result{Message: "created web", Data: server{"web", 80}},
+			wantOutbuf: "{\n  \"name\": \"web\",\n  \"port\": 80\n}\n",
+			wantErrbuf: "created web\n",
+		},
+		{
+			name:       "quiet",
+			args:       []string{"foo", "-q"},
+			result:     cli.Result{Message: "created web", Data: server{"web", 80}},
+			wantOutbuf: "NAME   PORT\nweb    80\n",
+		},
+		{
+			name:       "status",
+			args:       []string{"foo"},
+			result:     cli.Result{Message: "nothing to do", Status: cli.ExitTempFail},
+			wantOutbuf: "nothing to do\n",
+			wantStatus: cli.ExitTempFail,
+		},
+		{
+			name:       "error",
+			args:       []string{"foo"},
+			result:     cli.Result{Message: "created web"},
+			err:        errors.New("boom"),
+			wantErrbuf: "boom\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts cli.StandardOptions
+			cmd := &cli.Command[any]{
+				Name:        "foo",
+				UsagePolicy: cli.UsageNever,
+				Flags: func(fs *flag.FlagSet, p any) {
+					cli.StandardFlags(fs, &opts)
+					cli.OutputFlag(fs, nil, "table")
+				},
+				ActionR: func(ctx context.Context, e *cli.Env[any]) (cli.Result, error) {
+					return tt.result, tt.err
+				},
+			}
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Err: &errbuf}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
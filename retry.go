@@ -0,0 +1,80 @@
+package tinycli
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Default settings for a [RetryPolicy].
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBackoff    = time.Second
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// A RetryPolicy configures how [Retry] retries a failed action.
+type RetryPolicy struct {
+	MaxAttempts int                  // calls allowed, including the first; zero means DefaultRetryAttempts
+	Backoff     time.Duration        // delay before the first retry; zero means DefaultRetryBackoff
+	MaxBackoff  time.Duration        // bound on the doubling delay; zero means DefaultRetryMaxBackoff
+	Retryable   func(err error) bool // reports whether err may succeed on retry; nil means any error
+}
+
+// Retry wraps an [ActionErrFunc] that may fail transiently, such as a network
+// request, so that it is called again when it returns an error.
+//
+// Failed attempts are reported to the Env error output stream, and the action
+// is called again after a delay that doubles with each retry, until the
+// policy's MaxAttempts is reached. Errors for which the policy's Retryable
+// func reports false, context errors, and [ValueError] input errors are
+// returned without retrying. When ctx is canceled while waiting, Retry
+// returns the context's error.
+func Retry[P any](action ActionErrFunc[P], policy RetryPolicy) ActionErrFunc[P] {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultRetryAttempts
+	}
+	backoff := policy.Backoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	return func(ctx context.Context, e *Env[P]) error {
+		delay := backoff
+		for attempt := 1; ; attempt++ {
+			err := action(ctx, e)
+			if err == nil || !retryable(err, policy.Retryable) || ctx.Err() != nil {
+				return err
+			}
+			if attempt >= maxAttempts {
+				e.Errorf("attempt %d of %d failed, giving up\n", attempt, maxAttempts)
+				return err
+			}
+			e.Errorf("attempt %d of %d failed: %s; retrying in %v\n",
+				attempt, maxAttempts, e.Redactor.Redact(err.Error()), delay)
+
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+			delay = min(2*delay, maxBackoff)
+		}
+	}
+}
+
+// retryable reports whether err may succeed on retry according to fn.
+func retryable(err error, fn func(error) bool) bool {
+	var valErr *ValueError
+	if errors.As(err, &valErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return fn == nil || fn(err)
+}
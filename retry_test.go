@@ -0,0 +1,109 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestRetry(t *testing.T) {
+	errFlaky := errors.New("connection reset")
+	errFatal := errors.New("not found")
+
+	// run executes a retried action that returns errs in turn, and then nil
+	run := func(ctx context.Context, policy cli.RetryPolicy, errs ...error) (cli.ExitStatus, int, string) {
+		var calls int
+		action := func(ctx context.Context, e *cli.Env[any]) error {
+			calls++
+			if calls <= len(errs) {
+				return errs[calls-1]
+			}
+			return nil
+		}
+		var errOut bytes.Buffer
+		cmd := &cli.Command[any]{
+			Name:        "root",
+			UsagePolicy: cli.UsageNever,
+			ActionE:     cli.Retry(action, policy),
+		}
+		status := cmd.Execute(ctx, &cli.Env[any]{Args: []string{"root"}, Err: &errOut})
+		return status, calls, errOut.String()
+	}
+
+	tests := []struct {
+		name       string
+		policy     cli.RetryPolicy
+		errs       []error
+		wantStatus cli.ExitStatus
+		wantCalls  int
+		wantErrOut string
+	}{
+		{
+			name:       "recovers",
+			policy:     cli.RetryPolicy{Backoff: time.Millisecond},
+			errs:       []error{errFlaky, errFlaky},
+			wantCalls:  3,
+			wantErrOut: "attempt 1 of 3 failed: connection reset; retrying in 1ms\nattempt 2 of 3 failed: connection reset; retrying in 2ms\n",
+		},
+		{
+			name:       "gives_up",
+			policy:     cli.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+			errs:       []error{errFlaky, errFlaky, errFlaky},
+			wantStatus: cli.ExitFailure,
+			wantCalls:  2,
+			wantErrOut: "attempt 1 of 2 failed: connection reset; retrying in 1ms\nattempt 2 of 2 failed, giving up\nconnection reset\n",
+		},
+		{
+			name: "not_retryable",
+			policy: cli.RetryPolicy{
+				Backoff:   time.Millisecond,
+				Retryable: func(err error) bool { return !errors.Is(err, errFatal) },
+			},
+			errs:       []error{errFlaky, errFatal},
+			wantStatus: cli.ExitFailure,
+			wantCalls:  2,
+			wantErrOut: "attempt 1 of 3 failed: connection reset; retrying in 1ms\nnot found\n",
+		},
+		{
+			name:       "value_error",
+			policy:     cli.RetryPolicy{Backoff: time.Millisecond},
+			errs:       []error{&cli.ValueError{Name: "port", Err: errors.New("out of range")}},
+			wantStatus: cli.ExitUsage,
+			wantCalls:  1,
+			wantErrOut: "out of range\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, calls, errOut := run(t.Context(), tt.policy, tt.errs...)
+			if status != tt.wantStatus {
+				t.Errorf("exit status = %d, want %d", status, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("action called %d times, want %d", calls, tt.wantCalls)
+			}
+			if diff := cmp.Diff(tt.wantErrOut, errOut); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		policy := cli.RetryPolicy{MaxAttempts: 5, Backoff: time.Hour}
+		status, calls, _ := run(ctx, policy, errFlaky, errFlaky)
+		if want, got := cli.ExitCanceled, status; want != got {
+			t.Errorf("exit status = %d, want %d", got, want)
+		}
+		if want, got := 1, calls; want != got {
+			t.Errorf("action called %d times, want %d", got, want)
+		}
+	})
+}
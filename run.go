@@ -0,0 +1,53 @@
+package tinycli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// exit terminates the process, and is replaced in tests.
+var exit = os.Exit
+
+// Main executes cmd with a [DefaultEnv] for params, as [Command.Run] does, and
+// exits the process with the resulting status. It is intended to be the whole
+// body of a program's main function:
+//
+//	func main() {
+//		cli.Main(rootCmd, &params{})
+//	}
+func Main[P any](cmd *Command[P], params P) {
+	exit(int(cmd.Run(context.Background(), DefaultEnv(params))))
+}
+
+// Run executes the command like Execute, canceling the context passed to hooks
+// and actions when the process receives an interrupt or termination signal,
+// so that they can stop gracefully. A second signal exits the process
+// immediately with [ExitCanceled].
+func (c *Command[P]) Run(ctx context.Context, e *Env[P]) ExitStatus {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-done:
+			return
+		}
+		select {
+		case <-sigs:
+			exit(int(ExitCanceled))
+		case <-done:
+		}
+	}()
+
+	return c.Execute(ctx, e)
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package tinycli_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Run(t *testing.T) {
+	t.Run("interrupt_cancels", func(t *testing.T) {
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+				select {
+				case <-ctx.Done():
+					return cli.ExitCanceled
+				case <-time.After(5 * time.Second):
+					return cli.ExitFailure
+				}
+			},
+		}
+		if want, got := cli.ExitCanceled, cmd.Run(t.Context(), &cli.Env[any]{Args: []string{"root"}}); want != got {
+			t.Errorf("cmd.Run() = %d, want %d", got, want)
+		}
+	})
+
+	t.Run("second_signal_exits", func(t *testing.T) {
+		exited := make(chan int, 1)
+		defer cli.SetExit(func(code int) { exited <- code })()
+
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+				<-ctx.Done()
+				syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+				select {
+				case code := <-exited:
+					return cli.ExitStatus(code)
+				case <-time.After(5 * time.Second):
+					return cli.ExitFailure
+				}
+			},
+		}
+		if want, got := cli.ExitCanceled, cmd.Run(t.Context(), &cli.Env[any]{Args: []string{"root"}}); want != got {
+			t.Errorf("cmd.Run() = %d, want %d", got, want)
+		}
+	})
+}
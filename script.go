@@ -0,0 +1,105 @@
+package tinycli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+)
+
+// ScriptOptions configure [Command.RunScript].
+type ScriptOptions struct {
+	ContinueOnError bool // run the remaining lines after a line fails
+}
+
+// RunScript executes the command once for each line read from r, as if the
+// program were invoked with the args of the line, split as by [SplitArgs].
+// Blank lines and lines beginning with # are skipped. Each line is executed
+// with a copy of e, so the lines share its streams, vars, and params.
+//
+// Execution stops at the first line that fails, returning its status, unless
+// opts.ContinueOnError is set, in which case every line is executed and the
+// status of the last failing line is returned. Lines that cannot be split
+// fail with [ExitUsage].
+func (c *Command[P]) RunScript(ctx context.Context, e *Env[P], r io.Reader, opts ScriptOptions) ExitStatus {
+	name := c.Name
+	if len(e.Args) > 0 {
+		name = e.Args[0]
+	}
+
+	status := ExitSuccess
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if ctx.Err() != nil {
+			return ExitCanceled
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var lineStatus ExitStatus
+		args, err := SplitArgs(line)
+		if err != nil {
+			e.Errorf("line %d: %s\n", n, err)
+			lineStatus = ExitUsage
+		} else {
+			le := *e
+			le.Args = append([]string{name}, args...)
+			lineStatus = c.Execute(ctx, &le)
+		}
+		if lineStatus != ExitSuccess {
+			if !opts.ContinueOnError {
+				return lineStatus
+			}
+			status = lineStatus
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		e.Errorf("reading script: %s\n", err)
+		return ExitFailure
+	}
+	return status
+}
+
+// ScriptCommand returns a "script" Command that runs the command tree it is
+// added to once for each line of a script file, or of the standard input
+// stream when the file is "-", as by [Command.RunScript]. Its -k flag
+// continues after failing lines:
+//
+//	foo script - <<EOF
+//	serve -port 80 -check
+//	migrate -dry-run
+//	EOF
+func ScriptCommand[P any]() *Command[P] {
+	return &Command[P]{
+		Name:  "script",
+		Usage: "usage: script [-k] FILE|-",
+		Flags: func(fs *flag.FlagSet, _ P) {
+			fs.Bool("k", false, "continue after failing lines")
+		},
+		Args: ExactArgs(1),
+		Action: func(ctx context.Context, e *Env[P]) ExitStatus {
+			c := e.cmds[len(e.cmds)-1]
+			r := e.In
+			if e.Args[0] != "-" {
+				f, err := os.Open(e.Args[0])
+				if err != nil {
+					return c.onErr(e, err, ExitFailure)
+				}
+				defer f.Close()
+				r = f
+			}
+			if r == nil {
+				return c.onErr(e, errors.New("no input stream"), ExitFailure)
+			}
+			re := *e
+			re.Args = e.CommandPath()[:1]
+			opts := ScriptOptions{ContinueOnError: e.flagValue("k") == "true"}
+			return e.cmds[0].RunScript(ctx, &re, r, opts)
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func newScriptTree() *cli.Command[*int] {
+	return &cli.Command[*int]{
+		Name:  "root",
+		Usage: "root usage",
+		Subcommands: []*cli.Command[*int]{
+			{
+				Name: "add",
+				Flags: func(fs *flag.FlagSet, p *int) {
+					fs.Func("n", "amount", func(s string) error {
+						*p += len(s)
+						return nil
+					})
+				},
+				Action: func(ctx context.Context, e *cli.Env[*int]) cli.ExitStatus {
+					e.Printf("add %q total=%d\n", e.Args, *e.Params)
+					return cli.ExitSuccess
+				},
+			},
+			{
+				Name: "fail",
+				Action: func(ctx context.Context, e *cli.Env[*int]) cli.ExitStatus {
+					return cli.ExitUnavailable
+				},
+			},
+			cli.ScriptCommand[*int](),
+		},
+	}
+}
+
+func TestCommand_RunScript(t *testing.T) {
+	script := `# comment
+add -n xx 'a b'
+
+add -n "y" c
+fail
+add 'unterminated
+add last
+`
+
+	tests := []struct {
+		name       string
+		opts       cli.ScriptOptions
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "stop_on_error",
+			wantOutbuf: "add [\"a b\"] total=2\nadd [\"c\"] total=3\n",
+			wantStatus: cli.ExitUnavailable,
+		},
+		{
+			name:       "continue_on_error",
+			opts:       cli.ScriptOptions{ContinueOnError: true},
+			wantOutbuf: "add [\"a b\"] total=2\nadd [\"c\"] total=3\nadd [\"last\"] total=3\n",
+			wantErrbuf: "line 6: unterminated quote\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[*int]{Args: []string{"root"}, Out: &outbuf, Err: &errbuf, Params: new(int)}
+			if got := newScriptTree().RunScript(t.Context(), e, strings.NewReader(script), tt.opts); got != tt.wantStatus {
+				t.Errorf("cmd.RunScript() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptCommand(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "script")
+	missing := filepath.Join(dir, "missing")
+	_, errMissing := os.Open(missing)
+	if err := os.WriteFile(file, []byte("add one\nfail\nadd two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		in         string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:       "stdin",
+			args:       []string{"root", "script", "-"},
+			in:         "add one\nadd two\n",
+			wantOutbuf: "add [\"one\"] total=0\nadd [\"two\"] total=0\n",
+		},
+		{
+			name:       "file",
+			args:       []string{"root", "script", file},
+			wantOutbuf: "add [\"one\"] total=0\n",
+			wantStatus: cli.ExitUnavailable,
+		},
+		{
+			name:       "file_continue",
+			args:       []string{"root", "script", "-k", file},
+			wantOutbuf: "add [\"one\"] total=0\nadd [\"two\"] total=0\n",
+			wantStatus: cli.ExitUnavailable,
+		},
+		{
+			name:       "missing_file",
+			args:       []string{"root", "script", missing},
+			wantErrbuf: "usage: script [-k] FILE|-\n" + errMissing.Error() + "\n",
+			wantStatus: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			e := &cli.Env[*int]{Args: tt.args, In: strings.NewReader(tt.in), Out: &outbuf, Err: &errbuf, Params: new(int)}
+			if got := newScriptTree().Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("shared_tree", func(t *testing.T) {
+		tree := newScriptTree()
+		var wg sync.WaitGroup
+		for _, tt := range tests[1:3] {
+			wg.Go(func() {
+				var outbuf bytes.Buffer
+				e := &cli.Env[*int]{Args: tt.args, Out: &outbuf, Params: new(int)}
+				if got := tree.Execute(t.Context(), e); got != tt.wantStatus {
+					t.Errorf("%s: cmd.Execute() = %d, want %d", tt.name, got, tt.wantStatus)
+				}
+				if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+					t.Errorf("%s: output mismatch (-want +got):\n%s", tt.name, diff)
+				}
+			})
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,73 @@
+package tinycli
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// A resolvedFlag is a row of the output of a [ShowConfigCommand].
+type resolvedFlag struct {
+	Command string `json:"command"`
+	Flag    string `json:"flag"`
+	Value   string `json:"value"`
+	Source  string `json:"source"`
+}
+
+// ShowConfigCommand returns a "show-config" Command that resolves the command
+// line it is given in, without the show-config name itself, and prints the
+// value and source of every flag of the resolved commands without calling
+// their actions. Secret values are redacted:
+//
+//	$ foo show-config serve -port 80
+//	COMMAND     FLAG    VALUE       SOURCE
+//	foo serve   host    localhost   default
+//	foo serve   port    80          flag
+//	foo serve   token   ****        var $FOO_TOKEN
+//
+// The ancestors of the show-config command are reported as they were
+// resolved on the way to it, so their hooks are not called again; only the
+// commands named by its args are resolved, as by [Command.Resolve].
+//
+// The values are written by [Env.Emit], in the format selected by the
+// [OutputFlag] of the show-config command or its ancestors.
+func ShowConfigCommand[P any]() *Command[P] {
+	return &Command[P]{
+		Name:  "show-config",
+		Usage: "usage: show-config [COMMAND...] [FLAGS...]",
+		Action: func(ctx context.Context, e *Env[P]) ExitStatus {
+			// resolving the args may set shared params, including the
+			// output format
+			format := e.outputFormat()
+			re := *e
+			re.cmds = slices.Clone(e.cmds[:len(e.cmds)-1])
+			re.pathValues = maps.Clone(e.pathValues)
+			re.resolveOnly = true
+			parent := re.cmds[len(re.cmds)-1]
+			s := scope{varPrefix: parent.varPrefix}
+			for _, c := range slices.Backward(re.cmds) {
+				if c.Version != "" {
+					s.version = c.Version
+					break
+				}
+			}
+			if status := parent.dispatch(ctx, &re, s); status != ExitSuccess || !re.resolved {
+				return status
+			}
+
+			var rows []resolvedFlag
+			for i, c := range re.cmds {
+				path := strings.Join(re.CommandPath()[:i+1], " ")
+				for _, k := range slices.Sorted(maps.Keys(c.meta)) {
+					value, source := c.describeValue(k)
+					rows = append(rows, resolvedFlag{Command: path, Flag: k, Value: value, Source: source})
+				}
+			}
+			if err := e.emit(rows, format); err != nil {
+				return e.cmds[len(e.cmds)-1].onErr(e, err, ExitFailure)
+			}
+			return ExitSuccess
+		},
+	}
+}
@@ -0,0 +1,163 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestShowConfigCommand(t *testing.T) {
+	type params struct {
+		host, token, output string
+		port                int
+	}
+	cmdFactory := func(ran *bool) *cli.Command[*params] {
+		return &cli.Command[*params]{
+			Name: "foo",
+			Flags: func(fs *flag.FlagSet, p *params) {
+				cli.OutputFlag(fs, &p.output, "table")
+			},
+			Persistent: []string{"o", "output"},
+			Subcommands: []*cli.Command[*params]{
+				{
+					Name: "serve",
+					Flags: func(fs *flag.FlagSet, p *params) {
+						fs.StringVar(&p.host, "host", "localhost", "host")
+						fs.IntVar(&p.port, "port", 8080, "port")
+						fs.StringVar(&p.token, "token", "", "api token")
+					},
+					Vars:    map[string]string{"token": "FOO_TOKEN"},
+					Secrets: []string{"token"},
+					Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+						*ran = true
+						return cli.ExitSuccess
+					},
+				},
+				cli.ShowConfigCommand[*params](),
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name: "table",
+			args: []string{"foo", "show-config", "serve", "-port", "80"},
+			wantOutbuf: `COMMAND     FLAG     VALUE       SOURCE
+foo         o        table       default
+foo         output   table       default
+foo serve   host     localhost   default
+foo serve   port     80          flag
+foo serve   token    ****        var $FOO_TOKEN
+`,
+		},
+		{
+			name: "json",
+			args: []string{"foo", "-o", "json", "show-config"},
+			wantOutbuf: `[
+  {
+    "command": "foo",
+    "flag": "o",
+    "value": "json",
+    "source": "flag"
+  },
+  {
+    "command": "foo",
+    "flag": "output",
+    "value": "json",
+    "source": "default"
+  }
+]
+`,
+		},
+		{
+			name:       "invalid",
+			args:       []string{"foo", "show-config", "serve", "-port", "x"},
+			wantErrbuf: "\ninvalid value \"x\" for flag -port: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				outbuf, errbuf bytes.Buffer
+				ran            bool
+			)
+			e := &cli.Env[*params]{
+				Args:   tt.args,
+				Vars:   map[string]string{"FOO_TOKEN": "s3cret"},
+				Out:    &outbuf,
+				Err:    &errbuf,
+				Params: &params{},
+			}
+			if got := cmdFactory(&ran).Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if ran {
+				t.Error("show-config ran the action of the resolved command")
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("hooks_once_and_repeated_flags", func(t *testing.T) {
+		var (
+			tags  []string
+			hooks []string
+		)
+		cmd := &cli.Command[any]{
+			Name: "foo",
+			Flags: func(fs *flag.FlagSet, p any) {
+				cli.StringSliceVar(fs, &tags, "tag", nil, "tags", ",")
+			},
+			Persistent: []string{"tag"},
+			Before: func(e *cli.Env[any]) error {
+				hooks = append(hooks, "before")
+				return nil
+			},
+			After: func(e *cli.Env[any]) error {
+				hooks = append(hooks, "after")
+				return nil
+			},
+			Defer: func(e *cli.Env[any], status cli.ExitStatus) {
+				hooks = append(hooks, "defer")
+			},
+			OnExit: func(e *cli.Env[any], status cli.ExitStatus) {
+				hooks = append(hooks, "onExit")
+			},
+			Subcommands: []*cli.Command[any]{
+				cli.ShowConfigCommand[any](),
+			},
+		}
+		var outbuf bytes.Buffer
+		e := &cli.Env[any]{Args: []string{"foo", "-tag", "a", "show-config", "-tag=b"}, Out: &outbuf}
+		if got := cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+			t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+		}
+		want := "COMMAND   FLAG   VALUE   SOURCE\nfoo       tag    a,b     flag\n"
+		if diff := cmp.Diff(want, outbuf.String()); diff != "" {
+			t.Errorf("output mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"a", "b"}, tags); diff != "" {
+			t.Errorf("resolved tags mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff([]string{"before", "after", "defer", "onExit"}, hooks); diff != "" {
+			t.Errorf("hooks mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package tinycli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ShutdownContext returns the context for an action's shutdown work, which
+// remains valid after the action's context is canceled, until the Env
+// ShutdownGrace period elapses. An action observing cancellation of its
+// context should wrap up, flushing and closing resources with the shutdown
+// context, and abort when the shutdown context is canceled too. Without a
+// ShutdownGrace, the shutdown context is the action's context.
+//
+// Outside of an action, ShutdownContext returns [context.Background].
+func (e Env[P]) ShutdownContext() context.Context {
+	if e.shutdownCtx == nil {
+		return context.Background()
+	}
+	return e.shutdownCtx
+}
+
+// An actionResult is the outcome of an action run by awaitAction.
+type actionResult struct {
+	status   ExitStatus
+	panicked bool
+	panicVal any
+	stack    []byte // stack of the panicking goroutine
+}
+
+// awaitAction calls fn with ctx and the Env, and sets the Env shutdown context
+// for its duration. With a ShutdownGrace, fn runs in its own goroutine with a
+// copy of the Env, whose state is copied back when fn returns, and
+// awaitAction returns when fn does or when the grace period elapses after ctx
+// is canceled, reporting whether fn was abandoned. Output written by an
+// abandoned fn is discarded. Panics in fn are propagated.
+func (e *Env[P]) awaitAction(ctx context.Context, fn func(context.Context, *Env[P]) ExitStatus) (status ExitStatus, abandoned bool) {
+	defer func() { e.shutdownCtx = nil }()
+	if e.ShutdownGrace <= 0 {
+		e.shutdownCtx = ctx
+		return fn(ctx, e), false
+	}
+	shutdownCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	defer abort()
+
+	ae := *e
+	ae.shutdownCtx = shutdownCtx
+	out, errOut := detachable(e.Out), detachable(e.Err)
+	ae.Out, ae.Err = out, errOut
+
+	done := make(chan actionResult, 1)
+	go func() {
+		panicked := true
+		defer func() {
+			if panicked {
+				r := recover()
+				done <- actionResult{panicked: true, panicVal: r, stack: debug.Stack()}
+			}
+		}()
+		status := fn(ctx, &ae)
+		panicked = false
+		done <- actionResult{status: status}
+	}()
+
+	var res actionResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		t := time.NewTimer(e.ShutdownGrace)
+		defer t.Stop()
+		select {
+		case res = <-done:
+		case <-t.C:
+			detach(out)
+			detach(errOut)
+			return 0, true
+		}
+	}
+	streams := [2]io.Writer{e.Out, e.Err}
+	*e = ae
+	e.Out, e.Err = streams[0], streams[1]
+	if res.panicked {
+		if _, ok := res.panicVal.(exitSignal); ok {
+			panic(res.panicVal)
+		}
+		panic(actionPanic{value: res.panicVal, stack: res.stack})
+	}
+	return res.status, false
+}
+
+// A detachableWriter writes to w until it is detached, and then discards
+// writes, so that an abandoned action cannot write to the Env streams after
+// Execute returns.
+type detachableWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	detached bool
+}
+
+// detachable returns w wrapped in a detachableWriter, or nil if w is nil.
+func detachable(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return &detachableWriter{w: w}
+}
+
+func (d *detachableWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.detached {
+		return len(p), nil
+	}
+	return d.w.Write(p)
+}
+
+// detach stops w from writing, once any write in progress completes.
+func detach(w io.Writer) {
+	if d, ok := w.(*detachableWriter); ok {
+		d.mu.Lock()
+		d.detached = true
+		d.mu.Unlock()
+	}
+}
+
+// errAbandoned returns the error reported for an action that did not return
+// within the shutdown grace period after ctx was canceled.
+func errAbandoned(ctx context.Context, grace time.Duration) error {
+	return fmt.Errorf("%w; action did not stop within %s", context.Cause(ctx), grace)
+}
@@ -0,0 +1,153 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_ShutdownContext(t *testing.T) {
+	var cancel context.CancelFunc
+	tests := []struct {
+		name       string
+		grace      time.Duration
+		timeout    time.Duration
+		action     cli.ActionErrFunc[any]
+		wantOutbuf string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:  "wrap_up_within_grace",
+			grace: time.Minute,
+			action: func(ctx context.Context, e *cli.Env[any]) error {
+				cancel()
+				<-ctx.Done()
+				if err := e.ShutdownContext().Err(); err != nil {
+					return err
+				}
+				e.Printf("flushed\n")
+				return nil
+			},
+			wantOutbuf: "flushed\n",
+		},
+		{
+			name:  "abandoned",
+			grace: time.Millisecond,
+			action: func(ctx context.Context, e *cli.Env[any]) error {
+				cancel()
+				<-e.ShutdownContext().Done()
+				time.Sleep(time.Second)
+				return nil
+			},
+			wantErrbuf: "context canceled; action did not stop within 1ms\n",
+			wantStatus: cli.ExitCanceled,
+		},
+		{
+			name:    "abandoned_after_timeout",
+			grace:   time.Millisecond,
+			timeout: time.Millisecond,
+			action: func(ctx context.Context, e *cli.Env[any]) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+			wantErrbuf: "timed out after 1ms; action did not stop within 1ms\n",
+			wantStatus: cli.ExitTimeout,
+		},
+		{
+			name: "no_grace",
+			action: func(ctx context.Context, e *cli.Env[any]) error {
+				cancel()
+				<-ctx.Done()
+				return e.ShutdownContext().Err()
+			},
+			wantErrbuf: "context canceled\n",
+			wantStatus: cli.ExitCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf, errbuf bytes.Buffer
+			cmd := &cli.Command[any]{
+				Name:        "root",
+				Timeout:     tt.timeout,
+				UsagePolicy: cli.UsageNever,
+				ActionE:     tt.action,
+			}
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(t.Context())
+			defer cancel()
+			e := &cli.Env[any]{Args: []string{"root"}, Out: &outbuf, Err: &errbuf, ShutdownGrace: tt.grace}
+			if got := cmd.Execute(ctx, e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("panic", func(t *testing.T) {
+		var errbuf bytes.Buffer
+		cmd := &cli.Command[any]{
+			Name: "root",
+			Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+				panic(errors.New("boom"))
+			},
+		}
+		e := &cli.Env[any]{Args: []string{"root"}, Err: &errbuf, ShutdownGrace: time.Minute}
+		if got := cmd.Execute(t.Context(), e); got != cli.ExitFailure {
+			t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitFailure)
+		}
+		got := errbuf.String()
+		if !strings.HasPrefix(got, "panic: boom\n") {
+			t.Errorf("error output = %q, want panic report", got)
+		}
+		// the trimmed stack starts at the action, not where the panic was raised again
+		if _, frames, _ := strings.Cut(got, "]:\n"); !strings.HasPrefix(frames, "github.com/jonathonwebb/tinycli_test.TestEnv_ShutdownContext.") {
+			t.Errorf("error output = %q, want stack of the panicking action", got)
+		}
+	})
+
+	t.Run("abandoned_output_discarded", func(t *testing.T) {
+		var outbuf bytes.Buffer
+		finished := make(chan struct{})
+		ctx, cancel := context.WithCancel(t.Context())
+		cmd := &cli.Command[any]{
+			Name: "root",
+			ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+				defer close(finished)
+				cancel()
+				<-e.ShutdownContext().Done()
+				time.Sleep(10 * time.Millisecond)
+				e.Printf("late\n")
+				return errors.New("late error")
+			},
+		}
+		e := &cli.Env[any]{Args: []string{"root"}, Out: &outbuf, ShutdownGrace: time.Millisecond}
+		if got := cmd.Execute(ctx, e); got != cli.ExitCanceled {
+			t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitCanceled)
+		}
+		if ctx := e.ShutdownContext(); ctx.Err() != nil {
+			t.Errorf("ShutdownContext() after Execute is done: %v", ctx.Err())
+		}
+		<-finished
+		if got := outbuf.String(); got != "" {
+			t.Errorf("output = %q, want output of the abandoned action discarded", got)
+		}
+	})
+
+	if ctx := (cli.Env[any]{}).ShutdownContext(); ctx.Err() != nil {
+		t.Errorf("ShutdownContext() outside an action is done: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,221 @@
+package tinycli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A CommandSpec is a machine-readable description of a [Command].
+type CommandSpec struct {
+	Name     string     `json:"name"`               // name used to invoke the command
+	Aliases  []string   `json:"aliases,omitempty"`  // alternative names
+	Usage    string     `json:"usage,omitempty"`    // short usage text
+	Help     string     `json:"help,omitempty"`     // long help text
+	Args     string     `json:"args,omitempty"`     // positional args synopsis
+	Flags    []FlagSpec `json:"flags,omitempty"`    // defined flags, sorted by name
+	Examples []string   `json:"examples,omitempty"` // example command lines
+
+	Deprecated *Deprecation `json:"deprecated,omitempty"` // deprecation schedule
+
+	// GlobalFlags are the Persistent flags of the command's ancestors.
+	GlobalFlags []FlagSpec `json:"globalFlags,omitempty"`
+
+	// Subcommands are the specs of the command's subcommands, which are only
+	// included by Spec.
+	Subcommands []CommandSpec `json:"subcommands,omitempty"`
+}
+
+// A FlagSpec is a machine-readable description of a [Command] flag.
+type FlagSpec struct {
+	Name     string   `json:"name"`               // flag name
+	Type     string   `json:"type,omitempty"`     // value type, such as "int" or "[]string"
+	Usage    string   `json:"usage,omitempty"`    // flag usage text
+	Default  string   `json:"default"`            // default value as text
+	Var      string   `json:"var,omitempty"`      // bound env var name
+	Bool     bool     `json:"bool,omitempty"`     // whether the flag is a boolean flag
+	Required bool     `json:"required,omitempty"` // whether the flag must be set
+	Secret   bool     `json:"secret,omitempty"`   // whether the flag value is secret
+	Choices  []string `json:"choices,omitempty"`  // allowed values, for enum flags
+
+	Deprecated *Deprecation `json:"deprecated,omitempty"` // deprecation schedule
+}
+
+func (c *invocation[P]) spec(e *Env[P]) CommandSpec {
+	s := CommandSpec{
+		Name:     c.Name,
+		Aliases:  c.Aliases,
+		Usage:    c.Usage,
+		Help:     c.help(e),
+		Args:     c.ArgsUsage,
+		Examples: c.Examples,
+
+		Deprecated: c.Deprecated,
+	}
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		if !c.isHidden(f.Name) {
+			s.Flags = append(s.Flags, c.flagSpec(f))
+		}
+	})
+	s.GlobalFlags = c.globalFlags(e)
+	return s
+}
+
+func (c *invocation[P]) flagSpec(f *flag.Flag) FlagSpec {
+	isBool := isBoolFlag(f.Value)
+	var varName string
+	if _, ok := f.Value.(*builtinFlag); !ok {
+		varName, _ = c.lookupVarName(f.Name)
+	}
+	var deprecated *Deprecation
+	if d, ok := c.DeprecatedFlags[f.Name]; ok {
+		deprecated = &d
+	}
+	isSecret := slices.Contains(c.Secrets, f.Name)
+	defValue := f.DefValue
+	if isSecret && !isZeroDefault(defValue) {
+		defValue = redacted
+	}
+	var choices []string
+	if cv, ok := unwrapValue(f.Value).(choicesValue); ok {
+		choices = cv.choices()
+	}
+	return FlagSpec{
+		Name:       f.Name,
+		Type:       flagType(f.Value),
+		Usage:      f.Usage,
+		Default:    defValue,
+		Var:        varName,
+		Bool:       isBool,
+		Required:   slices.Contains(c.Required, f.Name),
+		Secret:     isSecret,
+		Choices:    choices,
+		Deprecated: deprecated,
+	}
+}
+
+// flagType returns the Go type of a flag's value, or the empty string if the
+// value does not implement [flag.Getter].
+func flagType(v flag.Value) string {
+	switch v := unwrapValue(v).(type) {
+	case *builtinFlag:
+		return "bool"
+	case flag.Getter:
+		if _, ok := v.Get().([]byte); ok {
+			return "[]byte" // rather than []uint8
+		}
+		return fmt.Sprintf("%T", v.Get())
+	}
+	return ""
+}
+
+// Spec returns a machine-readable description of the full command tree rooted
+// at c, as it would be executed with new params of type P, excluding hidden
+// commands and flags. Commands whose Flags hook panics with new params, such
+// as the nil value of an interface type, are described without their flags. The result can be marshaled to JSON, for example to
+// detect accidental changes to a program's interface in tests, or to feed
+// completion and documentation tools.
+func Spec[P any](c *Command[P]) CommandSpec {
+	e := &Env[P]{Params: newParams[P]()}
+	return (&invocation[P]{Command: c}).specTree(e, "")
+}
+
+// specTree returns the spec of c and its subcommands, defining their flags
+// along the way.
+func (c *invocation[P]) specTree(e *Env[P], varPrefix string) CommandSpec {
+	c.varPrefix = varPrefix + c.VarPrefix
+	if !defineFlags(c.Command, c.flagSet(), e.Params) {
+		c.fs = nil // flags the hook defined before panicking are incomplete
+	}
+	if c.Version != "" && c.flagSet().Lookup("version") == nil {
+		c.flagSet().Var(new(builtinFlag), "version", "print version and exit")
+	}
+	if c.PromptRequired && c.flagSet().Lookup("interactive") == nil {
+		c.flagSet().Var(new(builtinFlag), "interactive", "prompt for missing required values")
+	}
+
+	e.cmds = append(e.cmds, c)
+	defer func() { e.cmds = e.cmds[:len(e.cmds)-1] }()
+
+	s := c.spec(e)
+	for _, sub := range c.Subcommands {
+		if !sub.Hidden {
+			s.Subcommands = append(s.Subcommands, (&invocation[P]{Command: sub}).specTree(e, c.varPrefix))
+		}
+	}
+	return s
+}
+
+// newParams returns new params of type P: a pointer to a new zero value when P
+// is a pointer type, and otherwise the zero value of P.
+func newParams[P any]() P {
+	var p P
+	if t := reflect.TypeOf(p); t != nil && t.Kind() == reflect.Pointer {
+		p = reflect.New(t.Elem()).Interface().(P)
+	}
+	return p
+}
+
+// globalFlags returns the Persistent flags of the ancestors of c visited
+// during execution, from the root down.
+func (c *invocation[P]) globalFlags(e *Env[P]) []FlagSpec {
+	var specs []FlagSpec
+	for _, a := range e.cmds {
+		if a == c {
+			break
+		}
+		for _, name := range a.Persistent {
+			if f := a.flagSet().Lookup(name); f != nil && !a.isHidden(name) {
+				specs = append(specs, a.flagSpec(f))
+			}
+		}
+	}
+	return specs
+}
+
+// writeGlobalFlags writes a help section listing the global flags of c, if
+// there are any.
+func (c *invocation[P]) writeGlobalFlags(e *Env[P]) {
+	specs := c.globalFlags(e)
+	if len(specs) == 0 {
+		return
+	}
+	var width int
+	for _, s := range specs {
+		width = max(width, len(s.Name))
+	}
+	e.Printf("\nglobal flags:\n")
+	for _, s := range specs {
+		line := fmt.Sprintf("  -%-*s  %s", width, s.Name, s.Usage)
+		if s.Var != "" {
+			line += fmt.Sprintf(" ($%s)", s.Var)
+		}
+		e.Printf("%s\n", strings.TrimRight(line, " "))
+	}
+}
+
+func (c *invocation[P]) onHelpJSON(e *Env[P]) error {
+	b, err := json.MarshalIndent(c.spec(e), "", "  ")
+	if err != nil {
+		return err
+	}
+	e.Printf("%s\n", b)
+	return nil
+}
+
+// helpFormat reports the value given to the -help flag that stopped parsing
+// args, or the empty string if the flag was given without a value.
+func helpFormat(fs *flag.FlagSet, args []string) string {
+	var format string
+	visitFlagArgs(fs, args, func(name, value string) bool {
+		if (name == "help" || name == "h") && fs.Lookup(name) == nil {
+			format = value
+			return false
+		}
+		return true
+	})
+	return format
+}
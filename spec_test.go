@@ -0,0 +1,124 @@
+package tinycli_test
+
+import (
+	"encoding/json"
+	"flag"
+	"io/fs"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestSpec(t *testing.T) {
+	type p struct {
+		Env  string
+		Port int
+		Tags []string
+	}
+
+	cmd := &cli.Command[*p]{
+		Name:      "foo",
+		Version:   "v1.2.3",
+		Usage:     "usage: foo [flags] command",
+		VarPrefix: "FOO_",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.StringVar(&p.Env, "env", "dev", "environment name")
+		},
+		Persistent: []string{"env"},
+		Subcommands: []*cli.Command[*p]{
+			{
+				Name:      "serve",
+				Aliases:   []string{"s"},
+				Usage:     "usage: foo serve [flags]",
+				VarPrefix: "SERVE_",
+				Flags: func(fs *flag.FlagSet, p *p) {
+					fs.IntVar(&p.Port, "port", 8080, "port number")
+					cli.StringSliceVar(fs, &p.Tags, "tag", nil, "tags", "")
+				},
+				Required: []string{"port"},
+			},
+			{Name: "__debug", Hidden: true},
+		},
+	}
+
+	envSpec := cli.FlagSpec{Name: "env", Type: "string", Usage: "environment name", Default: "dev", Var: "FOO_ENV"}
+	want := cli.CommandSpec{
+		Name:  "foo",
+		Usage: "usage: foo [flags] command",
+		Flags: []cli.FlagSpec{
+			envSpec,
+			{Name: "version", Type: "bool", Usage: "print version and exit", Default: "false", Bool: true},
+		},
+		Subcommands: []cli.CommandSpec{
+			{
+				Name:    "serve",
+				Aliases: []string{"s"},
+				Usage:   "usage: foo serve [flags]",
+				Flags: []cli.FlagSpec{
+					{Name: "port", Type: "int", Usage: "port number", Default: "8080", Var: "FOO_SERVE_PORT", Required: true},
+					{Name: "tag", Type: "[]string", Usage: "tags", Var: "FOO_SERVE_TAG"},
+				},
+				GlobalFlags: []cli.FlagSpec{envSpec},
+			},
+		},
+	}
+
+	got := cli.Spec(cmd)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Spec() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := json.Marshal(got); err != nil {
+		t.Errorf("json.Marshal(Spec()) error: %v", err)
+	}
+}
+
+func TestSpec_flagTypes(t *testing.T) {
+	var (
+		n       int
+		f       float64
+		date    time.Time
+		loc     *time.Location
+		mode    fs.FileMode
+		addr    string
+		re      *regexp.Regexp
+		options map[string]int
+		key     []byte
+	)
+	cmd := &cli.Command[any]{
+		Name: "foo",
+		Flags: func(fs *flag.FlagSet, _ any) {
+			cli.LocaleIntVar(fs, &n, "n", 0, "", cli.Locale{})
+			cli.LocaleFloatVar(fs, &f, "f", 0, "", cli.Locale{})
+			cli.LocaleDateVar(fs, &date, "date", time.Time{}, "", cli.Locale{})
+			cli.LocationVar(fs, &loc, "tz", time.UTC, "")
+			cli.FileModeVar(fs, &mode, "mode", 0o644, "")
+			cli.ListenAddrVar(fs, &addr, "listen", ":8080", "")
+			cli.RegexpVar(fs, &re, "match", nil, "")
+			cli.JSONVar(fs, &options, "options", nil, "")
+			cli.HexVar(fs, &key, "key", nil, "")
+		},
+	}
+
+	got := make(map[string]string)
+	for _, f := range cli.Spec(cmd).Flags {
+		got[f.Name] = f.Type
+	}
+	want := map[string]string{
+		"n":       "int",
+		"f":       "float64",
+		"date":    "time.Time",
+		"tz":      "*time.Location",
+		"mode":    "fs.FileMode",
+		"listen":  "string",
+		"match":   "*regexp.Regexp",
+		"options": "map[string]int",
+		"key":     "[]byte",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Spec() flag types mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,79 @@
+package tinycli
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errUnterminatedQuote = errors.New("unterminated quote")
+	errTrailingBackslash = errors.New("trailing backslash")
+)
+
+// SplitArgs splits s into args as a POSIX shell splits a simple command line,
+// without expansions, so that `serve -p '80 80'` is split into "serve", "-p",
+// and "80 80".
+//
+// Args are separated by unquoted spaces, tabs, and newlines. Single quotes
+// preserve the text between them literally, and double quotes preserve it
+// except for a backslash before $, `, ", \, or a newline. Outside quotes, a
+// backslash preserves the next character, and a backslash followed by a
+// newline is removed. Quoted empty strings are kept as empty args.
+func SplitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		arg   strings.Builder
+		inArg bool // whether arg has begun, possibly as an empty quoted string
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ' ', '\t', '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case '\\':
+			if i+1 == len(s) {
+				return nil, errTrailingBackslash
+			}
+			i++
+			if s[i] != '\n' {
+				arg.WriteByte(s[i])
+				inArg = true
+			}
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errUnterminatedQuote
+			}
+			arg.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case '"':
+			inArg = true
+			for i++; ; i++ {
+				if i == len(s) {
+					return nil, errUnterminatedQuote
+				}
+				if s[i] == '"' {
+					break
+				}
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				arg.WriteByte(s[i])
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,50 @@
+package tinycli_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", s: "", want: nil},
+		{name: "blank", s: " \t\n", want: nil},
+		{name: "words", s: "  serve -p\t80\n", want: []string{"serve", "-p", "80"}},
+		{name: "single_quotes", s: `serve -p '80 80'`, want: []string{"serve", "-p", "80 80"}},
+		{name: "single_quotes_literal", s: `'a\b "c"'`, want: []string{`a\b "c"`}},
+		{name: "double_quotes", s: `"a 'b' \"c\" \$d \e"`, want: []string{`a 'b' "c" $d \e`}},
+		{name: "adjacent_quotes", s: `--name="a b"'c d'e`, want: []string{"--name=a bc de"}},
+		{name: "empty_quotes", s: `a '' ""`, want: []string{"a", "", ""}},
+		{name: "backslash", s: `a\ b \'c`, want: []string{"a b", "'c"}},
+		{name: "line_continuation", s: "a\\\nb \"c\\\nd\"", want: []string{"ab", "cd"}},
+		{name: "unicode", s: "héllo 'wörld ✓'", want: []string{"héllo", "wörld ✓"}},
+		{name: "unterminated_single", s: `a 'b`, wantErr: "unterminated quote"},
+		{name: "unterminated_double", s: `a "b\"`, wantErr: "unterminated quote"},
+		{name: "trailing_backslash", s: `a b\`, wantErr: "trailing backslash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cli.SplitArgs(tt.s)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("SplitArgs(%q) error = %v, want %q", tt.s, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitArgs(%q) error: %v", tt.s, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SplitArgs(%q) mismatch (-want +got):\n%s", tt.s, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package tinycli
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"strconv"
+)
+
+// StandardOptions are the values of the common output flags defined by
+// [StandardFlags].
+type StandardOptions struct {
+	Quiet     bool   // suppress informational output
+	Verbose   int    // verbosity level
+	NoColor   bool   // disable color output
+	LogFormat string // log format, "text" or "json"
+}
+
+// A quietValue is the value of the -q and -quiet flags defined by
+// [StandardFlags], through which the Env finds the options.
+type quietValue struct {
+	opts *StandardOptions
+}
+
+func (v *quietValue) String() string {
+	if v.opts == nil {
+		return ""
+	}
+	return strconv.FormatBool(v.opts.Quiet)
+}
+
+func (v *quietValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errParse
+	}
+	v.opts.Quiet = b
+	return nil
+}
+
+func (v *quietValue) Get() any {
+	return v.opts.Quiet
+}
+
+func (v *quietValue) IsBoolFlag() bool { return true }
+
+// StandardFlags defines common output flags, storing their values in opts:
+//
+//	-q, -quiet    suppress informational output
+//	-v            increase verbosity, counted as in -vv
+//	-no-color     disable color output
+//	-log-format   log format: text or json
+//
+// The current values of opts are the flag defaults, with LogFormat defaulting
+// to "text". The Env output helpers respect these flags when they are defined
+// by the executing command or one of its ancestors: Successf and Infof write
+// nothing when quiet, Debugf writes only when verbose, color helpers write no
+// color codes with -no-color, and Logger applies the log format and a level
+// derived from the verbosity.
+func StandardFlags(fs *flag.FlagSet, opts *StandardOptions) {
+	if opts.LogFormat == "" {
+		opts.LogFormat = "text"
+	}
+	q := &quietValue{opts: opts}
+	fs.Var(q, "q", "suppress informational output")
+	fs.Var(q, "quiet", "suppress informational output")
+	CountVar(fs, &opts.Verbose, "v", opts.Verbose, "increase verbosity")
+	fs.BoolVar(&opts.NoColor, "no-color", opts.NoColor, "disable color output")
+	EnumVar(fs, &opts.LogFormat, "log-format", opts.LogFormat, "log format", []string{"text", "json"})
+}
+
+// StandardOptions returns the options set by the [StandardFlags] of the
+// executing command or its nearest ancestor, or the zero options if there are
+// none.
+func (e Env[P]) StandardOptions() StandardOptions {
+	if v, ok := lookupValue[*quietValue](e); ok {
+		return *v.opts
+	}
+	return StandardOptions{}
+}
+
+// Debugf formats and writes a diagnostic message to the Env error output
+// stream, only when the -v flag defined by [StandardFlags] is set.
+func (e Env[P]) Debugf(format string, args ...any) (int, error) {
+	if e.StandardOptions().Verbose == 0 {
+		return 0, nil
+	}
+	return e.Errorf(format, args...)
+}
+
+// Logger returns a logger writing to the Env error output stream, in the log
+// format selected by the [StandardFlags] of the executing command. It logs at
+// the info level, the debug level when verbose, and the warn level when quiet.
+func (e Env[P]) Logger() *slog.Logger {
+	opts := e.StandardOptions()
+	level := slog.LevelInfo
+	switch {
+	case opts.Quiet:
+		level = slog.LevelWarn
+	case opts.Verbose > 0:
+		level = slog.LevelDebug
+	}
+
+	var w io.Writer = io.Discard
+	if e.Err != nil {
+		w = e.Err
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	if opts.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(w, handlerOpts))
+	}
+	return slog.New(slog.NewTextHandler(w, handlerOpts))
+}
@@ -0,0 +1,111 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestStandardFlags(t *testing.T) {
+	type p struct {
+		opts cli.StandardOptions
+	}
+
+	cmdFactory := func(got *cli.StandardOptions) *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				cli.StandardFlags(fs, &p.opts)
+			},
+			Persistent: []string{"q", "quiet", "v", "no-color", "log-format"},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name: "sub",
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus {
+						*got = e.StandardOptions()
+						e.Infof("info\n")
+						e.Successf("done\n")
+						e.Warnf("warning\n")
+						e.Debugf("debug\n")
+						e.Logger().Debug("logged", "n", 1)
+						return cli.ExitSuccess
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		wantOpts   cli.StandardOptions
+		wantOutbuf string
+		wantErrbuf string
+	}{
+		{
+			name:       "defaults",
+			args:       []string{"root", "sub"},
+			wantOpts:   cli.StandardOptions{LogFormat: "text"},
+			wantOutbuf: "info\ndone\n",
+			wantErrbuf: "warning\n",
+		},
+		{
+			name:       "quiet",
+			args:       []string{"root", "sub", "-q"},
+			wantOpts:   cli.StandardOptions{Quiet: true, LogFormat: "text"},
+			wantErrbuf: "warning\n",
+		},
+		{
+			name:       "verbose",
+			args:       []string{"root", "-vv", "-log-format=json", "sub"},
+			wantOpts:   cli.StandardOptions{Verbose: 2, LogFormat: "json"},
+			wantOutbuf: "info\ndone\n",
+			wantErrbuf: "warning\ndebug\n{\"level\":\"DEBUG\",\"msg\":\"logged\",\"n\":1}\n",
+		},
+		{
+			name:       "no_color",
+			args:       []string{"root", "sub", "-no-color"},
+			vars:       map[string]string{"CLICOLOR_FORCE": "1"},
+			wantOpts:   cli.StandardOptions{NoColor: true, LogFormat: "text"},
+			wantOutbuf: "info\ndone\n",
+			wantErrbuf: "warning\n",
+		},
+		{
+			name:       "color",
+			args:       []string{"root", "sub", "-quiet"},
+			vars:       map[string]string{"CLICOLOR_FORCE": "1"},
+			wantOpts:   cli.StandardOptions{Quiet: true, LogFormat: "text"},
+			wantErrbuf: "\x1b[33mwarning\x1b[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				outbuf, errbuf bytes.Buffer
+				got            cli.StandardOptions
+			)
+			e := &cli.Env[*p]{Args: tt.args, Vars: tt.vars, Out: &outbuf, Err: &errbuf, Params: &p{}}
+			if status := cmdFactory(&got).Execute(t.Context(), e); status != cli.ExitSuccess {
+				t.Fatalf("cmd.Execute() = %d, want %d", status, cli.ExitSuccess)
+			}
+			if diff := cmp.Diff(tt.wantOpts, got); diff != "" {
+				t.Errorf("StandardOptions() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+			// drop log timestamps
+			gotErrbuf := regexp.MustCompile(`"time":"[^"]*",`).ReplaceAllString(errbuf.String(), "")
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package tinycli
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxSuggestions bounds the number of names suggested for a mistyped name.
+const maxSuggestions = 3
+
+// suggest returns the candidates that are close to name: those that it is a
+// prefix of, and those within an edit distance of a third of its length,
+// closest first.
+func suggest(name string, candidates []string) []string {
+	type match struct {
+		name string
+		dist int
+	}
+	limit := max(1, len(name)/3)
+	var matches []match
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		if d := editDistance(name, c); d <= limit {
+			matches = append(matches, match{c, d})
+		} else if strings.HasPrefix(c, name) {
+			matches = append(matches, match{c, limit + 1})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.dist, b.dist), strings.Compare(a.name, b.name))
+	})
+
+	names := make([]string, 0, min(len(matches), maxSuggestions))
+	for _, m := range matches[:min(len(matches), maxSuggestions)] {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of single byte insertions, deletions, substitutions, and
+// transpositions of adjacent bytes needed to turn a into b.
+func editDistance(a, b string) int {
+	// rows i-2, i-1, and i of the distance matrix
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+// errUndefinedFlagPrefix begins the errors reported by the flag package for
+// flags that are not defined.
+const errUndefinedFlagPrefix = "flag provided but not defined: -"
+
+// suggestFlag adds a hint to an error reporting an undefined flag: the names of
+// similar flags of c, or else the command that defines the flag, among the
+// ancestors and subcommands of c. Other errors are returned unchanged.
+func (c *invocation[P]) suggestFlag(e *Env[P], err error) error {
+	name, ok := strings.CutPrefix(err.Error(), errUndefinedFlagPrefix)
+	if !ok {
+		return err
+	}
+
+	var names []string
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		if !c.isHidden(f.Name) {
+			names = append(names, f.Name)
+		}
+	})
+	if matches := suggest(name, names); len(matches) > 0 {
+		for i, m := range matches {
+			matches[i] = "-" + m
+		}
+		return fmt.Errorf("%w (did you mean %s?)", err, joinOr(matches))
+	}
+
+	path := e.CommandPath()
+	for i, a := range slices.Backward(e.cmds[:len(e.cmds)-1]) {
+		if a.flagSet().Lookup(name) != nil && !a.isHidden(name) {
+			return fmt.Errorf("%w (-%s is a flag of %q)", err, name, strings.Join(path[:i+1], " "))
+		}
+	}
+	for _, sub := range c.Subcommands {
+		if sub.Hidden || sub.Flags == nil || slices.Contains(sub.HiddenFlags, name) {
+			continue
+		}
+		fs := flag.NewFlagSet(sub.Name, flag.ContinueOnError)
+		if defineFlags(sub, fs, newParams[P]()) && fs.Lookup(name) != nil {
+			return fmt.Errorf("%w (-%s is a flag of %q)", err, name, strings.Join(append(path, sub.Name), " "))
+		}
+	}
+	return err
+}
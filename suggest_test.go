@@ -0,0 +1,137 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_flagSuggestions(t *testing.T) {
+	type p struct {
+		Port    int
+		Proto   string
+		Verbose bool
+		Force   bool
+		Debug   bool
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				fs.BoolVar(&p.Verbose, "verbose", false, "")
+			},
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name:  "serve",
+					Usage: "serve usage",
+					Flags: func(fs *flag.FlagSet, p *p) {
+						fs.IntVar(&p.Port, "port", 0, "")
+						fs.StringVar(&p.Proto, "proto", "", "")
+						fs.BoolVar(&p.Debug, "debug", false, "")
+					},
+					HiddenFlags: []string{"debug"},
+					Subcommands: []*cli.Command[*p]{
+						{
+							Name: "reset",
+							Flags: func(fs *flag.FlagSet, p *p) {
+								fs.BoolVar(&p.Force, "force", false, "")
+							},
+						},
+					},
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantErrbuf string
+	}{
+		{
+			name:       "transposed",
+			args:       []string{"root", "serve", "-prot=80"},
+			wantErrbuf: "serve usage\nflag provided but not defined: -prot (did you mean -port or -proto?)\n",
+		},
+		{
+			name:       "typo",
+			args:       []string{"root", "-verbsoe", "serve"},
+			wantErrbuf: "root usage\nflag provided but not defined: -verbsoe (did you mean -verbose?)\n",
+		},
+		{
+			name:       "prefix",
+			args:       []string{"root", "serve", "-p"},
+			wantErrbuf: "serve usage\nflag provided but not defined: -p (did you mean -port or -proto?)\n",
+		},
+		{
+			name:       "parent",
+			args:       []string{"root", "serve", "-verbose"},
+			wantErrbuf: "serve usage\nflag provided but not defined: -verbose (-verbose is a flag of \"root\")\n",
+		},
+		{
+			name:       "child",
+			args:       []string{"root", "serve", "-force", "reset"},
+			wantErrbuf: "serve usage\nflag provided but not defined: -force (-force is a flag of \"root serve reset\")\n",
+		},
+		{
+			name:       "hidden",
+			args:       []string{"root", "serve", "-debgu"},
+			wantErrbuf: "serve usage\nflag provided but not defined: -debgu\n",
+		},
+		{
+			name:       "no_match",
+			args:       []string{"root", "serve", "-xyz"},
+			wantErrbuf: "serve usage\nflag provided but not defined: -xyz\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[*p]{Args: tt.args, Err: &errbuf, Params: &p{}}
+			if got := cmdFactory().Execute(t.Context(), e); got != cli.ExitUsage {
+				t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitUsage)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Execute_flagSuggestionsPanickingFlags(t *testing.T) {
+	type opts struct{ Force bool }
+	cmd := &cli.Command[any]{
+		Name:  "root",
+		Usage: "root usage",
+		Subcommands: []*cli.Command[any]{
+			{
+				Name: "reset",
+				Flags: func(fs *flag.FlagSet, p any) {
+					fs.BoolVar(&p.(*opts).Force, "force", false, "")
+				},
+			},
+		},
+	}
+
+	var errbuf bytes.Buffer
+	e := &cli.Env[any]{Args: []string{"root", "-force", "reset"}, Err: &errbuf, Params: &opts{}}
+	if got := cmd.Execute(t.Context(), e); got != cli.ExitUsage {
+		t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitUsage)
+	}
+	if diff := cmp.Diff("root usage\nflag provided but not defined: -force\n", errbuf.String()); diff != "" {
+		t.Errorf("error output mismatch (-want +got):\n%s", diff)
+	}
+
+	spec := cli.Spec(cmd)
+	if got := spec.Subcommands[0]; got.Name != "reset" || len(got.Flags) != 0 {
+		t.Errorf("Spec() subcommand = %+v, want reset without flags", got)
+	}
+}
@@ -0,0 +1,71 @@
+package tinycli
+
+import (
+	"context"
+	"time"
+)
+
+// Default settings for a [RestartPolicy].
+const (
+	DefaultRestarts          = 5
+	DefaultRestartBackoff    = time.Second
+	DefaultRestartMaxBackoff = time.Minute
+)
+
+// A RestartPolicy configures how [Supervise] restarts a failed action.
+type RestartPolicy struct {
+	MaxRestarts int           // restarts allowed; zero means DefaultRestarts, negative means no limit
+	Backoff     time.Duration // delay before the first restart; zero means DefaultRestartBackoff
+	MaxBackoff  time.Duration // bound on the doubling delay; zero means DefaultRestartMaxBackoff
+}
+
+// Supervise wraps a long-running [ActionFunc], such as a service loop, so that
+// it is restarted when it fails.
+//
+// The action is considered to have failed when it returns a status other than
+// ExitSuccess. Failures are reported to the Env error output stream, and the
+// action is called again after a delay that doubles with each restart, until
+// the policy's MaxRestarts is reached. The status of the last call is
+// returned. When ctx is canceled, the action is not restarted and Supervise
+// returns ExitCanceled.
+func Supervise[P any](action ActionFunc[P], policy RestartPolicy) ActionFunc[P] {
+	backoff := policy.Backoff
+	if backoff <= 0 {
+		backoff = DefaultRestartBackoff
+	}
+	maxRestarts := policy.MaxRestarts
+	if maxRestarts == 0 {
+		maxRestarts = DefaultRestarts
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRestartMaxBackoff
+	}
+
+	return func(ctx context.Context, e *Env[P]) ExitStatus {
+		delay := backoff
+		for restarts := 0; ; restarts++ {
+			status := action(ctx, e)
+			if status == ExitSuccess {
+				return status
+			}
+			if ctx.Err() != nil {
+				return ExitCanceled
+			}
+			if maxRestarts >= 0 && restarts >= maxRestarts {
+				e.Errorf("action failed with status %d, giving up after %d restarts\n", status, restarts)
+				return status
+			}
+			e.Errorf("action failed with status %d, restarting in %v\n", status, delay)
+
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ExitCanceled
+			case <-t.C:
+			}
+			delay = min(2*delay, maxBackoff)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestSupervise(t *testing.T) {
+	// run executes a supervised action that fails until it has been called
+	// succeedAfter times, with succeedAfter < 0 failing forever
+	run := func(ctx context.Context, policy cli.RestartPolicy, succeedAfter int) (cli.ExitStatus, int, string) {
+		var calls int
+		action := func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+			calls++
+			if succeedAfter >= 0 && calls > succeedAfter {
+				return cli.ExitSuccess
+			}
+			return cli.ExitFailure
+		}
+		var errOut bytes.Buffer
+		cmd := &cli.Command[any]{
+			Name:   "root",
+			Action: cli.Supervise(action, policy),
+		}
+		status := cmd.Execute(ctx, &cli.Env[any]{Args: []string{"root"}, Err: &errOut})
+		return status, calls, errOut.String()
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		policy := cli.RestartPolicy{MaxRestarts: 3, Backoff: time.Millisecond}
+		status, calls, errOut := run(t.Context(), policy, 2)
+		if want, got := cli.ExitSuccess, status; want != got {
+			t.Errorf("exit status = %d, want %d", got, want)
+		}
+		if want, got := 3, calls; want != got {
+			t.Errorf("action called %d times, want %d", got, want)
+		}
+		if want := "action failed with status 1, restarting in 2ms\n"; !strings.Contains(errOut, want) {
+			t.Errorf("error output %q does not contain %q", errOut, want)
+		}
+	})
+
+	t.Run("gives_up", func(t *testing.T) {
+		policy := cli.RestartPolicy{MaxRestarts: 2, Backoff: time.Millisecond}
+		status, calls, errOut := run(t.Context(), policy, -1)
+		if want, got := cli.ExitFailure, status; want != got {
+			t.Errorf("exit status = %d, want %d", got, want)
+		}
+		if want, got := 3, calls; want != got {
+			t.Errorf("action called %d times, want %d", got, want)
+		}
+		if want := "giving up after 2 restarts\n"; !strings.HasSuffix(errOut, want) {
+			t.Errorf("error output %q does not end with %q", errOut, want)
+		}
+	})
+
+	t.Run("default_restarts", func(t *testing.T) {
+		policy := cli.RestartPolicy{Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+		status, calls, _ := run(t.Context(), policy, -1)
+		if want, got := cli.ExitFailure, status; want != got {
+			t.Errorf("exit status = %d, want %d", got, want)
+		}
+		if want, got := cli.DefaultRestarts+1, calls; want != got {
+			t.Errorf("action called %d times, want %d", got, want)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		policy := cli.RestartPolicy{MaxRestarts: -1, Backoff: time.Hour}
+		status, calls, _ := run(ctx, policy, -1)
+		if want, got := cli.ExitCanceled, status; want != got {
+			t.Errorf("exit status = %d, want %d", got, want)
+		}
+		if want, got := 1, calls; want != got {
+			t.Errorf("action called %d times, want %d", got, want)
+		}
+	})
+}
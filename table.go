@@ -0,0 +1,149 @@
+package tinycli
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tablePadding is the number of spaces between table columns.
+const tablePadding = 3
+
+// A Table writes aligned columns of text. Rows are written to it as lines of
+// tab-separated cells, as with [text/tabwriter], and written out by Flush.
+// It is created by [Env.Table].
+type Table struct {
+	out       io.Writer
+	headers   []string
+	rows      [][]string
+	partial   []byte
+	width     int
+	noHeaders bool
+}
+
+// Table returns a Table that flushes to the Env standard output stream, with a
+// header row of the given headers, if any.
+//
+// Lines are truncated to the width of the Env Terminal, when it is known, and
+// the header row is omitted when the -no-headers flag defined by
+// [NoHeadersFlag] is set:
+//
+//	t := e.Table("NAME", "PORT")
+//	for _, s := range servers {
+//		fmt.Fprintf(t, "%s\t%d\n", s.Name, s.Port)
+//	}
+//	return t.Flush()
+func (e Env[P]) Table(headers ...string) *Table {
+	t := &Table{out: e.Out, headers: headers, width: e.Terminal.Width}
+	if v, ok := lookupValue[*noHeadersValue](e); ok {
+		t.noHeaders = bool(*v.p)
+	}
+	return t
+}
+
+// Write adds the complete lines in p to the table as rows of tab-separated
+// cells. A trailing partial line is kept until it is completed or flushed.
+func (t *Table) Write(p []byte) (int, error) {
+	t.partial = append(t.partial, p...)
+	for {
+		line, rest, ok := bytes.Cut(t.partial, []byte("\n"))
+		if !ok {
+			break
+		}
+		t.rows = append(t.rows, strings.Split(string(line), "\t"))
+		t.partial = rest
+	}
+	return len(p), nil
+}
+
+// Flush writes the header row and the rows added since the last Flush, with
+// their columns aligned.
+func (t *Table) Flush() error {
+	if len(t.partial) > 0 {
+		t.Write([]byte("\n"))
+	}
+	rows := t.rows
+	if len(t.headers) > 0 && !t.noHeaders {
+		rows = append([][]string{t.headers}, rows...)
+	}
+	t.rows = nil
+	if t.out == nil || len(rows) == 0 {
+		return nil
+	}
+
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+tablePadding))
+			}
+		}
+		buf.WriteString(truncate(strings.TrimRight(line.String(), " "), t.width))
+		buf.WriteByte('\n')
+	}
+	_, err := t.out.Write(buf.Bytes())
+	return err
+}
+
+// truncate shortens s to width runes, ending it with an ellipsis, if it is
+// longer. A width of 0 or less leaves s unchanged.
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// A noHeadersValue is the value of a flag defined by [NoHeadersFlag].
+type noHeadersValue struct {
+	p *bool
+}
+
+func (v *noHeadersValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v.p)
+}
+
+func (v *noHeadersValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return errParse
+	}
+	*v.p = b
+	return nil
+}
+
+func (v *noHeadersValue) Get() any {
+	return *v.p
+}
+
+func (v *noHeadersValue) IsBoolFlag() bool { return true }
+
+// NoHeadersFlag defines a -no-headers flag, which omits the header row of
+// tables written by [Env.Table] and [Env.Emit]. Its value is stored in p,
+// which may be nil.
+func NoHeadersFlag(fs *flag.FlagSet, p *bool) {
+	if p == nil {
+		p = new(bool)
+	}
+	*p = false
+	fs.Var(&noHeadersValue{p: p}, "no-headers", "omit table headers")
+}
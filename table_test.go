@@ -0,0 +1,100 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_Table(t *testing.T) {
+	type server struct {
+		name string
+		port int
+		desc string
+	}
+	servers := []server{
+		{name: "web", port: 8080, desc: "public web server"},
+		{name: "database", port: 5432, desc: ""},
+	}
+
+	cmdFactory := func() *cli.Command[any] {
+		return &cli.Command[any]{
+			Name: "root",
+			Flags: func(fs *flag.FlagSet, _ any) {
+				cli.NoHeadersFlag(fs, nil)
+			},
+			ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+				t := e.Table("NAME", "PORT", "DESCRIPTION")
+				for _, s := range servers {
+					fmt.Fprintf(t, "%s\t%d\t", s.name, s.port)
+					fmt.Fprintf(t, "%s\n", s.desc)
+				}
+				return t.Flush()
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		width      int
+		wantOutbuf string
+	}{
+		{
+			name: "headers",
+			args: []string{"root"},
+			wantOutbuf: "NAME       PORT   DESCRIPTION\n" +
+				"web        8080   public web server\n" +
+				"database   5432\n",
+		},
+		{
+			name: "no_headers",
+			args: []string{"root", "-no-headers"},
+			wantOutbuf: "web        8080   public web server\n" +
+				"database   5432\n",
+		},
+		{
+			name:  "truncated",
+			args:  []string{"root"},
+			width: 24,
+			wantOutbuf: "NAME       PORT   DESCR…\n" +
+				"web        8080   publi…\n" +
+				"database   5432\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outbuf bytes.Buffer
+			e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Terminal: cli.Terminal{Width: tt.width}}
+			if got := cmdFactory().Execute(t.Context(), e); got != cli.ExitSuccess {
+				t.Errorf("cmd.Execute() = %d, want %d", got, cli.ExitSuccess)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTable_Flush(t *testing.T) {
+	var outbuf bytes.Buffer
+	e := cli.Env[any]{Out: &outbuf}
+
+	tbl := e.Table()
+	fmt.Fprint(tbl, "a\tbb\nccc\td")
+	if outbuf.Len() != 0 {
+		t.Errorf("table wrote %q before Flush", outbuf.String())
+	}
+	if err := tbl.Flush(); err != nil {
+		t.Fatalf("table.Flush() error: %v", err)
+	}
+	if diff := cmp.Diff("a     bb\nccc   d\n", outbuf.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package tinycli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func isTerminal(f *os.File) bool {
+	var t syscall.Termios
+	return ioctl(f, syscall.TIOCGETA, uintptr(unsafe.Pointer(&t))) == nil
+}
+
+func disableEcho(f *os.File) (restore func(), err error) {
+	var t syscall.Termios
+	if err := ioctl(f, syscall.TIOCGETA, uintptr(unsafe.Pointer(&t))); err != nil {
+		return nil, err
+	}
+	saved := t
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON
+	if err := ioctl(f, syscall.TIOCSETA, uintptr(unsafe.Pointer(&t))); err != nil {
+		return nil, err
+	}
+	return func() { ioctl(f, syscall.TIOCSETA, uintptr(unsafe.Pointer(&saved))) }, nil
+}
@@ -0,0 +1,20 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package tinycli
+
+import (
+	"errors"
+	"os"
+)
+
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func disableEcho(f *os.File) (restore func(), err error) {
+	return nil, errors.ErrUnsupported
+}
+
+func terminalSize(f *os.File) (width, height int, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
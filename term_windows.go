@@ -0,0 +1,66 @@
+package tinycli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+)
+
+// enableEchoInput is the console input mode flag that echoes typed characters.
+const enableEchoInput = 0x4
+
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
+
+func disableEcho(f *os.File) (restore func(), err error) {
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return nil, err
+	}
+	if err := setConsoleMode(h, mode&^enableEchoInput); err != nil {
+		return nil, err
+	}
+	return func() { setConsoleMode(h, mode) }, nil
+}
+
+func setConsoleMode(h syscall.Handle, mode uint32) error {
+	if r, _, err := procSetConsoleMode.Call(uintptr(h), uintptr(mode)); r == 0 {
+		return err
+	}
+	return nil
+}
+
+type coord struct {
+	x, y int16
+}
+
+type smallRect struct {
+	left, top, right, bottom int16
+}
+
+// consoleScreenBufferInfo is the CONSOLE_SCREEN_BUFFER_INFO structure.
+type consoleScreenBufferInfo struct {
+	size              coord
+	cursorPosition    coord
+	attributes        uint16
+	window            smallRect
+	maximumWindowSize coord
+}
+
+func terminalSize(f *os.File) (width, height int, err error) {
+	var info consoleScreenBufferInfo
+	if r, _, err := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info))); r == 0 {
+		return 0, 0, err
+	}
+	w := info.window
+	return int(w.right-w.left) + 1, int(w.bottom-w.top) + 1, nil
+}
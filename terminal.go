@@ -0,0 +1,33 @@
+package tinycli
+
+import "os"
+
+// A Terminal describes the terminal connected to an output stream.
+type Terminal struct {
+	Width  int  // width in columns, or 0 if unknown
+	Height int  // height in rows, or 0 if unknown
+	Color  bool // whether the terminal supports ANSI color codes
+}
+
+// IsTerminal reports whether stream, such as the Env In, Out, or Err stream, is
+// an interactive terminal.
+func (e Env[P]) IsTerminal(stream any) bool {
+	if d, ok := stream.(*detachableWriter); ok {
+		stream = d.w
+	}
+	f, ok := stream.(*os.File)
+	return ok && isTerminal(f)
+}
+
+// newTerminal returns a description of the terminal connected to f, using the
+// TERM var to detect color support, or the zero Terminal if f is not a
+// terminal.
+func newTerminal(f *os.File, vars map[string]string) Terminal {
+	if !isTerminal(f) {
+		return Terminal{}
+	}
+	var t Terminal
+	t.Width, t.Height, _ = terminalSize(f)
+	t.Color = vars["TERM"] != "dumb"
+	return t
+}
@@ -0,0 +1,46 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestEnv_IsTerminal(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	tests := []struct {
+		name   string
+		stream any
+	}{
+		{name: "nil", stream: nil},
+		{name: "nil_file", stream: (*os.File)(nil)},
+		{name: "dev_null", stream: devNull},
+		{name: "pipe_reader", stream: r},
+		{name: "pipe_writer", stream: w},
+		{name: "buffer", stream: io.Writer(&bytes.Buffer{})},
+	}
+
+	var e cli.Env[any]
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if e.IsTerminal(tt.stream) {
+				t.Errorf("env.IsTerminal(%v)=true, want false", tt.stream)
+			}
+		})
+	}
+}
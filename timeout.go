@@ -0,0 +1,82 @@
+package tinycli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+)
+
+// A timeoutError is the cause of the context of an action canceled because
+// its timeout elapsed.
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return "timed out after " + e.timeout.String()
+}
+
+// A timeoutValue is the value of a flag defined by [TimeoutFlag].
+type timeoutValue struct {
+	p *time.Duration
+}
+
+func (v *timeoutValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return v.p.String()
+}
+
+func (v *timeoutValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errParse
+	}
+	if d < 0 {
+		return errors.New("must not be negative")
+	}
+	*v.p = d
+	return nil
+}
+
+func (v *timeoutValue) Get() any {
+	return *v.p
+}
+
+// TimeoutFlag defines a -timeout flag bounding the time of the action of the
+// executing command, overriding its Timeout when non-zero. Its value is
+// stored in p, which may be nil, and defaults to value.
+func TimeoutFlag(fs *flag.FlagSet, p *time.Duration, value time.Duration) {
+	if p == nil {
+		p = new(time.Duration)
+	}
+	*p = value
+	fs.Var(&timeoutValue{p: p}, "timeout", "time limit of the command, such as 30s; 0 for none")
+}
+
+// timeout returns the time limit of the action of c: the value of the
+// [TimeoutFlag] of c or its nearest ancestor when non-zero, or else c.Timeout.
+func (c *invocation[P]) timeout(e *Env[P]) time.Duration {
+	if v, ok := lookupValue[*timeoutValue](*e); ok && *v.p > 0 {
+		return *v.p
+	}
+	return c.Timeout
+}
+
+// withTimeout returns a copy of ctx that is canceled when the timeout of c
+// elapses, if it has one.
+func (c *invocation[P]) withTimeout(ctx context.Context, e *Env[P]) (context.Context, context.CancelFunc) {
+	timeout := c.timeout(e)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, timeout, &timeoutError{timeout: timeout})
+}
+
+// timedOut reports whether ctx was canceled by a timeout set by withTimeout.
+func timedOut(ctx context.Context) bool {
+	var err *timeoutError
+	return errors.As(context.Cause(ctx), &err)
+}
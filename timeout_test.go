@@ -0,0 +1,131 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestCommand_Execute_timeout(t *testing.T) {
+	wait := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name       string
+		cmd        *cli.Command[any]
+		args       []string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name: "action_err",
+			cmd: &cli.Command[any]{
+				Name:    "root",
+				Timeout: time.Millisecond,
+				ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+					return wait(ctx)
+				},
+			},
+			wantErrbuf: "\ntimed out after 1ms\n",
+			wantStatus: cli.ExitTimeout,
+		},
+		{
+			name: "action_status",
+			cmd: &cli.Command[any]{
+				Name:        "root",
+				Timeout:     time.Millisecond,
+				UsagePolicy: cli.UsageNever,
+				Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+					if err := wait(ctx); err != nil {
+						return cli.ExitFailure
+					}
+					return cli.ExitSuccess
+				},
+			},
+			wantErrbuf: "timed out after 1ms\n",
+			wantStatus: cli.ExitTimeout,
+		},
+		{
+			name: "success_within_timeout",
+			cmd: &cli.Command[any]{
+				Name:    "root",
+				Timeout: time.Minute,
+				ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+					return nil
+				},
+			},
+		},
+		{
+			name: "flag_override",
+			cmd: &cli.Command[any]{
+				Name:        "root",
+				Timeout:     time.Minute,
+				UsagePolicy: cli.UsageNever,
+				Flags: func(fs *flag.FlagSet, _ any) {
+					cli.TimeoutFlag(fs, nil, 0)
+				},
+				Subcommands: []*cli.Command[any]{
+					{
+						Name: "sub",
+						ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+							return wait(ctx)
+						},
+					},
+				},
+			},
+			args:       []string{"-timeout", "2ms", "sub"},
+			wantErrbuf: "timed out after 2ms\n",
+			wantStatus: cli.ExitTimeout,
+		},
+		{
+			name: "invalid_flag",
+			cmd: &cli.Command[any]{
+				Name:        "root",
+				UsagePolicy: cli.UsageNever,
+				Flags: func(fs *flag.FlagSet, _ any) {
+					cli.TimeoutFlag(fs, nil, 0)
+				},
+			},
+			args:       []string{"-timeout", "-1s"},
+			wantErrbuf: "invalid value \"-1s\" for flag -timeout: must not be negative\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name: "canceled_without_timeout",
+			cmd: &cli.Command[any]{
+				Name:        "root",
+				Timeout:     time.Minute,
+				UsagePolicy: cli.UsageNever,
+				ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+					return context.Canceled
+				},
+			},
+			wantErrbuf: "context canceled\n",
+			wantStatus: cli.ExitCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: append([]string{"root"}, tt.args...), Err: &errbuf}
+			if got := tt.cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
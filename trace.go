@@ -0,0 +1,23 @@
+package tinycli
+
+import (
+	"context"
+	"slices"
+)
+
+// A TraceFunc is a hook that starts a tracing span for an action, such as an
+// OpenTelemetry span named after the command path. It returns the context
+// passed to the action, carrying the span, and a func that ends the span with
+// the exit status of the action and the error it failed with, if any.
+type TraceFunc[P any] = func(ctx context.Context, e *Env[P]) (context.Context, func(ExitStatus, error))
+
+// traceFunc returns the Trace hook of the nearest visited command that sets
+// one.
+func (c *Command[P]) traceFunc(e *Env[P]) TraceFunc[P] {
+	for _, cmd := range slices.Backward(e.cmds) {
+		if cmd.Trace != nil {
+			return cmd.Trace
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+type spanKey struct{}
+
+type span struct {
+	name   string
+	status cli.ExitStatus
+	err    string
+	ended  bool
+}
+
+func TestCommand_Execute_trace(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantSpans  []span
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name:      "success",
+			args:      []string{"foo", "serve"},
+			wantSpans: []span{{name: "foo serve", ended: true}},
+		},
+		{
+			name:       "error",
+			args:       []string{"foo", "fail"},
+			wantSpans:  []span{{name: "foo fail", status: cli.ExitFailure, err: "boom", ended: true}},
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "panic",
+			args:       []string{"foo", "panic"},
+			wantSpans:  []span{{name: "foo panic", status: cli.ExitFailure, err: "panic: oops", ended: true}},
+			wantStatus: cli.ExitFailure,
+		},
+		{
+			name:       "usage_error",
+			args:       []string{"foo", "serve", "-bad"},
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var spans []*span
+			cmd := &cli.Command[any]{
+				Name: "foo",
+				Trace: func(ctx context.Context, e *cli.Env[any]) (context.Context, func(cli.ExitStatus, error)) {
+					s := &span{name: strings.Join(e.CommandPath(), " ")}
+					spans = append(spans, s)
+					return context.WithValue(ctx, spanKey{}, s), func(status cli.ExitStatus, err error) {
+						s.status, s.ended = status, true
+						if err != nil {
+							s.err = err.Error()
+						}
+					}
+				},
+				Subcommands: []*cli.Command[any]{
+					{
+						Name: "serve",
+						Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+							if s, _ := ctx.Value(spanKey{}).(*span); s == nil || s.name != "foo serve" {
+								t.Errorf("action context span = %v, want foo serve", s)
+							}
+							return cli.ExitSuccess
+						},
+					},
+					{
+						Name: "fail",
+						ActionE: func(ctx context.Context, e *cli.Env[any]) error {
+							return errors.New("boom")
+						},
+					},
+					{
+						Name: "panic",
+						Action: func(ctx context.Context, e *cli.Env[any]) cli.ExitStatus {
+							panic("oops")
+						},
+					},
+				},
+			}
+			var errbuf bytes.Buffer
+			e := &cli.Env[any]{Args: tt.args, Err: &errbuf}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			var got []span
+			for _, s := range spans {
+				got = append(got, *s)
+			}
+			if diff := cmp.Diff(tt.wantSpans, got, cmp.AllowUnexported(span{})); diff != "" {
+				t.Errorf("spans mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package tinycli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// A validatedValue is a flag.Value whose input is checked before it is set.
+type validatedValue struct {
+	flag.Value
+	check func(string) error
+}
+
+func (v *validatedValue) Set(s string) error {
+	if err := v.check(s); err != nil {
+		return err
+	}
+	return v.Value.Set(s)
+}
+
+func (v *validatedValue) IsBoolFlag() bool {
+	return isBoolFlag(v.Value)
+}
+
+func (v *validatedValue) Get() any {
+	if g, ok := v.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return v.Value.String()
+}
+
+// Validated returns a flag.Value that calls check with the input of each Set
+// call, before setting value, and fails with the error check returns. Errors
+// are reported like other invalid values, attributed to the flag, env var, or
+// config key the input is from:
+//
+//	fs.IntVar(&p.port, "port", 8080, "listen port")
+//	f := fs.Lookup("port")
+//	f.Value = Validated(f.Value, InRange(1, 65535))
+//
+//	// invalid value "0" for var $FOO_PORT: must be between 1 and 65535
+//
+// [InRange], [Matches], [OneOf], and [FileExists] provide common checks.
+func Validated(value flag.Value, check func(string) error) flag.Value {
+	return &validatedValue{Value: value, check: check}
+}
+
+// InRange returns a check for [Validated] accepting numbers between lo and
+// hi, inclusive.
+func InRange(lo, hi float64) func(string) error {
+	return func(s string) error {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return errParse
+		}
+		if n < lo || n > hi {
+			return fmt.Errorf("must be between %v and %v", lo, hi)
+		}
+		return nil
+	}
+}
+
+// Matches returns a check for [Validated] accepting input matched by re.
+func Matches(re *regexp.Regexp) func(string) error {
+	return func(s string) error {
+		if !re.MatchString(s) {
+			return fmt.Errorf("must match %s", re)
+		}
+		return nil
+	}
+}
+
+// OneOf returns a check for [Validated] accepting only the given choices.
+func OneOf(choices ...string) func(string) error {
+	return func(s string) error {
+		if !slices.Contains(choices, s) {
+			return errors.New("must be one of " + quoteChoices(choices))
+		}
+		return nil
+	}
+}
+
+// FileExists is a check for [Validated] accepting paths of existing files
+// that are not directories.
+func FileExists(s string) error {
+	info, err := os.Stat(s)
+	if err != nil {
+		return errors.New("no such file")
+	}
+	if info.IsDir() {
+		return errors.New("is a directory")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestValidated(t *testing.T) {
+	type params struct {
+		port  int
+		name  string
+		level string
+		file  string
+		tags  []string
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		vars       map[string]string
+		config     map[string]string
+		wantErrbuf string
+		wantStatus cli.ExitStatus
+	}{
+		{
+			name: "valid",
+			args: []string{"foo", "-port", "80", "-name", "web-1", "-level", "info", "-file", file, "-tags", "a,b"},
+		},
+		{
+			name:       "range_flag",
+			args:       []string{"foo", "-port", "0"},
+			wantErrbuf: "invalid value \"0\" for flag -port: must be between 1 and 65535\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "range_parse",
+			args:       []string{"foo", "-port", "http"},
+			wantErrbuf: "invalid value \"http\" for flag -port: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "range_var",
+			args:       []string{"foo"},
+			vars:       map[string]string{"FOO_PORT": "70000"},
+			wantErrbuf: "invalid value \"70000\" for var $FOO_PORT: must be between 1 and 65535\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "matches_config",
+			args:       []string{"foo"},
+			config:     map[string]string{"name": "Web 1"},
+			wantErrbuf: "invalid value \"Web 1\" for config key name: must match ^[a-z0-9-]+$\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "one_of",
+			args:       []string{"foo", "-level", "loud"},
+			wantErrbuf: "invalid value \"loud\" for flag -level: must be one of \"debug\", \"info\", or \"warn\"\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "file_exists",
+			args:       []string{"foo", "-file", filepath.Join(dir, "missing.json")},
+			wantErrbuf: "invalid value \"" + filepath.Join(dir, "missing.json") + "\" for flag -file: no such file\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "file_is_dir",
+			args:       []string{"foo", "-file", dir},
+			wantErrbuf: "invalid value \"" + dir + "\" for flag -file: is a directory\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "list_var",
+			args:       []string{"foo"},
+			vars:       map[string]string{"FOO_TAGS": "a,,b"},
+			wantErrbuf: "invalid value \"a,,b\" for var $FOO_TAGS: must match ^[a-z]+(,[a-z]+)*$\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validate := func(fs *flag.FlagSet, name string, check func(string) error) {
+				f := fs.Lookup(name)
+				f.Value = cli.Validated(f.Value, check)
+			}
+			cmd := &cli.Command[*params]{
+				Name:        "foo",
+				UsagePolicy: cli.UsageNever,
+				Flags: func(fs *flag.FlagSet, p *params) {
+					fs.IntVar(&p.port, "port", 8080, "")
+					fs.StringVar(&p.name, "name", "web", "")
+					fs.StringVar(&p.level, "level", "info", "")
+					fs.StringVar(&p.file, "file", "", "")
+					cli.StringSliceVar(fs, &p.tags, "tags", nil, "", ",")
+					validate(fs, "port", cli.InRange(1, 65535))
+					validate(fs, "name", cli.Matches(regexp.MustCompile(`^[a-z0-9-]+$`)))
+					validate(fs, "level", cli.OneOf("debug", "info", "warn"))
+					validate(fs, "file", cli.FileExists)
+					validate(fs, "tags", cli.Matches(regexp.MustCompile(`^[a-z]+(,[a-z]+)*$`)))
+				},
+				Vars: map[string]string{"port": "FOO_PORT", "tags": "FOO_TAGS"},
+				Config: func(e *cli.Env[*params]) (map[string]string, error) {
+					return tt.config, nil
+				},
+				Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+					return cli.ExitSuccess
+				},
+			}
+			var errbuf bytes.Buffer
+			e := &cli.Env[*params]{Args: tt.args, Vars: tt.vars, Err: &errbuf, Params: &params{}}
+			if got := cmd.Execute(t.Context(), e); got != tt.wantStatus {
+				t.Errorf("cmd.Execute() = %d, want %d", got, tt.wantStatus)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, errbuf.String()); diff != "" {
+				t.Errorf("error output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,1183 @@
+package tinycli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Locale describes how numbers and dates are written in a region, for flags
+// defined with [LocaleIntVar], [LocaleFloatVar], and [LocaleDateVar]. Flags
+// defined with the zero Locale use the Locale of the command that defines
+// them, if any, and otherwise accept numbers as written by the strconv
+// package and dates in the [time.DateOnly] layout.
+type Locale struct {
+	GroupSeparator   string   // digit group separator, such as "," or "."
+	DecimalSeparator string   // decimal separator, such as "." or ","
+	DateLayouts      []string // accepted date layouts, as for time.Parse
+}
+
+var (
+	// LocaleUS formats numbers like "1,234.5" and dates like "01/02/2006".
+	LocaleUS = Locale{
+		GroupSeparator:   ",",
+		DecimalSeparator: ".",
+		DateLayouts:      []string{"01/02/2006", time.DateOnly},
+	}
+
+	// LocaleDE formats numbers like "1.234,5" and dates like "02.01.2006".
+	LocaleDE = Locale{
+		GroupSeparator:   ".",
+		DecimalSeparator: ",",
+		DateLayouts:      []string{"02.01.2006", time.DateOnly},
+	}
+
+	// LocaleFR formats numbers like "1 234,5" and dates like "02/01/2006".
+	LocaleFR = Locale{
+		GroupSeparator:   " ",
+		DecimalSeparator: ",",
+		DateLayouts:      []string{"02/01/2006", time.DateOnly},
+	}
+)
+
+// isZero reports whether l is the zero Locale.
+func (l Locale) isZero() bool {
+	return l.GroupSeparator == "" && l.DecimalSeparator == "" && len(l.DateLayouts) == 0
+}
+
+// dateLayouts returns the accepted date layouts of l.
+func (l Locale) dateLayouts() []string {
+	if len(l.DateLayouts) == 0 {
+		return []string{time.DateOnly}
+	}
+	return l.DateLayouts
+}
+
+// A localeValue is a flag value that accepts input written in a Locale.
+type localeValue interface {
+	// inheritLocale sets the Locale of the value to l, unless the value
+	// was defined with its own.
+	inheritLocale(l Locale)
+}
+
+var errDigitGrouping = errors.New("invalid digit grouping")
+
+// normalizeNumber converts s from the locale's number format to the format
+// accepted by the strconv package.
+func (l Locale) normalizeNumber(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	var sign string
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	intPart, fracPart, hasFrac := s, "", false
+	if l.DecimalSeparator != "" {
+		intPart, fracPart, hasFrac = strings.Cut(s, l.DecimalSeparator)
+	}
+
+	if l.GroupSeparator != "" && strings.Contains(intPart, l.GroupSeparator) {
+		groups := strings.Split(intPart, l.GroupSeparator)
+		if len(groups[0]) < 1 || len(groups[0]) > 3 {
+			return "", errDigitGrouping
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				return "", errDigitGrouping
+			}
+		}
+		intPart = strings.Join(groups, "")
+	}
+
+	if hasFrac {
+		return sign + intPart + "." + fracPart, nil
+	}
+	return sign + intPart, nil
+}
+
+// formatNumber converts s from the format produced by the strconv package to
+// the locale's number format, without digit grouping.
+func (l Locale) formatNumber(s string) string {
+	if l.DecimalSeparator == "" {
+		return s
+	}
+	return strings.Replace(s, ".", l.DecimalSeparator, 1)
+}
+
+type localeIntValue struct {
+	p   *int
+	loc Locale
+}
+
+func (v *localeIntValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strconv.Itoa(*v.p)
+}
+
+func (v *localeIntValue) Set(s string) error {
+	n, err := v.loc.normalizeNumber(s)
+	if err != nil {
+		return err
+	}
+	i, err := strconv.ParseInt(n, 10, strconv.IntSize)
+	if err != nil {
+		return numError(err)
+	}
+	*v.p = int(i)
+	return nil
+}
+
+func (v *localeIntValue) inheritLocale(l Locale) {
+	if v.loc.isZero() {
+		v.loc = l
+	}
+}
+
+func (v *localeIntValue) Get() any {
+	return *v.p
+}
+
+// LocaleIntVar defines an int flag with specified name, default value, and
+// usage string, accepting decimal values written in the format of loc. The
+// argument p points to an int variable in which to store the value of the flag.
+func LocaleIntVar(fs *flag.FlagSet, p *int, name string, value int, usage string, loc Locale) {
+	*p = value
+	fs.Var(&localeIntValue{p: p, loc: loc}, name, usage)
+}
+
+type localeFloatValue struct {
+	p   *float64
+	loc Locale
+}
+
+func (v *localeFloatValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return v.loc.formatNumber(strconv.FormatFloat(*v.p, 'g', -1, 64))
+}
+
+func (v *localeFloatValue) Set(s string) error {
+	n, err := v.loc.normalizeNumber(s)
+	if err != nil {
+		return err
+	}
+	f, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return numError(err)
+	}
+	*v.p = f
+	return nil
+}
+
+func (v *localeFloatValue) inheritLocale(l Locale) {
+	if v.loc.isZero() {
+		v.loc = l
+	}
+}
+
+func (v *localeFloatValue) Get() any {
+	return *v.p
+}
+
+// LocaleFloatVar defines a float64 flag with specified name, default value,
+// and usage string, accepting values written in the format of loc. The
+// argument p points to a float64 variable in which to store the value of the
+// flag.
+func LocaleFloatVar(fs *flag.FlagSet, p *float64, name string, value float64, usage string, loc Locale) {
+	*p = value
+	fs.Var(&localeFloatValue{p: p, loc: loc}, name, usage)
+}
+
+type localeDateValue struct {
+	p   *time.Time
+	loc Locale
+}
+
+func (v *localeDateValue) String() string {
+	if v.p == nil || v.p.IsZero() {
+		return ""
+	}
+	return v.p.Format(v.loc.dateLayouts()[0])
+}
+
+func (v *localeDateValue) Set(s string) error {
+	s = strings.TrimSpace(s)
+	layouts := v.loc.dateLayouts()
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			*v.p = t
+			return nil
+		}
+	}
+	return errors.New("date must match " + strings.Join(layouts, " or "))
+}
+
+func (v *localeDateValue) inheritLocale(l Locale) {
+	if v.loc.isZero() {
+		v.loc = l
+	}
+}
+
+func (v *localeDateValue) Get() any {
+	return *v.p
+}
+
+// LocaleDateVar defines a date flag with specified name, default value, and
+// usage string, accepting dates in any of the layouts of loc, interpreted in
+// the local time zone. The argument p points to a [time.Time] variable in
+// which to store the value of the flag.
+func LocaleDateVar(fs *flag.FlagSet, p *time.Time, name string, value time.Time, usage string, loc Locale) {
+	*p = value
+	fs.Var(&localeDateValue{p: p, loc: loc}, name, usage)
+}
+
+var (
+	errParse = errors.New("parse error")
+	errRange = errors.New("value out of range")
+)
+
+// numError converts a strconv error to the errors reported by the flag
+// package for numeric flags.
+func numError(err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) && ne.Err == strconv.ErrRange {
+		return errRange
+	}
+	return errParse
+}
+
+type locationValue struct {
+	p **time.Location
+}
+
+func (v *locationValue) String() string {
+	if v.p == nil || *v.p == nil {
+		return ""
+	}
+	return (*v.p).String()
+}
+
+func (v *locationValue) Set(s string) error {
+	if s == "" {
+		return errors.New("empty time zone name")
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return err
+	}
+	*v.p = loc
+	return nil
+}
+
+func (v *locationValue) Get() any {
+	return *v.p
+}
+
+// LocationVar defines a time zone flag with specified name, default value,
+// and usage string, accepting IANA time zone names such as
+// "America/New_York", as well as "UTC" and "Local". The argument p points to a
+// [*time.Location] variable in which to store the value of the flag.
+func LocationVar(fs *flag.FlagSet, p **time.Location, name string, value *time.Location, usage string) {
+	*p = value
+	fs.Var(&locationValue{p: p}, name, usage)
+}
+
+type fileModeValue struct {
+	p *fs.FileMode
+}
+
+func (v *fileModeValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%#04o", unixMode(*v.p))
+}
+
+func (v *fileModeValue) Set(s string) error {
+	if s == "" {
+		return errors.New("empty file mode")
+	}
+	if s[0] >= '0' && s[0] <= '9' {
+		n, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+		if err != nil || n > 0o7777 {
+			return errors.New("octal file mode must be between 0000 and 7777")
+		}
+		*v.p = fileMode(uint32(n))
+		return nil
+	}
+	m, err := applySymbolicMode(unixMode(*v.p), s)
+	if err != nil {
+		return err
+	}
+	*v.p = fileMode(m)
+	return nil
+}
+
+func (v *fileModeValue) Get() any {
+	return *v.p
+}
+
+// FileModeVar defines a file permission flag with specified name, default
+// value, and usage string. The flag accepts octal modes such as "0644" and
+// symbolic modes such as "u+rwx,go-w", which are applied to the default value.
+// The argument p points to an [fs.FileMode] variable in which to store the
+// value of the flag.
+func FileModeVar(fs *flag.FlagSet, p *fs.FileMode, name string, value fs.FileMode, usage string) {
+	*p = value
+	fs.Var(&fileModeValue{p: p}, name, usage)
+}
+
+// unixMode converts m to its Unix permission bits.
+func unixMode(m fs.FileMode) uint32 {
+	u := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		u |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		u |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		u |= 0o1000
+	}
+	return u
+}
+
+// fileMode converts Unix permission bits to an fs.FileMode.
+func fileMode(u uint32) fs.FileMode {
+	m := fs.FileMode(u & 0o777)
+	if u&0o4000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if u&0o2000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if u&0o1000 != 0 {
+		m |= fs.ModeSticky
+	}
+	return m
+}
+
+// applySymbolicMode applies a chmod-style symbolic mode, such as "u+rwx,go=r",
+// to the Unix permission bits m.
+func applySymbolicMode(m uint32, s string) (uint32, error) {
+	for _, clause := range strings.Split(s, ",") {
+		var who uint32
+		i := 0
+	who:
+		for ; i < len(clause); i++ {
+			switch clause[i] {
+			case 'u':
+				who |= 0o4700
+			case 'g':
+				who |= 0o2070
+			case 'o':
+				who |= 0o1007
+			case 'a':
+				who |= 0o7777
+			default:
+				break who
+			}
+		}
+		if who == 0 {
+			who = 0o7777
+		}
+		if i == len(clause) {
+			return 0, fmt.Errorf("invalid file mode clause %q", clause)
+		}
+
+		for i < len(clause) {
+			op := clause[i]
+			if op != '+' && op != '-' && op != '=' {
+				return 0, fmt.Errorf("invalid file mode clause %q", clause)
+			}
+			i++
+			var perm uint32
+		perms:
+			for ; i < len(clause); i++ {
+				switch clause[i] {
+				case 'r':
+					perm |= 0o444
+				case 'w':
+					perm |= 0o222
+				case 'x':
+					perm |= 0o111
+				case 's':
+					perm |= 0o6000
+				case 't':
+					perm |= 0o1000
+				case '+', '-', '=':
+					break perms
+				default:
+					return 0, fmt.Errorf("invalid file mode clause %q", clause)
+				}
+			}
+			perm &= who
+			switch op {
+			case '+':
+				m |= perm
+			case '-':
+				m &^= perm
+			case '=':
+				m = m&^who | perm // clears the setuid, setgid, and sticky bits of who, as chmod does
+			}
+		}
+	}
+	return m, nil
+}
+
+// AddrVar defines an IP address flag with specified name, default value, and
+// usage string. The argument p points to a [netip.Addr] variable in which to
+// store the value of the flag.
+func AddrVar(fs *flag.FlagSet, p *netip.Addr, name string, value netip.Addr, usage string) {
+	fs.TextVar(p, name, value, usage)
+}
+
+// PrefixVar defines an IP network prefix flag, such as "10.0.0.0/8", with
+// specified name, default value, and usage string. The argument p points to a
+// [netip.Prefix] variable in which to store the value of the flag.
+func PrefixVar(fs *flag.FlagSet, p *netip.Prefix, name string, value netip.Prefix, usage string) {
+	fs.TextVar(p, name, value, usage)
+}
+
+// AddrPortVar defines an IP address and port flag, such as "127.0.0.1:8080",
+// with specified name, default value, and usage string. The argument p points
+// to a [netip.AddrPort] variable in which to store the value of the flag.
+func AddrPortVar(fs *flag.FlagSet, p *netip.AddrPort, name string, value netip.AddrPort, usage string) {
+	fs.TextVar(p, name, value, usage)
+}
+
+type listenAddrValue struct {
+	p *string
+}
+
+func (v *listenAddrValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *listenAddrValue) Set(s string) error {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return errors.New("listen address must be host:port or :port")
+	}
+	if strings.Contains(host, ":") {
+		if _, err := netip.ParseAddr(host); err != nil {
+			return fmt.Errorf("invalid host %q", host)
+		}
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	*v.p = s
+	return nil
+}
+
+func (v *listenAddrValue) Get() any {
+	return *v.p
+}
+
+// ListenAddrVar defines a listen address flag with specified name, default
+// value, and usage string. The flag accepts addresses as passed to
+// [net.Listen], such as ":8080", "localhost:8080", or "[::1]:8080". The
+// argument p points to a string variable in which to store the value of the
+// flag.
+func ListenAddrVar(fs *flag.FlagSet, p *string, name string, value string, usage string) {
+	*p = value
+	fs.Var(&listenAddrValue{p: p}, name, usage)
+}
+
+type regexpValue struct {
+	p **regexp.Regexp
+}
+
+func (v *regexpValue) String() string {
+	if v.p == nil || *v.p == nil {
+		return ""
+	}
+	return (*v.p).String()
+}
+
+func (v *regexpValue) Set(s string) error {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	*v.p = re
+	return nil
+}
+
+func (v *regexpValue) Get() any {
+	return *v.p
+}
+
+// RegexpVar defines a regular expression flag with specified name, default
+// value, and usage string. The flag value is compiled with [regexp.Compile].
+// The argument p points to a [*regexp.Regexp] variable in which to store the
+// value of the flag.
+func RegexpVar(fs *flag.FlagSet, p **regexp.Regexp, name string, value *regexp.Regexp, usage string) {
+	*p = value
+	fs.Var(&regexpValue{p: p}, name, usage)
+}
+
+type jsonValue[T any] struct {
+	p *T
+}
+
+func (v *jsonValue[T]) String() string {
+	if v.p == nil {
+		return ""
+	}
+	b, err := json.Marshal(*v.p)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func (v *jsonValue[T]) Set(s string) error {
+	data := []byte(s)
+	if path, ok := strings.CutPrefix(s, "@"); ok {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return err
+		}
+	}
+
+	var t T
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	*v.p = t
+	return nil
+}
+
+func (v *jsonValue[T]) Get() any {
+	return *v.p
+}
+
+// JSONVar defines a flag with specified name, default value, and usage string
+// whose value is JSON decoded into a variable of any type. A value beginning
+// with "@" names a file from which the JSON is read instead, as in
+// "@options.json". Unknown object fields are rejected. The argument p points
+// to a variable in which to store the value of the flag.
+func JSONVar[T any](fs *flag.FlagSet, p *T, name string, value T, usage string) {
+	*p = value
+	fs.Var(&jsonValue[T]{p: p}, name, usage)
+}
+
+type byteEncoding interface {
+	EncodeToString([]byte) string
+	DecodeString(string) ([]byte, error)
+}
+
+type bytesValue struct {
+	p   *[]byte
+	enc byteEncoding
+}
+
+func (v *bytesValue) String() string {
+	if v.p == nil || v.enc == nil {
+		return ""
+	}
+	return v.enc.EncodeToString(*v.p)
+}
+
+func (v *bytesValue) Set(s string) error {
+	b, err := v.enc.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*v.p = b
+	return nil
+}
+
+func (v *bytesValue) Get() any {
+	return *v.p
+}
+
+// base64Encoding decodes standard and URL-safe base64, with or without
+// padding, and encodes standard padded base64.
+type base64Encoding struct{}
+
+func (base64Encoding) EncodeToString(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (base64Encoding) DecodeString(s string) ([]byte, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.URLEncoding
+	}
+	if !strings.HasSuffix(s, "=") && len(s)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	return enc.DecodeString(s)
+}
+
+type hexEncoding struct{}
+
+func (hexEncoding) EncodeToString(b []byte) string {
+	return hex.EncodeToString(b)
+}
+
+func (hexEncoding) DecodeString(s string) ([]byte, error) {
+	return hex.DecodeString(s)
+}
+
+// Base64Var defines a []byte flag with specified name, default value, and
+// usage string whose value is base64 encoded. Standard and URL-safe
+// encodings are accepted, with or without padding. The argument p points to a
+// []byte variable in which to store the decoded value of the flag.
+func Base64Var(fs *flag.FlagSet, p *[]byte, name string, value []byte, usage string) {
+	*p = value
+	fs.Var(&bytesValue{p: p, enc: base64Encoding{}}, name, usage)
+}
+
+// HexVar defines a []byte flag with specified name, default value, and usage
+// string whose value is hex encoded. The argument p points to a []byte
+// variable in which to store the decoded value of the flag.
+func HexVar(fs *flag.FlagSet, p *[]byte, name string, value []byte, usage string) {
+	*p = value
+	fs.Var(&bytesValue{p: p, enc: hexEncoding{}}, name, usage)
+}
+
+// A listValue is a flag value holding a list, which is set as a whole from a
+// delimited env var or config value rather than one element at a time.
+type listValue interface {
+	setList(s string) error
+}
+
+type sliceValue[T any] struct {
+	p      *[]T
+	sep    string
+	parse  func(string) (T, error)
+	format func(T) string
+	set    bool // whether the default has been replaced by a flag
+}
+
+func (v *sliceValue[T]) String() string {
+	if v.p == nil {
+		return ""
+	}
+	elems := make([]string, len(*v.p))
+	for i, t := range *v.p {
+		elems[i] = v.format(t)
+	}
+	return strings.Join(elems, v.sep)
+}
+
+func (v *sliceValue[T]) Set(s string) error {
+	t, err := v.parse(s)
+	if err != nil {
+		return err
+	}
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	*v.p = append(*v.p, t)
+	return nil
+}
+
+func (v *sliceValue[T]) Get() any {
+	return *v.p
+}
+
+func (v *sliceValue[T]) setList(s string) error {
+	var ts []T
+	if s != "" {
+		for _, elem := range strings.Split(s, v.sep) {
+			t, err := v.parse(elem)
+			if err != nil {
+				return err
+			}
+			ts = append(ts, t)
+		}
+	}
+	*v.p = ts
+	return nil
+}
+
+// SliceVar defines a repeatable flag with specified name, default value, and
+// usage string, whose values are converted by parse and formatted by format.
+// Each occurrence of the flag appends to the list, replacing the default. Env
+// var and config values are split on sep, or "," if sep is empty. The argument
+// p points to a slice variable in which to store the values of the flag.
+func SliceVar[T any](fs *flag.FlagSet, p *[]T, name string, value []T, usage string, sep string, parse func(string) (T, error), format func(T) string) {
+	if sep == "" {
+		sep = ","
+	}
+	*p = value
+	fs.Var(&sliceValue[T]{p: p, sep: sep, parse: parse, format: format}, name, usage)
+}
+
+// StringSliceVar defines a repeatable string flag with specified name, default
+// value, and usage string, as for [SliceVar]. The argument p points to a
+// []string variable in which to store the values of the flag.
+func StringSliceVar(fs *flag.FlagSet, p *[]string, name string, value []string, usage string, sep string) {
+	SliceVar(fs, p, name, value, usage, sep, func(s string) (string, error) {
+		return s, nil
+	}, func(s string) string {
+		return s
+	})
+}
+
+// IntSliceVar defines a repeatable int flag with specified name, default
+// value, and usage string, as for [SliceVar]. The argument p points to an
+// []int variable in which to store the values of the flag.
+func IntSliceVar(fs *flag.FlagSet, p *[]int, name string, value []int, usage string, sep string) {
+	SliceVar(fs, p, name, value, usage, sep, func(s string) (int, error) {
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 0, strconv.IntSize)
+		if err != nil {
+			return 0, numError(err)
+		}
+		return int(i), nil
+	}, strconv.Itoa)
+}
+
+// Float64SliceVar defines a repeatable float64 flag with specified name,
+// default value, and usage string, as for [SliceVar]. The argument p points to
+// a []float64 variable in which to store the values of the flag.
+func Float64SliceVar(fs *flag.FlagSet, p *[]float64, name string, value []float64, usage string, sep string) {
+	SliceVar(fs, p, name, value, usage, sep, func(s string) (float64, error) {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, numError(err)
+		}
+		return f, nil
+	}, func(f float64) string {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	})
+}
+
+// DurationSliceVar defines a repeatable [time.Duration] flag with specified
+// name, default value, and usage string, as for [SliceVar]. The argument p
+// points to a []time.Duration variable in which to store the values of the
+// flag.
+func DurationSliceVar(fs *flag.FlagSet, p *[]time.Duration, name string, value []time.Duration, usage string, sep string) {
+	SliceVar(fs, p, name, value, usage, sep, func(s string) (time.Duration, error) {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return 0, errParse
+		}
+		return d, nil
+	}, time.Duration.String)
+}
+
+type mapValue struct {
+	p   *map[string]string
+	set bool // whether the default has been replaced by a flag
+}
+
+func (v *mapValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*v.p))
+	for k, val := range *v.p {
+		pairs = append(pairs, k+"="+val)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v *mapValue) Set(s string) error {
+	k, val, err := parsePair(s)
+	if err != nil {
+		return err
+	}
+	if !v.set || *v.p == nil {
+		*v.p = make(map[string]string)
+		v.set = true
+	}
+	(*v.p)[k] = val
+	return nil
+}
+
+func (v *mapValue) Get() any {
+	return *v.p
+}
+
+func (v *mapValue) setList(s string) error {
+	m := make(map[string]string)
+	if s != "" {
+		for _, pair := range strings.Split(s, ",") {
+			k, val, err := parsePair(pair)
+			if err != nil {
+				return err
+			}
+			m[k] = val
+		}
+	}
+	*v.p = m
+	return nil
+}
+
+// parsePair parses a key=value pair of a map flag.
+func parsePair(s string) (key, value string, err error) {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("entry %q must be key=value", s)
+	}
+	return key, value, nil
+}
+
+// MapVar defines a repeatable key=value flag with specified name, default
+// value, and usage string. Each occurrence of the flag, such as "-label
+// env=prod", adds an entry to the map, replacing the default. Env var and
+// config values are comma separated lists of pairs, such as "env=prod,tier=web".
+// The argument p points to a map variable in which to store the values of the
+// flag.
+func MapVar(fs *flag.FlagSet, p *map[string]string, name string, value map[string]string, usage string) {
+	*p = value
+	fs.Var(&mapValue{p: p}, name, usage)
+}
+
+// A choicesValue is a flag value restricted to a set of choices, which are
+// offered for completion and listed in help.
+type choicesValue interface {
+	choices() []string
+}
+
+type enumValue struct {
+	p       *string
+	allowed []string
+}
+
+func (v *enumValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return *v.p
+}
+
+func (v *enumValue) Set(s string) error {
+	if !slices.Contains(v.allowed, s) {
+		return errors.New("must be one of " + quoteChoices(v.allowed))
+	}
+	*v.p = s
+	return nil
+}
+
+func (v *enumValue) Get() any {
+	return *v.p
+}
+
+func (v *enumValue) choices() []string {
+	return v.allowed
+}
+
+// quoteChoices formats choices as a quoted list, such as `"a", "b", or "c"`.
+func quoteChoices(choices []string) string {
+	quoted := make([]string, len(choices))
+	for i, c := range choices {
+		quoted[i] = strconv.Quote(c)
+	}
+	if len(quoted) == 0 {
+		return "nothing"
+	}
+	return joinOr(quoted)
+}
+
+// joinOr formats items as a list, such as "a, b, or c".
+func joinOr(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " or " + items[1]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + ", or " + items[len(items)-1]
+}
+
+// EnumVar defines a string flag with specified name, default value, and usage
+// string, accepting only the given choices. The choices are offered by
+// [CompletionCommand] and listed by [GenerateHelp] and [CommandSpec]. The
+// argument p points to a string variable in which to store the value of the
+// flag.
+func EnumVar(fs *flag.FlagSet, p *string, name string, value string, usage string, choices []string) {
+	*p = value
+	fs.Var(&enumValue{p: p, allowed: choices}, name, usage)
+}
+
+type timeValue struct {
+	p *time.Time
+}
+
+func (v *timeValue) String() string {
+	if v.p == nil || v.p.IsZero() {
+		return ""
+	}
+	return v.p.Format(time.RFC3339Nano)
+}
+
+func (v *timeValue) Set(s string) error {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	if err != nil {
+		return errors.New("time must be RFC 3339, such as 2006-01-02T15:04:05Z")
+	}
+	*v.p = t
+	return nil
+}
+
+func (v *timeValue) Get() any {
+	return *v.p
+}
+
+// TimeVar defines a timestamp flag with specified name, default value, and
+// usage string, accepting RFC 3339 timestamps such as "2006-01-02T15:04:05Z"
+// or "2006-01-02T15:04:05.5-07:00". For durations, use the flag package's
+// DurationVar. The argument p points to a [time.Time] variable in which to
+// store the value of the flag.
+func TimeVar(fs *flag.FlagSet, p *time.Time, name string, value time.Time, usage string) {
+	*p = value
+	fs.Var(&timeValue{p: p}, name, usage)
+}
+
+// byteUnits are the byte size units accepted by ByteSizeVar, from largest to
+// smallest within each base, with binary units preferred for formatting.
+var byteUnits = []struct {
+	name string
+	size int64
+}{
+	{"EiB", 1 << 60},
+	{"PiB", 1 << 50},
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"EB", 1e18},
+	{"PB", 1e15},
+	{"TB", 1e12},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"KB", 1e3},
+	{"B", 1},
+}
+
+type byteSizeValue struct {
+	p *int64
+}
+
+func (v *byteSizeValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	n := *v.p
+	if n != 0 {
+		for _, u := range byteUnits {
+			if n%u.size == 0 {
+				return strconv.FormatInt(n/u.size, 10) + u.name
+			}
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func (v *byteSizeValue) Set(s string) error {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	num, unit := s, ""
+	if i >= 0 {
+		num, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	if num == "" {
+		return errors.New("byte size must be a number with an optional unit, such as 512MiB")
+	}
+
+	size := int64(1)
+	if unit != "" {
+		found := false
+		for _, u := range byteUnits {
+			if strings.EqualFold(unit, u.name) || strings.EqualFold(unit, strings.TrimSuffix(u.name, "B")) {
+				size, found = u.size, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown byte size unit %q", unit)
+		}
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return errParse
+	}
+	n := f * float64(size)
+	if n >= 1<<63 {
+		return errRange
+	}
+	*v.p = int64(n)
+	return nil
+}
+
+func (v *byteSizeValue) Get() any {
+	return *v.p
+}
+
+// ByteSizeVar defines a byte size flag with specified name, default value, and
+// usage string. The flag accepts a number of bytes with an optional decimal
+// (KB, MB, GB, ...) or binary (KiB, MiB, GiB, ...) unit, such as "512MiB" or
+// "1.5GB". Units are case-insensitive, and a unit's trailing "B" may be
+// omitted, as in "64k". The argument p points to an int64 variable in which
+// to store the size in bytes.
+func ByteSizeVar(fs *flag.FlagSet, p *int64, name string, value int64, usage string) {
+	*p = value
+	fs.Var(&byteSizeValue{p: p}, name, usage)
+}
+
+type countValue struct {
+	p *int
+}
+
+func (v *countValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strconv.Itoa(*v.p)
+}
+
+// Set increments the count for each occurrence of the flag, which the flag
+// package sets to "true", and otherwise sets the count to a plain number.
+func (v *countValue) Set(s string) error {
+	if s == "true" {
+		*v.p++
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return errParse
+	}
+	*v.p = n
+	return nil
+}
+
+func (v *countValue) Get() any {
+	return *v.p
+}
+
+func (v *countValue) IsBoolFlag() bool { return true }
+
+// CountVar defines a counting flag with specified name, default value, and
+// usage string. Each occurrence of the flag increments the count, so that
+// "-v -v -v" or, for a single letter name, "-vvv" sets it to 3. A number may
+// also be given explicitly, as in "-v=2" or from an env var. The argument p
+// points to an int variable in which to store the count.
+func CountVar(fs *flag.FlagSet, p *int, name string, value int, usage string) {
+	*p = value
+	fs.Var(&countValue{p: p}, name, usage)
+}
+
+// expandCounts rewrites repeated single letter count flags among the flag
+// args of args, such as "-vvv", into separate occurrences, "-v -v -v", which
+// the flag package can parse.
+func expandCounts(fs *flag.FlagSet, args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(out, args[i:]...)
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil && !hasValue && isRepeatedCount(fs, name) {
+			for range name {
+				out = append(out, "-"+name[:1])
+			}
+			continue
+		}
+		out = append(out, arg)
+		if f != nil && !hasValue && !isBoolFlag(f.Value) && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// foldFlagArgs replaces the names of the flags in args that match a flag of fs
+// only case-insensitively with the name of that flag.
+func foldFlagArgs(fs *flag.FlagSet, args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(out, args[i:]...)
+		}
+		dashes := arg[:len(arg)-len(strings.TrimLeft(arg, "-"))]
+		name, value, hasValue := strings.Cut(arg[len(dashes):], "=")
+		name = foldFlagName(fs, name)
+		if hasValue {
+			out = append(out, dashes+name+"="+value)
+		} else {
+			out = append(out, dashes+name)
+		}
+		f := fs.Lookup(name)
+		if f != nil && !hasValue && !isBoolFlag(f.Value) && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// foldFlagName returns the name of the flag of fs that matches name
+// case-insensitively, or name itself if a flag matches it exactly or if no
+// single flag matches it.
+func foldFlagName(fs *flag.FlagSet, name string) string {
+	if fs.Lookup(name) != nil {
+		return name
+	}
+	var matches []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if strings.EqualFold(f.Name, name) {
+			matches = append(matches, f.Name)
+		}
+	})
+	if len(matches) != 1 {
+		return name
+	}
+	return matches[0]
+}
+
+// isRepeatedCount reports whether name repeats the single letter name of a
+// count flag.
+func isRepeatedCount(fs *flag.FlagSet, name string) bool {
+	if len(name) < 2 || strings.Trim(name, name[:1]) != "" {
+		return false
+	}
+	f := fs.Lookup(name[:1])
+	if f == nil {
+		return false
+	}
+	_, ok := unwrapValue(f.Value).(*countValue)
+	return ok
+}
@@ -0,0 +1,791 @@
+package tinycli_test
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+// valueTest is a test case for a flag value: setting the flag to in either
+// succeeds with the flag's String() equal to want, or fails.
+type valueTest struct {
+	in      string
+	want    string
+	wantErr bool
+}
+
+func testFlagValue(t *testing.T, newFlagSet func() *flag.FlagSet, tests []valueTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		fs := newFlagSet()
+		err := fs.Set("v", tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("fs.Set(%q) returned nil error, value %q", tt.in, fs.Lookup("v").Value.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("fs.Set(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got := fs.Lookup("v").Value.String(); tt.want != got {
+			t.Errorf("fs.Set(%q) value=%q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleIntVar(t *testing.T) {
+	newFlagSet := func(loc cli.Locale) func() *flag.FlagSet {
+		return func() *flag.FlagSet {
+			var n int
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			cli.LocaleIntVar(fs, &n, "v", 0, "", loc)
+			return fs
+		}
+	}
+
+	testFlagValue(t, newFlagSet(cli.LocaleUS), []valueTest{
+		{in: "1234567", want: "1234567"},
+		{in: "1,234,567", want: "1234567"},
+		{in: "-12,345", want: "-12345"},
+		{in: "010", want: "10"},
+		{in: "0x10", wantErr: true},
+		{in: "1,23", wantErr: true},
+		{in: "1.234", wantErr: true},
+		{in: "99,999,999,999,999,999,999", wantErr: true},
+	})
+	testFlagValue(t, newFlagSet(cli.LocaleDE), []valueTest{
+		{in: "1.234.567", want: "1234567"},
+		{in: "1,234", wantErr: true},
+	})
+}
+
+func TestLocaleFloatVar(t *testing.T) {
+	newFlagSet := func(loc cli.Locale) func() *flag.FlagSet {
+		return func() *flag.FlagSet {
+			var f float64
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			cli.LocaleFloatVar(fs, &f, "v", 0, "", loc)
+			return fs
+		}
+	}
+
+	testFlagValue(t, newFlagSet(cli.LocaleUS), []valueTest{
+		{in: "1,234.5", want: "1234.5"},
+		{in: "0.25", want: "0.25"},
+		{in: "1,234,5", wantErr: true},
+	})
+	testFlagValue(t, newFlagSet(cli.LocaleDE), []valueTest{
+		{in: "1.234,5", want: "1234,5"},
+		{in: "0,25", want: "0,25"},
+		{in: "1.5", wantErr: true},
+	})
+	testFlagValue(t, newFlagSet(cli.LocaleFR), []valueTest{
+		{in: "1 234,5", want: "1234,5"},
+	})
+}
+
+func TestLocaleDateVar(t *testing.T) {
+	newFlagSet := func(loc cli.Locale) func() *flag.FlagSet {
+		return func() *flag.FlagSet {
+			var d time.Time
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			cli.LocaleDateVar(fs, &d, "v", time.Time{}, "", loc)
+			return fs
+		}
+	}
+
+	testFlagValue(t, newFlagSet(cli.LocaleUS), []valueTest{
+		{in: "03/14/2025", want: "03/14/2025"},
+		{in: "2025-03-14", want: "03/14/2025"},
+		{in: "14/03/2025", wantErr: true},
+	})
+	testFlagValue(t, newFlagSet(cli.LocaleDE), []valueTest{
+		{in: "14.03.2025", want: "14.03.2025"},
+		{in: "03/14/2025", wantErr: true},
+	})
+}
+
+func TestCommand_Execute_locale(t *testing.T) {
+	type params struct {
+		Amount float64
+		Count  int
+		Since  time.Time
+		Price  float64
+	}
+	var got params
+	cmd := &cli.Command[*params]{
+		Name:   "root",
+		Locale: &cli.LocaleDE,
+		Subcommands: []*cli.Command[*params]{
+			{
+				Name: "sub",
+				Flags: func(fs *flag.FlagSet, p *params) {
+					cli.LocaleFloatVar(fs, &p.Amount, "amount", 0, "", cli.Locale{})
+					cli.LocaleIntVar(fs, &p.Count, "count", 0, "", cli.Locale{})
+					cli.LocaleDateVar(fs, &p.Since, "since", time.Time{}, "", cli.Locale{})
+					cli.LocaleFloatVar(fs, &p.Price, "price", 0, "", cli.LocaleUS)
+				},
+				Action: func(ctx context.Context, e *cli.Env[*params]) cli.ExitStatus {
+					got = *e.Params
+					return cli.ExitSuccess
+				},
+			},
+		},
+	}
+
+	e := &cli.Env[*params]{
+		Args:   []string{"root", "sub", "-amount=1.234,5", "-count=1.000", "-since=14.03.2025", "-price=1,234.5"},
+		Params: &params{},
+	}
+	if status := cmd.Execute(t.Context(), e); status != cli.ExitSuccess {
+		t.Fatalf("cmd.Execute() = %d, want %d", status, cli.ExitSuccess)
+	}
+	want := params{
+		Amount: 1234.5,
+		Count:  1000,
+		Since:  time.Date(2025, time.March, 14, 0, 0, 0, 0, time.Local),
+		Price:  1234.5,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("params mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLocationVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var loc *time.Location
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.LocationVar(fs, &loc, "v", time.UTC, "")
+		return fs
+	}, []valueTest{
+		{in: "America/New_York", want: "America/New_York"},
+		{in: "UTC", want: "UTC"},
+		{in: "Local", want: "Local"},
+		{in: "Mars/Olympus_Mons", wantErr: true},
+		{in: "", wantErr: true},
+	})
+}
+
+func TestLocationVar_env(t *testing.T) {
+	type p struct {
+		Loc *time.Location
+	}
+
+	var params p
+	cmd := &cli.Command[*p]{
+		Name:  "root",
+		Usage: "root usage",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			cli.LocationVar(fs, &p.Loc, "tz", time.UTC, "")
+		},
+		Vars: map[string]string{"tz": "APP_TZ"},
+	}
+	_, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmd, &params, tc[*p]{
+		args: []string{"root"},
+		vars: map[string]string{"APP_TZ": "Mars/Olympus_Mons"},
+	})
+
+	if want, got := cli.ExitUsage, gotStatus; want != got {
+		t.Errorf("cmd.Execute()=%v, want %v", got, want)
+	}
+	if want, got := "root usage\ninvalid value \"Mars/Olympus_Mons\" for var $APP_TZ: unknown time zone Mars/Olympus_Mons\n", gotErrbuf; want != got {
+		t.Errorf("cmd.Execute() wrote %q, want %q", got, want)
+	}
+}
+
+func TestFileModeVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var mode fs.FileMode
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.FileModeVar(fs, &mode, "v", 0o644, "")
+		return fs
+	}, []valueTest{
+		{in: "0755", want: "0755"},
+		{in: "600", want: "0600"},
+		{in: "0o1777", want: "01777"},
+		{in: "u+x", want: "0744"},
+		{in: "go-r", want: "0600"},
+		{in: "a=rx", want: "0555"},
+		{in: "u=rwx,g+w-r,o=", want: "0720"},
+		{in: "+x", want: "0755"},
+		{in: "g+s", want: "02644"},
+		{in: "0800", wantErr: true},
+		{in: "17777", wantErr: true},
+		{in: "u", wantErr: true},
+		{in: "u+q", wantErr: true},
+		{in: "", wantErr: true},
+	})
+	testFlagValue(t, func() *flag.FlagSet {
+		var mode fs.FileMode
+		value := 0o755 | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.FileModeVar(fs, &mode, "v", value, "")
+		return fs
+	}, []valueTest{
+		{in: "u=rw", want: "03655"},
+		{in: "g=rx", want: "05755"},
+		{in: "o=rx", want: "06755"},
+		{in: "u+w", want: "07755"},
+	})
+	testFlagValue(t, func() *flag.FlagSet {
+		var mode fs.FileMode
+		value := 0o755 | fs.ModeSetuid
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.FileModeVar(fs, &mode, "v", value, "")
+		return fs
+	}, []valueTest{
+		{in: "u=rw", want: "0655"},
+	})
+}
+
+func TestAddrVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var addr netip.Addr
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.AddrVar(fs, &addr, "v", netip.Addr{}, "")
+		return fs
+	}, []valueTest{
+		{in: "192.168.0.1", want: "192.168.0.1"},
+		{in: "::1", want: "::1"},
+		{in: "256.0.0.1", wantErr: true},
+		{in: "example.com", wantErr: true},
+	})
+}
+
+func TestPrefixVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var prefix netip.Prefix
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.PrefixVar(fs, &prefix, "v", netip.Prefix{}, "")
+		return fs
+	}, []valueTest{
+		{in: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{in: "fd00::/8", want: "fd00::/8"},
+		{in: "10.0.0.0/33", wantErr: true},
+		{in: "10.0.0.0", wantErr: true},
+	})
+}
+
+func TestAddrPortVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var ap netip.AddrPort
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.AddrPortVar(fs, &ap, "v", netip.AddrPort{}, "")
+		return fs
+	}, []valueTest{
+		{in: "127.0.0.1:8080", want: "127.0.0.1:8080"},
+		{in: "[::1]:443", want: "[::1]:443"},
+		{in: ":8080", wantErr: true},
+		{in: "127.0.0.1", wantErr: true},
+	})
+}
+
+func TestListenAddrVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var addr string
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.ListenAddrVar(fs, &addr, "v", ":8080", "")
+		return fs
+	}, []valueTest{
+		{in: ":9090", want: ":9090"},
+		{in: "localhost:0", want: "localhost:0"},
+		{in: "[::1]:443", want: "[::1]:443"},
+		{in: "0.0.0.0:65535", want: "0.0.0.0:65535"},
+		{in: "8080", wantErr: true},
+		{in: ":65536", wantErr: true},
+		{in: ":http", wantErr: true},
+		{in: "localhost:", wantErr: true},
+	})
+}
+
+func TestRegexpVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var re *regexp.Regexp
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.RegexpVar(fs, &re, "v", nil, "")
+		return fs
+	}, []valueTest{
+		{in: `^foo-\d+$`, want: `^foo-\d+$`},
+		{in: "", want: ""},
+		{in: "(", wantErr: true},
+		{in: "a**", wantErr: true},
+	})
+
+	type p struct {
+		Filter *regexp.Regexp
+	}
+	var params p
+	cmd := &cli.Command[*p]{
+		Name:  "root",
+		Usage: "root usage",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			cli.RegexpVar(fs, &p.Filter, "filter", nil, "")
+		},
+		Vars: map[string]string{"filter": "FILTER"},
+	}
+	_, _, _, gotErrbuf, _ := execTestCommand(t, cmd, &params, tc[*p]{
+		args: []string{"root"},
+		vars: map[string]string{"FILTER": "("},
+	})
+	if want, got := "root usage\ninvalid value \"(\" for var $FILTER: error parsing regexp: missing closing ): `(`\n", gotErrbuf; want != got {
+		t.Errorf("cmd.Execute() wrote %q, want %q", got, want)
+	}
+}
+
+func TestJSONVar(t *testing.T) {
+	type options struct {
+		Name    string         `json:"name"`
+		Retries int            `json:"retries"`
+		Labels  map[string]any `json:"labels,omitempty"`
+	}
+
+	path := filepath.Join(t.TempDir(), "options.json")
+	if err := os.WriteFile(path, []byte(`{"name": "file", "retries": 3}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testFlagValue(t, func() *flag.FlagSet {
+		var opts options
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.JSONVar(fs, &opts, "v", options{Retries: 1}, "")
+		return fs
+	}, []valueTest{
+		{in: `{"name": "inline"}`, want: `{"name":"inline","retries":0}`},
+		{in: `{"labels": {"a": [1, 2]}}`, want: `{"name":"","retries":0,"labels":{"a":[1,2]}}`},
+		{in: "@" + path, want: `{"name":"file","retries":3}`},
+		{in: `{"unknown": true}`, wantErr: true},
+		{in: `{"name": 1}`, wantErr: true},
+		{in: `{} {}`, wantErr: true},
+		{in: "@" + path + ".missing", wantErr: true},
+	})
+}
+
+func TestBase64Var(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var b []byte
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.Base64Var(fs, &b, "v", nil, "")
+		return fs
+	}, []valueTest{
+		{in: "aGVsbG8=", want: "aGVsbG8="},
+		{in: "aGVsbG8", want: "aGVsbG8="},
+		{in: "-_-_", want: "+/+/"},
+		{in: "+/+/", want: "+/+/"},
+		{in: "", want: ""},
+		{in: "a", wantErr: true},
+		{in: "aGVs!G8=", wantErr: true},
+	})
+}
+
+func TestHexVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var b []byte
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.HexVar(fs, &b, "v", []byte{0xff}, "")
+		return fs
+	}, []valueTest{
+		{in: "deadBEEF", want: "deadbeef"},
+		{in: "", want: ""},
+		{in: "abc", wantErr: true},
+		{in: "zz", wantErr: true},
+	})
+
+	type p struct {
+		Key []byte
+	}
+	var params p
+	cmd := &cli.Command[*p]{
+		Name:  "root",
+		Usage: "root usage",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			cli.HexVar(fs, &p.Key, "key", nil, "")
+		},
+		Vars: map[string]string{"key": "KEY"},
+	}
+	_, _, _, gotErrbuf, _ := execTestCommand(t, cmd, &params, tc[*p]{
+		args: []string{"root"},
+		vars: map[string]string{"KEY": "0g"},
+	})
+	if want, got := "root usage\ninvalid value \"0g\" for var $KEY: encoding/hex: invalid byte: U+0067 'g'\n", gotErrbuf; want != got {
+		t.Errorf("cmd.Execute() wrote %q, want %q", got, want)
+	}
+}
+
+func TestIntSliceVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var n []int
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.IntSliceVar(fs, &n, "v", []int{1, 2}, "", "")
+		return fs
+	}, []valueTest{
+		{in: "3", want: "3"},
+		{in: "0x10", want: "16"},
+		{in: "1,2", wantErr: true},
+		{in: "", wantErr: true},
+	})
+}
+
+func TestDurationSliceVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var d []time.Duration
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.DurationSliceVar(fs, &d, "v", nil, "", "")
+		return fs
+	}, []valueTest{
+		{in: "1m30s", want: "1m30s"},
+		{in: "90", wantErr: true},
+	})
+}
+
+func TestStringSliceVar(t *testing.T) {
+	type p struct {
+		Tags []string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				cli.StringSliceVar(fs, &p.Tags, "tag", []string{"default"}, "", ":")
+				cli.IntSliceVar(fs, new([]int), "port", nil, "", "")
+			},
+			Vars: map[string]string{"tag": "APP_TAGS", "port": "APP_PORTS"},
+			Config: func(e *cli.Env[*p]) (map[string]string, error) {
+				if v, ok := e.Vars["CONFIG_TAGS"]; ok {
+					return map[string]string{"tag": v}, nil
+				}
+				return nil, nil
+			},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+		}
+	}
+
+	tests := []tc[*p]{
+		{
+			name:       "default",
+			args:       []string{"root"},
+			wantParams: &p{Tags: []string{"default"}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "repeated",
+			args:       []string{"root", "-tag", "a", "-tag=b:c"},
+			vars:       map[string]string{"APP_TAGS": "x:y"},
+			wantParams: &p{Tags: []string{"a", "b:c"}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "var",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_TAGS": "x:y,z"},
+			wantParams: &p{Tags: []string{"x", "y,z"}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "var_empty",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_TAGS": ""},
+			wantParams: &p{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "config",
+			args:       []string{"root"},
+			vars:       map[string]string{"CONFIG_TAGS": "x:y"},
+			wantParams: &p{Tags: []string{"x", "y"}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "var_invalid",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_PORTS": "80,http"},
+			wantParams: &p{Tags: []string{"default"}},
+			wantErrbuf: "root usage\ninvalid value \"80,http\" for var $APP_PORTS: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(), &p{}, tt)
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("cmd.Execute()=%v, want %v", got, want)
+			}
+			if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if want, got := tt.wantErrbuf, gotErrbuf; want != got {
+				t.Errorf("cmd.Execute() wrote %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEnumVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var format string
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.EnumVar(fs, &format, "v", "table", "", []string{"json", "yaml", "table"})
+		return fs
+	}, []valueTest{
+		{in: "json", want: "json"},
+		{in: "JSON", wantErr: true},
+		{in: "", wantErr: true},
+	})
+
+	var format string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cli.EnumVar(fs, &format, "v", "table", "", []string{"json", "yaml", "table"})
+	if want, got := `must be one of "json", "yaml", or "table"`, fs.Set("v", "xml"); got == nil || got.Error() != want {
+		t.Errorf("fs.Set(%q) error=%v, want %q", "xml", got, want)
+	}
+}
+
+func TestMapVar(t *testing.T) {
+	type p struct {
+		Labels map[string]string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				cli.MapVar(fs, &p.Labels, "label", map[string]string{"tier": "web"}, "")
+			},
+			Vars:   map[string]string{"label": "APP_LABELS"},
+			Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+		}
+	}
+
+	tests := []tc[*p]{
+		{
+			name:       "default",
+			args:       []string{"root"},
+			wantParams: &p{Labels: map[string]string{"tier": "web"}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "repeated",
+			args:       []string{"root", "-label", "env=prod", "-label=owner=a=b", "-label", "env=dev"},
+			wantParams: &p{Labels: map[string]string{"env": "dev", "owner": "a=b"}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "malformed",
+			args:       []string{"root", "-label", "env"},
+			wantParams: &p{Labels: map[string]string{"tier": "web"}},
+			wantErrbuf: "root usage\ninvalid value \"env\" for flag -label: entry \"env\" must be key=value\n",
+			wantStatus: cli.ExitUsage,
+		},
+		{
+			name:       "var",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_LABELS": "env=prod,owner="},
+			wantParams: &p{Labels: map[string]string{"env": "prod", "owner": ""}},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "var_malformed",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_LABELS": "env=prod,=web"},
+			wantParams: &p{Labels: map[string]string{"tier": "web"}},
+			wantErrbuf: "root usage\ninvalid value \"env=prod,=web\" for var $APP_LABELS: entry \"=web\" must be key=value\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotParams, _, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(), &p{}, tt)
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("cmd.Execute()=%v, want %v", got, want)
+			}
+			if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if want, got := tt.wantErrbuf, gotErrbuf; want != got {
+				t.Errorf("cmd.Execute() wrote %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTimeVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var ts time.Time
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.TimeVar(fs, &ts, "v", time.Time{}, "")
+		return fs
+	}, []valueTest{
+		{in: "2024-03-01T12:30:00Z", want: "2024-03-01T12:30:00Z"},
+		{in: "2024-03-01T12:30:00.5-07:00", want: "2024-03-01T12:30:00.5-07:00"},
+		{in: "2024-03-01", wantErr: true},
+		{in: "", wantErr: true},
+	})
+}
+
+func TestByteSizeVar(t *testing.T) {
+	testFlagValue(t, func() *flag.FlagSet {
+		var n int64
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		cli.ByteSizeVar(fs, &n, "v", 0, "")
+		return fs
+	}, []valueTest{
+		{in: "512MiB", want: "512MiB"},
+		{in: "512mib", want: "512MiB"},
+		{in: "1.5GB", want: "1500MB"},
+		{in: "1.5 GiB", want: "1536MiB"},
+		{in: "64k", want: "64KB"},
+		{in: "2048", want: "2KiB"},
+		{in: "1500", want: "1500B"},
+		{in: "0", want: "0"},
+		{in: "16EiB", wantErr: true},
+		{in: "12XB", wantErr: true},
+		{in: "MiB", wantErr: true},
+		{in: "1..5MB", wantErr: true},
+	})
+}
+
+func TestByteSizeVar_env(t *testing.T) {
+	type p struct {
+		Timeout  time.Duration
+		MaxBytes int64
+	}
+
+	var params p
+	cmd := &cli.Command[*p]{
+		Name: "root",
+		Flags: func(fs *flag.FlagSet, p *p) {
+			fs.DurationVar(&p.Timeout, "timeout", 0, "")
+			cli.ByteSizeVar(fs, &p.MaxBytes, "max-bytes", 0, "")
+		},
+		VarPrefix: "FOO_",
+		Action:    func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+	}
+	gotParams, _, _, _, gotStatus := execTestCommand(t, cmd, &params, tc[*p]{
+		args: []string{"root"},
+		vars: map[string]string{"FOO_TIMEOUT": "30s", "FOO_MAX_BYTES": "512MiB"},
+	})
+
+	if want, got := cli.ExitSuccess, gotStatus; want != got {
+		t.Errorf("cmd.Execute()=%v, want %v", got, want)
+	}
+	if diff := cmp.Diff(&p{Timeout: 30 * time.Second, MaxBytes: 512 << 20}, gotParams); diff != "" {
+		t.Errorf("params mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCountVar(t *testing.T) {
+	type p struct {
+		Verbosity int
+		Name      string
+	}
+
+	cmdFactory := func() *cli.Command[*p] {
+		return &cli.Command[*p]{
+			Name:  "root",
+			Usage: "root usage",
+			Flags: func(fs *flag.FlagSet, p *p) {
+				cli.CountVar(fs, &p.Verbosity, "v", 0, "")
+				fs.StringVar(&p.Name, "name", "", "")
+			},
+			Vars:       map[string]string{"v": "APP_VERBOSITY"},
+			Persistent: []string{"v"},
+			Action:     func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+			Subcommands: []*cli.Command[*p]{
+				{
+					Name:   "sub",
+					Action: func(ctx context.Context, e *cli.Env[*p]) cli.ExitStatus { return cli.ExitSuccess },
+				},
+			},
+		}
+	}
+
+	tests := []tc[*p]{
+		{
+			name:       "unset",
+			args:       []string{"root"},
+			wantParams: &p{},
+			wantArgs:   []string{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "repeated",
+			args:       []string{"root", "-v", "-name", "-vv", "--v"},
+			wantParams: &p{Verbosity: 2, Name: "-vv"},
+			wantArgs:   []string{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "combined",
+			args:       []string{"root", "-vvv", "--", "-vv"},
+			wantParams: &p{Verbosity: 3},
+			wantArgs:   []string{"-vv"},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "explicit",
+			args:       []string{"root", "-v=2", "-v"},
+			wantParams: &p{Verbosity: 3},
+			wantArgs:   []string{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "var",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_VERBOSITY": "2"},
+			wantParams: &p{Verbosity: 2},
+			wantArgs:   []string{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "persistent",
+			args:       []string{"root", "-v", "sub", "-vv"},
+			wantParams: &p{Verbosity: 3},
+			wantArgs:   []string{},
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name:       "var_invalid",
+			args:       []string{"root"},
+			vars:       map[string]string{"APP_VERBOSITY": "-1"},
+			wantParams: &p{},
+			wantArgs:   []string{"root"},
+			wantErrbuf: "root usage\ninvalid boolean value \"-1\" for $APP_VERBOSITY: parse error\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotParams, gotArgs, _, gotErrbuf, gotStatus := execTestCommand(t, cmdFactory(), &p{}, tt)
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("cmd.Execute()=%v, want %v", got, want)
+			}
+			if diff := cmp.Diff(tt.wantParams, gotParams); diff != "" {
+				t.Errorf("params mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantArgs, gotArgs); diff != "" {
+				t.Errorf("args mismatch (-want +got):\n%s", diff)
+			}
+			if want, got := tt.wantErrbuf, gotErrbuf; want != got {
+				t.Errorf("cmd.Execute() wrote %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package tinycli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"slices"
+)
+
+// A VersionInfo describes a program build.
+type VersionInfo struct {
+	Version   string `json:"version"`          // program version
+	Commit    string `json:"commit,omitempty"` // VCS revision
+	Date      string `json:"date,omitempty"`   // build or commit date
+	GoVersion string `json:"goVersion"`        // Go toolchain version
+	Platform  string `json:"platform"`         // GOOS/GOARCH
+}
+
+// BuildVersion returns the VersionInfo of the running program, read from its
+// embedded build information.
+//
+// When version is non-empty, it is used instead of the main module version,
+// so that a version injected at link time with -ldflags takes precedence.
+func BuildVersion(version string) VersionInfo {
+	info := VersionInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "" {
+		info.Version = bi.Main.Version
+	}
+	if bi.GoVersion != "" {
+		info.GoVersion = bi.GoVersion
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.time":
+			info.Date = s.Value
+		}
+	}
+	return info
+}
+
+var errVersionOutput = errors.New(`must be one of "full", "short", or "json"`)
+
+// VersionCommand returns a "version" Command that prints info.
+//
+// The command's -o or -output flag selects the format: "full" (the default)
+// prints every field on its own line, "short" prints only the version, and
+// "json" prints info as a JSON object for automation. When an ancestor lists
+// the flags of an [OutputFlag] as Persistent, the command uses the inherited
+// flag instead of defining its own: "table" prints every field, and the other
+// formats write info as by [Env.Emit].
+func VersionCommand[P any](info VersionInfo) *Command[P] {
+	return &Command[P]{
+		Name:  "version",
+		Usage: "usage: version [-output full|short|json]",
+		Help: `flags:
+  -o, -output   output format: full, short, or json (default "full")`,
+		Before: func(e *Env[P]) error {
+			if !inheritsOutputFlag(e) {
+				fs := e.cmds[len(e.cmds)-1].flagSet()
+				usage := "output format: full, short, or json"
+				p := fs.String("output", "full", usage)
+				fs.StringVar(p, "o", "full", usage)
+			}
+			return nil
+		},
+		After: func(e *Env[P]) error {
+			switch e.flagValue("output") {
+			case "", "full", "short", "json":
+				return nil
+			}
+			return &ValueError{Name: "output", Err: errVersionOutput}
+		},
+		Action: func(ctx context.Context, e *Env[P]) ExitStatus {
+			format := e.flagValue("output")
+			if format == "" {
+				switch inherited := e.outputFormat(); inherited {
+				case "table":
+					format = "full"
+				case "json":
+					format = "json"
+				default:
+					if err := e.emit(info, inherited); err != nil {
+						e.Errorf("%v\n", err)
+						return ExitFailure
+					}
+					return ExitSuccess
+				}
+			}
+			switch format {
+			case "short":
+				e.Printf("%s\n", info.Version)
+			case "json":
+				b, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					e.Errorf("%v\n", err)
+					return ExitFailure
+				}
+				e.Printf("%s\n", b)
+			default:
+				e.Printf("version:  %s\n", info.Version)
+				if info.Commit != "" {
+					e.Printf("commit:   %s\n", info.Commit)
+				}
+				if info.Date != "" {
+					e.Printf("date:     %s\n", info.Date)
+				}
+				e.Printf("go:       %s\n", info.GoVersion)
+				e.Printf("platform: %s\n", info.Platform)
+			}
+			return ExitSuccess
+		},
+	}
+}
+
+// inheritsOutputFlag reports whether an ancestor of the executing command
+// lists an -o or -output flag as Persistent.
+func inheritsOutputFlag[P any](e *Env[P]) bool {
+	for _, a := range e.cmds[:len(e.cmds)-1] {
+		if slices.Contains(a.Persistent, "o") || slices.Contains(a.Persistent, "output") {
+			return true
+		}
+	}
+	return false
+}
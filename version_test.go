@@ -0,0 +1,135 @@
+package tinycli_test
+
+import (
+	"bytes"
+	"flag"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	cli "github.com/jonathonwebb/tinycli"
+)
+
+func TestBuildVersion(t *testing.T) {
+	info := cli.BuildVersion("v1.2.3")
+
+	if want, got := "v1.2.3", info.Version; want != got {
+		t.Errorf("BuildVersion().Version=%q, want %q", got, want)
+	}
+	if want, got := runtime.GOOS+"/"+runtime.GOARCH, info.Platform; want != got {
+		t.Errorf("BuildVersion().Platform=%q, want %q", got, want)
+	}
+	if info.GoVersion == "" {
+		t.Errorf("BuildVersion().GoVersion is empty")
+	}
+}
+
+func TestVersionCommand(t *testing.T) {
+	info := cli.VersionInfo{
+		Version:   "v1.2.3",
+		Commit:    "abc123",
+		Date:      "2025-01-02T03:04:05Z",
+		GoVersion: "go1.25.4",
+		Platform:  "linux/amd64",
+	}
+
+	tests := []tc[any]{
+		{
+			name: "full",
+			args: []string{"version"},
+
+			wantOutbuf: `version:  v1.2.3
+commit:   abc123
+date:     2025-01-02T03:04:05Z
+go:       go1.25.4
+platform: linux/amd64
+`,
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "short",
+			args: []string{"version", "-output=short"},
+
+			wantOutbuf: "v1.2.3\n",
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "json",
+			args: []string{"version", "-o", "json"},
+
+			wantOutbuf: `{
+  "version": "v1.2.3",
+  "commit": "abc123",
+  "date": "2025-01-02T03:04:05Z",
+  "goVersion": "go1.25.4",
+  "platform": "linux/amd64"
+}
+`,
+			wantStatus: cli.ExitSuccess,
+		},
+		{
+			name: "invalid",
+			args: []string{"version", "-output=yaml"},
+
+			wantErrbuf: "usage: version [-output full|short|json]\ninvalid value \"yaml\" for flag output: must be one of \"full\", \"short\", or \"json\"\n",
+			wantStatus: cli.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, gotOutbuf, gotErrbuf, gotStatus := execTestCommand(t, cli.VersionCommand[any](info), nil, tt)
+
+			if want, got := tt.wantStatus, gotStatus; want != got {
+				t.Errorf("%s: cmd.Execute()=%v, want %v", tt.name, got, want)
+			}
+			if diff := cmp.Diff(tt.wantOutbuf, gotOutbuf); diff != "" {
+				t.Errorf("%s: cmd.Execute out buffer mismatch (-want +got):\n%s", tt.name, diff)
+			}
+			if diff := cmp.Diff(tt.wantErrbuf, gotErrbuf); diff != "" {
+				t.Errorf("%s: cmd.Execute err buffer mismatch (-want +got):\n%s", tt.name, diff)
+			}
+		})
+	}
+}
+
+func TestVersionCommand_persistentOutputFlag(t *testing.T) {
+	cmd := &cli.Command[any]{
+		Name: "foo",
+		Flags: func(fs *flag.FlagSet, _ any) {
+			cli.OutputFlag(fs, nil, "table")
+		},
+		Persistent: []string{"o", "output"},
+		Subcommands: []*cli.Command[any]{
+			cli.VersionCommand[any](cli.VersionInfo{Version: "v1.2.3", GoVersion: "go1.25.4", Platform: "linux/amd64"}),
+		},
+	}
+
+	tests := []struct {
+		args       []string
+		wantOutbuf string
+	}{
+		{
+			args:       []string{"foo", "version"},
+			wantOutbuf: "version:  v1.2.3\ngo:       go1.25.4\nplatform: linux/amd64\n",
+		},
+		{
+			args:       []string{"foo", "-o", "json", "version"},
+			wantOutbuf: "{\n  \"version\": \"v1.2.3\",\n  \"goVersion\": \"go1.25.4\",\n  \"platform\": \"linux/amd64\"\n}\n",
+		},
+		{
+			args:       []string{"foo", "version", "-output=go-template={{.Version}}"},
+			wantOutbuf: "v1.2.3",
+		},
+	}
+	for _, tt := range tests {
+		var outbuf, errbuf bytes.Buffer
+		e := &cli.Env[any]{Args: tt.args, Out: &outbuf, Err: &errbuf}
+		if got := cmd.Execute(t.Context(), e); got != cli.ExitSuccess {
+			t.Fatalf("%q: cmd.Execute()=%v, want %v; stderr: %s", tt.args, got, cli.ExitSuccess, errbuf.String())
+		}
+		if diff := cmp.Diff(tt.wantOutbuf, outbuf.String()); diff != "" {
+			t.Errorf("%q: output mismatch (-want +got):\n%s", tt.args, diff)
+		}
+	}
+}